@@ -1,8 +1,6 @@
 package main
 
 import (
-	"fmt"
-	"html/template"
 	"log"
 	"net/http"
 	"time"
@@ -54,14 +52,11 @@ func main() {
 		})
 	})
 	
-	// g2 分组
-	g2 := e.Group("/g2")
-	g2.Use(onlyForG2()) { // g2 分组中间件
-		g2.GET("/hello/:name", func(c *zinc.Context) {
-			// /hello/zincRe
-			c.String(http.StatusOK, "hello %s, you're at %s\n", c.Param("name"), c.Path)
-		})
-	}
+	// g2 分组，链式设置分组中间件并注册路由
+	e.Group("/g2").Use(onlyForG2()).GET("/hello/:name", func(c *zinc.Context) {
+		// /hello/zincRe
+		c.String(http.StatusOK, "hello %s, you're at %s\n", c.Param("name"), c.Path)
+	})
 
 	// 启动HTTP服务
 	e.Run(":9999")
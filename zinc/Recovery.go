@@ -26,6 +26,105 @@ func trace(message string) string {
 	return str.String()
 }
 
+// PanicPolicy Recovery 捕获 panic 后的处理方式
+type PanicPolicy int
+
+const (
+	PanicJSON     PanicPolicy = iota // 以 500 状态码返回 JSON 错误（默认）
+	PanicHTML                        // 以 500 状态码渲染 HTML 错误页面
+	PanicClose                       // 不返回响应，直接关闭连接
+	PanicRepanic                     // 重新 panic，交给上层（如进程监管程序）处理
+	PanicDelegate                    // 由外层的 ErrorHandler 将 c.Errors 中的 *PanicError 转换为响应，没有 ErrorHandler 时按默认映射响应
+)
+
+// PanicError 由 Recovery 捕获的 panic，记录在 c.Errors 中。
+// panic 的值是 error 时可以通过 errors.Is 和 errors.As 匹配，如 panic(zinc.NewHTTPError(404, "", nil))
+// 经 ErrorHandler 处理后以 404 响应。
+type PanicError struct {
+	Value interface{} // panic 的原始值
+	Stack string      // 堆栈信息
+}
+
+// Error 方法返回 panic 的描述
+func (e *PanicError) Error() string {
+	return "panic: " + panicMessage(e.Value)
+}
+
+// Unwrap 方法在 panic 的值是 error 时返回该值
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// panicMessage 返回 panic 的值的描述：error 和 fmt.Stringer 使用其方法，其他非字符串的值包含类型和字段
+func panicMessage(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	}
+	return fmt.Sprintf("%T %+v", v, v)
+}
+
+// PanicConfig 分组的 panic 处理配置
+type PanicConfig struct {
+	Policy   PanicPolicy
+	Template string // PanicHTML 渲染的模板名，模板数据为 H{"status": 500, "error": "Internal Server Error"}，panic 的值只写入日志和 PanicReporter；为空时返回纯文本错误
+}
+
+// Severity panic 上报的严重程度
+type Severity int
+
+const (
+	SeverityError    Severity = iota // 普通路由的 panic
+	SeverityCritical                 // 声明了 Critical 的路由的 panic
+)
+
+// PanicReporter 错误上报函数，err 为 panic 的值，stack 为堆栈信息；调用期间 c.Req 是按 Engine.Redactor 脱敏后的副本
+type PanicReporter func(c *Context, err interface{}, stack string, severity Severity)
+
+// OnPanic 方法设置分组（包括子分组）中路由发生 panic 时 Recovery 的处理方式
+func (group *RouterGroup) OnPanic(config PanicConfig) *RouterGroup {
+	group.engine.checkMutable("OnPanic")
+	group.engine.mu.Lock()
+	defer group.engine.mu.Unlock()
+	group.panicConfig = &config
+	return group
+}
+
+// panicConfig 方法返回匹配到的路由所在分组（或最近的上层分组）的 panic 处理配置
+func (c *Context) panicConfig() PanicConfig {
+	if c.route != nil {
+		for g := c.route.group; g != nil; g = g.parent {
+			if g.panicConfig != nil {
+				return *g.panicConfig
+			}
+		}
+	}
+	return PanicConfig{}
+}
+
+// reportPanic 将请求和堆栈信息打印在日志中并调用 Engine.PanicReporter，日志和上报只使用脱敏后的请求
+func reportPanic(c *Context, err interface{}, stack string, severity Severity) {
+	redactor := c.engine.redactor()
+	req, body := c.Req, c.body
+	c.Req, c.body = redactor.redactRequest(req, body)
+	defer func() {
+		c.Req, c.body = req, body
+	}()
+	if severity == SeverityCritical {
+		log.Printf("[CRITICAL] %s %s\n%s\n\n", c.Req.Method, c.Req.RequestURI, stack)
+	} else {
+		log.Printf("%s %s\n%s\n\n", c.Req.Method, c.Req.RequestURI, stack)
+	}
+	if c.engine != nil && c.engine.PanicReporter != nil {
+		c.engine.PanicReporter(c, err, stack, severity)
+	}
+}
+
 // 错误处理中间件
 func Recovery() HandlerFunc {
 	return func(c *Context) {
@@ -33,12 +132,46 @@ func Recovery() HandlerFunc {
 		defer func() {
 			// 捕获 panic
 			if err := recover(); err != nil {
-				message := fmt.Sprintf("%s", err)
-				// 将堆栈信息打印在日志中
+				if err == http.ErrAbortHandler {
+					// 有意中止的请求（如流式响应中途出错）交给 net/http 断开连接
+					panic(err)
+				}
+				message := panicMessage(err)
 				// trace 获取触发 panic 的堆栈信息
-				log.Printf("%s\n\n", trace(message))
-				// 向用户返回 Internal Server Error
-				c.Fail(http.StatusInternalServerError, "Internal Server Error")
+				stack := trace(message)
+				// 保留 panic 的原始值，供 ErrorHandler 等通过 errors.As 处理
+				panicErr := &PanicError{Value: err, Stack: stack}
+				c.Error(panicErr)
+				severity := SeverityError
+				if c.route != nil && c.route.critical {
+					severity = SeverityCritical
+				}
+				reportPanic(c, err, stack, severity)
+
+				config := c.panicConfig()
+				switch config.Policy {
+				case PanicRepanic:
+					panic(err)
+				case PanicClose:
+					// net/http 收到 ErrAbortHandler 时不记录日志，直接关闭连接
+					panic(http.ErrAbortHandler)
+				case PanicDelegate:
+					// 外层没有 ErrorHandler 时按默认映射返回响应，不留下空的 200 响应
+					c.abortWithError(panicErr)
+				case PanicHTML:
+					c.Abort()
+					if config.Template == "" || c.engine.htmlTemplates == nil {
+						c.String(http.StatusInternalServerError, "Internal Server Error")
+						return
+					}
+					c.HTML(http.StatusInternalServerError, config.Template, H{
+						"status": http.StatusInternalServerError,
+						"error":  http.StatusText(http.StatusInternalServerError),
+					})
+				default:
+					// 向用户返回 Internal Server Error
+					c.Fail(http.StatusInternalServerError, "Internal Server Error")
+				}
 			}
 		}()
 		// 执行后面的中间件或Handler
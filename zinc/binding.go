@@ -0,0 +1,389 @@
+package zinc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// BindError 绑定请求数据失败的原因，可以直接作为 400 响应的内容返回给客户端
+type BindError struct {
+	Field   string `json:"field,omitempty"` // 出错的字段，如 address.city；与具体字段无关时为空
+	Reason  string `json:"reason"`          // 错误类型：empty_body、syntax、type、unknown_field、invalid 或 duplicate
+	Message string `json:"message"`         // 错误描述
+	Err     error  `json:"-"`               // 原始错误
+}
+
+// BindError 的错误类型
+const (
+	ReasonEmptyBody    = "empty_body"
+	ReasonSyntax       = "syntax"
+	ReasonType         = "type"
+	ReasonUnknownField = "unknown_field"
+	ReasonInvalid      = "invalid"
+	ReasonDuplicate    = "duplicate"
+)
+
+// Error 方法返回错误描述
+func (e *BindError) Error() string {
+	if e.Field != "" {
+		return e.Field + ": " + e.Message
+	}
+	return e.Message
+}
+
+// Unwrap 方法返回原始错误
+func (e *BindError) Unwrap() error {
+	return e.Err
+}
+
+// jsonBindError 将 encoding/json 的解码错误转换为 BindError
+func jsonBindError(err error) *BindError {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, io.EOF):
+		return &BindError{Reason: ReasonEmptyBody, Message: "request body is empty", Err: err}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return &BindError{Reason: ReasonSyntax, Message: "request body is truncated", Err: err}
+	case errors.As(err, &syntaxErr):
+		return &BindError{Reason: ReasonSyntax, Message: syntaxErr.Error(), Err: err}
+	case errors.As(err, &typeErr):
+		return &BindError{Field: typeErr.Field, Reason: ReasonType, Message: "expected " + typeErr.Type.String() + ", got " + typeErr.Value, Err: err}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json 没有为未知字段定义错误类型
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return &BindError{Field: field, Reason: ReasonUnknownField, Message: "unknown field", Err: err}
+	}
+	return &BindError{Reason: ReasonInvalid, Message: err.Error(), Err: err}
+}
+
+// ShouldBindJSON 方法将JSON请求体解码到 obj 中，开启 Engine.DisallowUnknownFields 时请求体中出现 obj 未声明的字段会返回错误。
+// 解码失败时返回 *BindError。
+func (c *Context) ShouldBindJSON(obj interface{}) error {
+	return c.ShouldBindWith(obj, JSONBinding)
+}
+
+// BindJSON 方法与 ShouldBindJSON 相同，解码失败时记录错误（c.Error）并以 400 状态码中止请求，
+// 响应中包含 BindError 的 field、reason 和 message
+func (c *Context) BindJSON(obj interface{}) error {
+	err := c.ShouldBindJSON(obj)
+	if err != nil {
+		c.abortBinding(err)
+	}
+	return err
+}
+
+// ShouldBindWith 方法使用指定的格式 b 将请求体解码到 obj 中，并按 validate 标签校验（见 RegisterValidator），
+// 解码或校验失败时返回 *BindError；请求体超过 Engine.MaxBodyBytes 时返回的错误匹配 ErrRequestBodyTooLarge
+func (c *Context) ShouldBindWith(obj interface{}, b Binding) error {
+	if c.Req.Body == nil || c.Req.Body == http.NoBody {
+		return &BindError{Reason: ReasonEmptyBody, Message: "request body is empty", Err: io.EOF}
+	}
+	if _, ok := b.(bufferedBinding); ok {
+		return c.ShouldBindBodyWith(obj, b)
+	}
+	limit := c.engine.maxBodyBytes()
+	if c.Req.ContentLength > limit {
+		return bodyTooLargeError()
+	}
+	body := &limitedBody{Reader: http.MaxBytesReader(c.Writer, c.Req.Body, limit), limit: limit}
+	if err := c.decodeBody(body, obj, b); err != nil {
+		// 各格式的解码器包装读取错误的方式不同，按读取的字节数判断是否超出上限
+		if body.exceeded {
+			return bodyTooLargeError()
+		}
+		return err
+	}
+	return nil
+}
+
+// bodyTooLargeError 返回请求体超出上限时的 BindError，它匹配 ErrRequestBodyTooLarge
+func bodyTooLargeError() *BindError {
+	return &BindError{Reason: ReasonInvalid, Message: ErrRequestBodyTooLarge.Error(), Err: ErrRequestBodyTooLarge}
+}
+
+// limitedBody 包装 http.MaxBytesReader，记录请求体是否超出了 limit 字节
+type limitedBody struct {
+	io.Reader
+	limit    int64
+	read     int64
+	exceeded bool
+}
+
+// Read 方法读取请求体，MaxBytesReader 读满 limit 字节后仍有数据时返回错误
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.Reader.Read(p)
+	b.read += int64(n)
+	if err != nil && err != io.EOF && b.read >= b.limit {
+		b.exceeded = true
+	}
+	return n, err
+}
+
+// decodeBody 方法使用格式 b 将 body 解码到 obj 中并校验
+func (c *Context) decodeBody(body io.Reader, obj interface{}, b Binding) error {
+	if b == MsgPackBinding {
+		// 默认的 MessagePack 格式使用 Engine.MsgPackCodec
+		b = codecBinding{name: "msgpack", codec: c.msgpack()}
+	}
+	strict := c.engine != nil && c.engine.DisallowUnknownFields
+	if err := b.Bind(body, obj, strict); err != nil {
+		return err
+	}
+	return validateStruct(obj, b.Name())
+}
+
+// ShouldBindBodyWith 方法与 ShouldBindWith 相同，但第一次调用时将请求体读入内存保存在 Context 中，
+// 之后可以多次以不同的格式绑定，如中间件先绑定请求体校验签名，Handler 再绑定到业务结构体。
+// 读取后 c.Req.Body 也被替换为缓存的内容，之后的 ShouldBindJSON 等仍可以读取一次。
+// 请求体超过 Engine.MaxBodyBytes 时返回的错误匹配 ErrRequestBodyTooLarge，BindBodyWith 以 413 状态码中止请求。
+func (c *Context) ShouldBindBodyWith(obj interface{}, b Binding) error {
+	body, err := c.bufferBody()
+	if errors.Is(err, ErrRequestBodyTooLarge) {
+		return bodyTooLargeError()
+	}
+	if err != nil {
+		return &BindError{Reason: ReasonInvalid, Message: err.Error(), Err: err}
+	}
+	if len(body) == 0 {
+		return &BindError{Reason: ReasonEmptyBody, Message: "request body is empty", Err: io.EOF}
+	}
+	return c.decodeBody(bytes.NewReader(body), obj, b)
+}
+
+// BindBodyWith 方法与 ShouldBindBodyWith 相同，绑定失败时以 400 状态码中止请求，请求体过大时为 413
+func (c *Context) BindBodyWith(obj interface{}, b Binding) error {
+	err := c.ShouldBindBodyWith(obj, b)
+	if err != nil {
+		c.abortBinding(err)
+	}
+	return err
+}
+
+// bufferBody 方法第一次调用时读取整个请求体并保存在 c.body 中，返回保存的请求体。
+// 请求体通过 http.MaxBytesReader 读取，超过 Engine.MaxBodyBytes 时返回 ErrRequestBodyTooLarge，请求体不会被保存
+func (c *Context) bufferBody() ([]byte, error) {
+	if !c.bodyBuffered {
+		limit := c.engine.maxBodyBytes()
+		if c.Req.ContentLength > limit {
+			return nil, ErrRequestBodyTooLarge
+		}
+		if c.Req.Body != nil && c.Req.Body != http.NoBody {
+			body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Req.Body, limit))
+			if err != nil {
+				// MaxBytesReader 读满 limit 字节后仍有数据时返回错误
+				if int64(len(body)) == limit {
+					return nil, ErrRequestBodyTooLarge
+				}
+				return nil, err
+			}
+			c.body = body
+		}
+		c.bodyBuffered = true
+	}
+	c.Req.Body = io.NopCloser(bytes.NewReader(c.body))
+	return c.body, nil
+}
+
+// ErrRequestBodyTooLarge 请求体超过 GetRawDataLimit 或 Engine.MaxBodyBytes 的上限时返回的错误
+var ErrRequestBodyTooLarge = errors.New("zinc: request body exceeds the size limit")
+
+// GetRawData 方法返回整个请求体，第一次调用时读入内存并保存在 Context 中，之后的 GetRawData、ShouldBindBodyWith
+// 直接使用保存的内容，c.Req.Body 也被替换为保存的内容，之后的 ShouldBindJSON 等仍可以读取一次；
+// 请求体超过 Engine.MaxBodyBytes 时返回 ErrRequestBodyTooLarge，需要其他上限时使用 GetRawDataLimit。
+// 用于校验 Webhook 签名等需要原始字节的场景：
+//
+//	payload, err := c.GetRawData()
+//	if err != nil || !validSignature(payload, c.GetHeader("X-Signature")) {
+//		c.Fail(http.StatusUnauthorized, "invalid signature")
+//		return
+//	}
+//	c.BindJSON(&event)
+func (c *Context) GetRawData() ([]byte, error) {
+	return c.bufferBody()
+}
+
+// GetRawDataLimit 方法与 GetRawData 相同，但请求体超过 limit 字节时返回 ErrRequestBodyTooLarge；
+// 此时请求体不会被保存，c.Req.Body 恢复为完整的请求体（已读取的部分加上剩余部分）
+func (c *Context) GetRawDataLimit(limit int64) ([]byte, error) {
+	if c.bodyBuffered || c.Req.Body == nil || c.Req.Body == http.NoBody {
+		body, err := c.bufferBody()
+		if err == nil && int64(len(body)) > limit {
+			return nil, ErrRequestBodyTooLarge
+		}
+		return body, err
+	}
+	if c.Req.ContentLength > limit {
+		return nil, ErrRequestBodyTooLarge
+	}
+	// 多读一个字节，用于判断请求体是否超出上限
+	head, err := io.ReadAll(io.LimitReader(c.Req.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(head)) > limit {
+		c.Req.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(head), c.Req.Body), Closer: c.Req.Body}
+		return nil, ErrRequestBodyTooLarge
+	}
+	c.body, c.bodyBuffered = head, true
+	c.Req.Body = io.NopCloser(bytes.NewReader(c.body))
+	return c.body, nil
+}
+
+// readCloser 组合 Reader 和原请求体的 Closer
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// BindWith 方法与 ShouldBindWith 相同，解码失败时以 400 状态码中止请求
+func (c *Context) BindWith(obj interface{}, b Binding) error {
+	err := c.ShouldBindWith(obj, b)
+	if err != nil {
+		c.abortBinding(err)
+	}
+	return err
+}
+
+// ShouldBindXML 方法将XML请求体解码到 obj 中，见 ShouldBindWith
+func (c *Context) ShouldBindXML(obj interface{}) error {
+	return c.ShouldBindWith(obj, XMLBinding)
+}
+
+// BindXML 方法将XML请求体解码到 obj 中，失败时以 400 状态码中止请求
+func (c *Context) BindXML(obj interface{}) error {
+	return c.BindWith(obj, XMLBinding)
+}
+
+// ShouldBindYAML 方法将YAML请求体解码到 obj 中，见 ShouldBindWith
+func (c *Context) ShouldBindYAML(obj interface{}) error {
+	return c.ShouldBindWith(obj, YAMLBinding)
+}
+
+// BindYAML 方法将YAML请求体解码到 obj 中，失败时以 400 状态码中止请求
+func (c *Context) BindYAML(obj interface{}) error {
+	return c.BindWith(obj, YAMLBinding)
+}
+
+// ShouldBindTOML 方法将TOML请求体解码到 obj 中，见 ShouldBindWith
+func (c *Context) ShouldBindTOML(obj interface{}) error {
+	return c.ShouldBindWith(obj, TOMLBinding)
+}
+
+// BindTOML 方法将TOML请求体解码到 obj 中，失败时以 400 状态码中止请求
+func (c *Context) BindTOML(obj interface{}) error {
+	return c.BindWith(obj, TOMLBinding)
+}
+
+// ShouldBindProtoBuf 方法将 Protocol Buffers 请求体（application/x-protobuf）解码到 msg 中，见 ShouldBindWith
+func (c *Context) ShouldBindProtoBuf(msg proto.Message) error {
+	return c.ShouldBindWith(msg, ProtoBufBinding)
+}
+
+// BindProtoBuf 方法将 Protocol Buffers 请求体解码到 msg 中，失败时以 400 状态码中止请求
+func (c *Context) BindProtoBuf(msg proto.Message) error {
+	return c.BindWith(msg, ProtoBufBinding)
+}
+
+// ShouldBindBody 方法按请求的 Content-Type 选择格式（JSON、XML、YAML、TOML、Protocol Buffers、MessagePack）解码请求体，
+// 没有 Content-Type 时按JSON解码，不支持的 Content-Type 返回错误
+func (c *Context) ShouldBindBody(obj interface{}) error {
+	b, ok := bindingFor(c.requestHeader("Content-Type"))
+	if !ok {
+		return &BindError{Reason: ReasonInvalid, Message: "unsupported content type " + c.requestHeader("Content-Type")}
+	}
+	return c.ShouldBindWith(obj, b)
+}
+
+// BindBody 方法与 ShouldBindBody 相同，解码失败时以 400 状态码中止请求
+func (c *Context) BindBody(obj interface{}) error {
+	err := c.ShouldBindBody(obj)
+	if err != nil {
+		c.abortBinding(err)
+	}
+	return err
+}
+
+// abortBinding 方法记录绑定错误并以 400 状态码中止请求
+func (c *Context) abortBinding(err error) {
+	c.Error(err)
+	if errors.Is(err, ErrRequestBodyTooLarge) {
+		c.Fail(http.StatusRequestEntityTooLarge, "Request Entity Too Large")
+		return
+	}
+	var bindErr *BindError
+	if !errors.As(err, &bindErr) || c.envelope != nil {
+		c.Fail(http.StatusBadRequest, err.Error())
+		return
+	}
+	c.Abort()
+	if c.engine != nil && c.engine.ProblemJSON {
+		c.Problem(http.StatusBadRequest, ProblemDetails{
+			Detail:     bindErr.Error(),
+			Instance:   c.Path,
+			Extensions: map[string]interface{}{"field": bindErr.Field, "reason": bindErr.Reason},
+		})
+		return
+	}
+	c.JSON(http.StatusBadRequest, H{"message": bindErr.Error(), "field": bindErr.Field, "reason": bindErr.Reason})
+}
+
+// defaultMultipartMemory 解析 multipart 表单时保存在内存中的最大字节数，超出部分写入临时文件
+const defaultMultipartMemory = 32 << 20
+
+// ShouldBindForm 方法将表单字段（包括URL查询参数，请求体中的同名字段优先）按 form 标签绑定到 obj 中，
+// 支持 application/x-www-form-urlencoded 和 multipart/form-data 请求体，支持的类型与 ShouldBindQuery 相同
+func (c *Context) ShouldBindForm(obj interface{}) error {
+	if err := c.Req.ParseMultipartForm(defaultMultipartMemory); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		return &BindError{Reason: ReasonSyntax, Message: err.Error(), Err: err}
+	}
+	if err := mapForm(obj, formValues(c.Req.Form), "form", c.queryPolicy(), nil); err != nil {
+		return err
+	}
+	return validateStruct(obj, "form")
+}
+
+// BindForm 方法与 ShouldBindForm 相同，绑定失败时以 400 状态码中止请求
+func (c *Context) BindForm(obj interface{}) error {
+	err := c.ShouldBindForm(obj)
+	if err != nil {
+		c.abortBinding(err)
+	}
+	return err
+}
+
+// ShouldBind 方法按请求方法和 Content-Type 选择绑定方式：
+// 没有请求体的 GET、HEAD、DELETE 请求绑定查询参数（ShouldBindQuery），
+// 表单请求体绑定表单字段（ShouldBindForm），其他请求体按 ShouldBindBody 解码（JSON、XML、YAML、TOML）。
+func (c *Context) ShouldBind(obj interface{}) error {
+	switch c.Method {
+	case http.MethodGet, http.MethodHead, http.MethodDelete:
+		if c.Req.ContentLength <= 0 && (c.Req.Body == nil || c.Req.Body == http.NoBody) {
+			return c.ShouldBindQuery(obj)
+		}
+	}
+	contentType := c.requestHeader("Content-Type")
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") || strings.HasPrefix(contentType, "multipart/form-data") {
+		return c.ShouldBindForm(obj)
+	}
+	return c.ShouldBindBody(obj)
+}
+
+// MustBind 方法与 ShouldBind 相同，绑定失败时以 400 状态码中止请求，处理函数只需检查返回值后返回：
+//
+//	if c.MustBind(&req) != nil {
+//		return
+//	}
+func (c *Context) MustBind(obj interface{}) error {
+	err := c.ShouldBind(obj)
+	if err != nil {
+		c.abortBinding(err)
+	}
+	return err
+}
@@ -0,0 +1,188 @@
+package zinc
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v3"
+)
+
+// Binding 请求体的解码格式，用于 c.ShouldBindWith 和 c.BindWith
+type Binding interface {
+	// Name 方法返回格式的名称，如 json
+	Name() string
+	// Bind 方法将 body 解码到 obj 中，strict 为 true 时出现 obj 未声明的字段返回错误；失败时返回 *BindError
+	Bind(body io.Reader, obj interface{}, strict bool) error
+}
+
+// bufferedBinding 需要先将整个请求体读入内存再解码的格式，
+// ShouldBindWith 通过 ShouldBindBodyWith 读取请求体，不超过 Engine.MaxBodyBytes
+type bufferedBinding interface {
+	Binding
+	buffered()
+}
+
+// 内置的请求体格式
+var (
+	JSONBinding     Binding = jsonBinding{}
+	XMLBinding      Binding = xmlBinding{}
+	YAMLBinding     Binding = yamlBinding{}
+	TOMLBinding     Binding = tomlBinding{}
+	ProtoBufBinding Binding = protobufBinding{}
+)
+
+// bindingFor 按 Content-Type 返回请求体格式，Content-Type 为空时为 JSON
+func bindingFor(contentType string) (Binding, bool) {
+	if contentType == "" {
+		return JSONBinding, true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return JSONBinding, true
+	case mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		return XMLBinding, true
+	case mediaType == "application/yaml" || mediaType == "application/x-yaml" || mediaType == "text/yaml":
+		return YAMLBinding, true
+	case mediaType == "application/toml":
+		return TOMLBinding, true
+	case mediaType == "application/x-protobuf" || mediaType == "application/protobuf":
+		return ProtoBufBinding, true
+	case mediaType == "application/msgpack" || mediaType == "application/x-msgpack":
+		return MsgPackBinding, true
+	}
+	return nil, false
+}
+
+type jsonBinding struct{}
+
+func (jsonBinding) Name() string {
+	return "json"
+}
+
+func (jsonBinding) Bind(body io.Reader, obj interface{}, strict bool) error {
+	decoder := json.NewDecoder(body)
+	if strict {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(obj); err != nil {
+		return jsonBindError(err)
+	}
+	return nil
+}
+
+// xmlBinding XML格式，encoding/xml 会忽略未声明的元素，strict 对其无效
+type xmlBinding struct{}
+
+func (xmlBinding) Name() string {
+	return "xml"
+}
+
+func (xmlBinding) Bind(body io.Reader, obj interface{}, strict bool) error {
+	err := xml.NewDecoder(body).Decode(obj)
+	if err == nil {
+		return nil
+	}
+	var syntaxErr *xml.SyntaxError
+	switch {
+	case errors.Is(err, io.EOF):
+		return &BindError{Reason: ReasonEmptyBody, Message: "request body is empty", Err: err}
+	case errors.As(err, &syntaxErr):
+		return &BindError{Reason: ReasonSyntax, Message: syntaxErr.Error(), Err: err}
+	}
+	// encoding/xml 的类型转换错误为 strconv.NumError 等，没有字段信息
+	return &BindError{Reason: ReasonType, Message: err.Error(), Err: err}
+}
+
+type yamlBinding struct{}
+
+func (yamlBinding) Name() string {
+	return "yaml"
+}
+
+func (yamlBinding) Bind(body io.Reader, obj interface{}, strict bool) error {
+	decoder := yaml.NewDecoder(body)
+	decoder.KnownFields(strict)
+	err := decoder.Decode(obj)
+	if err == nil {
+		return nil
+	}
+	var typeErr *yaml.TypeError
+	switch {
+	case errors.Is(err, io.EOF):
+		return &BindError{Reason: ReasonEmptyBody, Message: "request body is empty", Err: err}
+	case errors.As(err, &typeErr):
+		// 未知字段也以 TypeError 报告，如 "line 3: field extra not found in type main.User"
+		message := strings.Join(typeErr.Errors, "; ")
+		for _, e := range typeErr.Errors {
+			if i := strings.Index(e, "field "); i >= 0 && strings.Contains(e, " not found in type ") {
+				field := strings.TrimPrefix(e[i:], "field ")
+				field = field[:strings.Index(field, " not found")]
+				return &BindError{Field: field, Reason: ReasonUnknownField, Message: "unknown field", Err: err}
+			}
+		}
+		return &BindError{Reason: ReasonType, Message: message, Err: err}
+	}
+	return &BindError{Reason: ReasonSyntax, Message: err.Error(), Err: err}
+}
+
+type tomlBinding struct{}
+
+func (tomlBinding) Name() string {
+	return "toml"
+}
+
+func (tomlBinding) Bind(body io.Reader, obj interface{}, strict bool) error {
+	meta, err := toml.NewDecoder(body).Decode(obj)
+	if err != nil {
+		var parseErr toml.ParseError
+		if errors.As(err, &parseErr) {
+			return &BindError{Reason: ReasonSyntax, Message: parseErr.Error(), Err: err}
+		}
+		return &BindError{Reason: ReasonType, Message: err.Error(), Err: err}
+	}
+	if undecoded := meta.Undecoded(); strict && len(undecoded) > 0 {
+		return &BindError{Field: undecoded[0].String(), Reason: ReasonUnknownField, Message: "unknown field"}
+	}
+	if len(meta.Keys()) == 0 {
+		return &BindError{Reason: ReasonEmptyBody, Message: "request body is empty", Err: io.EOF}
+	}
+	return nil
+}
+
+// protobufBinding Protocol Buffers 格式，obj 必须实现 proto.Message；未声明的字段按 proto 的规则保留，strict 对其无效
+type protobufBinding struct{}
+
+func (protobufBinding) Name() string {
+	return "protobuf"
+}
+
+func (protobufBinding) buffered() {}
+
+func (protobufBinding) Bind(body io.Reader, obj interface{}, strict bool) error {
+	msg, ok := obj.(proto.Message)
+	if !ok {
+		return &BindError{Reason: ReasonInvalid, Message: fmt.Sprintf("%T is not a proto.Message", obj)}
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return &BindError{Reason: ReasonInvalid, Message: err.Error(), Err: err}
+	}
+	if len(data) == 0 {
+		return &BindError{Reason: ReasonEmptyBody, Message: "request body is empty", Err: io.EOF}
+	}
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return &BindError{Reason: ReasonSyntax, Message: err.Error(), Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package zinc
+
+import (
+	"net/http"
+	"strings"
+)
+
+// defaultMaxBodyBytes Engine.MaxBodyBytes 为 0 时读取请求体的字节数上限
+const defaultMaxBodyBytes = 10 << 20
+
+// maxBodyBytes 方法返回框架读取整个请求体时的字节数上限
+func (engine *Engine) maxBodyBytes() int64 {
+	if engine == nil || engine.MaxBodyBytes <= 0 {
+		return defaultMaxBodyBytes
+	}
+	return engine.MaxBodyBytes
+}
+
+// ExpectsContinue 方法判断客户端是否在发送请求体之前等待 100 Continue（请求头部 Expect: 100-continue）。
+//
+// net/http 在第一次读取请求体时才向客户端发送 100 Continue，
+// 所以鉴权、请求体大小限制等中间件只要在读取请求体之前调用 Fail 拒绝请求，
+// 客户端就会直接收到 401/413 等最终响应而不会上传请求体。
+func (c *Context) ExpectsContinue() bool {
+	return strings.EqualFold(c.requestHeader("Expect"), "100-continue")
+}
+
+// BodyLimit 是请求体大小限制中间件的构造函数。
+// 声明的 Content-Length 超过 limit 时不读取请求体，直接以 413 状态码拒绝请求（对 Expect: 100-continue 请求即拒绝握手）；
+// 否则将请求体包装为最多只能读取 limit 字节的 Reader，防止未声明长度的请求体超出限制。
+func BodyLimit(limit int64) HandlerFunc {
+	return func(c *Context) {
+		if c.Req.ContentLength > limit {
+			// 请求体没有被读取，告知客户端关闭连接，避免继续上传
+			c.SetHeader("Connection", "close")
+			c.Fail(http.StatusRequestEntityTooLarge, "Request Entity Too Large")
+			return
+		}
+		if c.Req.Body != nil {
+			c.Req.Body = http.MaxBytesReader(c.Writer, c.Req.Body, limit)
+		}
+		c.Next()
+	}
+}
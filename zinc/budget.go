@@ -0,0 +1,226 @@
+package zinc
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrResponseTooLarge 响应体超过 Budget.MaxBytes 后继续写出时返回的错误
+var ErrResponseTooLarge = errors.New("zinc: response exceeds the size budget")
+
+// ErrResponseTooSlow 生成响应超过 Budget.MaxDuration 后继续写出时返回的错误
+var ErrResponseTooSlow = errors.New("zinc: response exceeds the time budget")
+
+// Budget 响应的大小和时间预算，用于防止导出等接口失控
+type Budget struct {
+	// MaxBytes 响应体的字节数上限，为 0 时不限制；超过时以 500 状态码中止响应
+	MaxBytes int64
+	// MaxDuration 生成响应的时间上限，为 0 时不限制；超过时以 503 状态码中止响应，并取消请求的 context
+	MaxDuration time.Duration
+	// Counters 非空时记录超出预算的次数
+	Counters *BudgetCounters
+}
+
+// BudgetCounters 超出响应预算的计数器，字段需要通过 Load 方法读取
+type BudgetCounters struct {
+	OversizedResponse int64 // 响应体超过 MaxBytes
+	SlowResponse      int64 // 生成响应超过 MaxDuration
+}
+
+// Load 方法返回计数器当前值的副本
+func (s *BudgetCounters) Load() BudgetCounters {
+	return BudgetCounters{
+		OversizedResponse: atomic.LoadInt64(&s.OversizedResponse),
+		SlowResponse:      atomic.LoadInt64(&s.SlowResponse),
+	}
+}
+
+// Budget 方法为路由设置响应预算，预算检查在路由的其他中间件和 Handler 之前开始，
+// 如：g.GET("/export", export).Budget(zinc.Budget{MaxBytes: 10 << 20, MaxDuration: 30 * time.Second})
+func (route *Route) Budget(budget Budget) *Route {
+	return route.update("Route.Budget", func() {
+		route.budget = &budget
+	})
+}
+
+// ResponseBudget 是响应预算中间件的构造函数。
+// 超出预算时，还没有写出响应的以 500（大小）或 503（时间）状态码响应；已经开始写出的响应被截断，
+// 之后的写入返回 ErrResponseTooLarge 或 ErrResponseTooSlow。超出预算会记录日志并增加 Counters 中的计数。
+func ResponseBudget(budget Budget) HandlerFunc {
+	counters := budget.Counters
+	if counters == nil {
+		counters = &BudgetCounters{}
+	}
+	return func(c *Context) {
+		origin := c.Writer
+		writer := &budgetWriter{ResponseWriter: origin, limit: budget.MaxBytes, counters: counters}
+		c.Writer = writer
+		defer func() {
+			c.Writer = origin
+		}()
+
+		if budget.MaxDuration > 0 {
+			writer.deadline = time.Now().Add(budget.MaxDuration)
+			_, cancel := c.WithTimeout(budget.MaxDuration)
+			timer := time.AfterFunc(budget.MaxDuration, func() {
+				writer.exceed(ErrResponseTooSlow)
+				cancel()
+			})
+			defer func() {
+				timer.Stop()
+				cancel()
+			}()
+		}
+
+		c.Next()
+
+		if code, err := writer.finish(); err != nil {
+			log.Printf("%v: %s %s", err, c.Method, c.Path)
+			if code != 0 {
+				c.StatusCode = code
+			}
+		}
+	}
+}
+
+// budgetWriter 统计响应体大小、超出预算后拒绝写入的 http.ResponseWriter。
+// 状态码延迟到第一次写出数据时发送，以便响应体一开始就超出预算时仍然可以改为错误响应。
+// 超时回调只记录超出预算，错误响应总是由处理函数所在的 goroutine 在下一次写入或 finish 时写出。
+type budgetWriter struct {
+	http.ResponseWriter
+	mu       sync.Mutex // 超时回调与处理函数并发访问
+	limit    int64
+	written  int64
+	status   int       // 等待发送的状态码
+	sent     bool      // 状态码是否已经发送
+	done     bool      // finish 之后为true，超时回调不再记录超出预算
+	deadline time.Time // 设置了 MaxDuration 时的截止时间，写入时检查，不依赖超时回调的执行顺序
+	err      error     // 超出预算的原因
+	code     int       // 超出预算时写出的状态码，已经开始写出响应时为 0
+	counters *BudgetCounters
+}
+
+// exceed 方法记录超出预算，由超时回调调用
+func (w *budgetWriter) exceed(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.exceedLocked(err)
+}
+
+// exceedLocked 方法是持有 mu 时调用的 exceed，只记录原因和计数，不写出响应
+func (w *budgetWriter) exceedLocked(err error) {
+	if w.err != nil || w.done {
+		return
+	}
+	w.err = err
+	counter := &w.counters.OversizedResponse
+	if err == ErrResponseTooSlow {
+		counter = &w.counters.SlowResponse
+	}
+	atomic.AddInt64(counter, 1)
+}
+
+// checkDeadlineLocked 方法在超过截止时间时记录超出预算，需要持有 mu
+func (w *budgetWriter) checkDeadlineLocked() {
+	if !w.deadline.IsZero() && !time.Now().Before(w.deadline) {
+		w.exceedLocked(ErrResponseTooSlow)
+	}
+}
+
+// failLocked 方法在超出预算、还没有写出响应时以对应的状态码响应，需要持有 mu 并在处理函数所在的 goroutine 中调用
+func (w *budgetWriter) failLocked() {
+	if w.sent {
+		return
+	}
+	code := http.StatusInternalServerError
+	if w.err == ErrResponseTooSlow {
+		code = http.StatusServiceUnavailable
+	}
+	w.code = code
+	w.sent = true
+	header := w.ResponseWriter.Header()
+	header.Del("Content-Length")
+	header.Del("Content-Encoding")
+	header.Set("Content-Type", "text/plain; charset=utf-8")
+	w.ResponseWriter.WriteHeader(code)
+	w.ResponseWriter.Write([]byte(http.StatusText(code)))
+}
+
+// sendStatus 方法发送等待中的状态码，需要持有 mu
+func (w *budgetWriter) sendStatus() {
+	if w.sent {
+		return
+	}
+	w.sent = true
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
+
+// finish 方法在处理函数返回后发送等待中的状态码（没有响应体的响应）或错误响应，返回超出预算的原因和写出的状态码。
+// 之后超时回调不再修改响应
+func (w *budgetWriter) finish() (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.checkDeadlineLocked()
+	w.done = true
+	if w.err != nil {
+		w.failLocked()
+	} else {
+		w.sendStatus()
+	}
+	return w.code, w.err
+}
+
+// WriteHeader 方法记录状态码，1xx 状态码直接发送
+func (w *budgetWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil || w.sent {
+		return
+	}
+	if code < http.StatusOK {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+// Write 方法在没有超出预算时写出数据
+func (w *budgetWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.checkDeadlineLocked()
+	if w.limit > 0 && w.err == nil && w.written+int64(len(data)) > w.limit {
+		w.exceedLocked(ErrResponseTooLarge)
+	}
+	if w.err != nil {
+		w.failLocked()
+		return 0, w.err
+	}
+	w.sendStatus()
+	n, err := w.ResponseWriter.Write(data)
+	w.written += int64(n)
+	return n, err
+}
+
+// Flush 方法发送状态码和已写出的数据
+func (w *budgetWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.checkDeadlineLocked()
+	if w.err != nil {
+		w.failLocked()
+	} else {
+		w.sendStatus()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
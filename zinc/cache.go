@@ -0,0 +1,104 @@
+package zinc
+
+import (
+	"container/list"
+	"sync"
+)
+
+// routeKey 路由缓存的键
+type routeKey struct {
+	r      *router
+	method string
+	path   string
+}
+
+// routeEntry 路由缓存的值：匹配到的node和解析出的参数
+type routeEntry struct {
+	key    routeKey
+	node   *node
+	params Params
+}
+
+// routeCache 有容量上限的 LRU 缓存，保存动态路由的查找结果
+type routeCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List                 // 按最近使用排列，最前面的最近被使用
+	entries  map[routeKey]*list.Element // 键到 order 中元素的索引
+}
+
+// newRouteCache 是 zinc.routeCache 的构造函数
+func newRouteCache(capacity int) *routeCache {
+	return &routeCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[routeKey]*list.Element),
+	}
+}
+
+// get 方法返回缓存的查找结果，并将其标记为最近使用
+func (cache *routeCache) get(key routeKey) (*routeEntry, bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	element, ok := cache.entries[key]
+	if !ok {
+		return nil, false
+	}
+	cache.order.MoveToFront(element)
+	return element.Value.(*routeEntry), true
+}
+
+// add 方法缓存查找结果，超出容量时淘汰最久未使用的结果
+func (cache *routeCache) add(entry *routeEntry) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if element, ok := cache.entries[entry.key]; ok {
+		element.Value = entry
+		cache.order.MoveToFront(element)
+		return
+	}
+	cache.entries[entry.key] = cache.order.PushFront(entry)
+	if cache.order.Len() > cache.capacity {
+		oldest := cache.order.Back()
+		cache.order.Remove(oldest)
+		delete(cache.entries, oldest.Value.(*routeEntry).key)
+	}
+}
+
+// purge 方法清空缓存
+func (cache *routeCache) purge() {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.order.Init()
+	cache.entries = make(map[routeKey]*list.Element)
+}
+
+// cachedLookup 方法在 r 中查找 path，开启 RouteCacheSize 时优先使用缓存的动态路由查找结果
+func (engine *Engine) cachedLookup(r *router, method string, path string, params *Params) *node {
+	if engine.RouteCacheSize <= 0 {
+		return r.lookup(method, path, engine.searchMode(), params)
+	}
+	engine.cacheOnce.Do(func() {
+		engine.routeCache = newRouteCache(engine.RouteCacheSize)
+	})
+
+	key := routeKey{r: r, method: method, path: path}
+	if entry, ok := engine.routeCache.get(key); ok {
+		*params = append(*params, entry.params...)
+		return entry.node
+	}
+	start := len(*params)
+	n := r.lookup(method, path, engine.searchMode(), params)
+	// 只缓存动态路由，静态路由由静态路由表直接索引；匹配失败的结果不缓存，避免随机路径占满缓存
+	if n != nil && len(n.paramNames) > 0 {
+		engine.routeCache.add(&routeEntry{key: key, node: n, params: append(Params(nil), (*params)[start:]...)})
+	}
+	return n
+}
+
+// invalidateRouteCache 方法在路由变化时清空路由缓存
+func (engine *Engine) invalidateRouteCache() {
+	if engine.routeCache != nil {
+		engine.routeCache.purge()
+	}
+}
@@ -0,0 +1,136 @@
+package zinc
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// CanaryConfig 路由灰度发布的配置，在同一个路由上按比例、Cookie 或头部将请求分给新旧两个 Handler
+type CanaryConfig struct {
+	// Percent 分给灰度 Handler 的请求比例（0-100），运行期间可以通过 Engine.SetCanaryPercent 或 Engine.SetCanary 调整
+	Percent int
+	// Header 非空时，请求头部为 canary 或 stable 的请求直接使用对应的 Handler，用于测试和排查
+	Header string
+	// Cookie 非空时灰度是粘性的：该 Cookie 保存随机的客户端标识，比例不变时同一客户端始终使用同一个 Handler
+	Cookie string
+	// Start、End 灰度生效的时间段，为零值时不限制；时间段之外的请求都使用原 Handler（Header 指定的除外）
+	Start, End time.Time
+}
+
+// 分流结果，也是 Header 的取值
+const (
+	canaryVariant = "canary"
+	stableVariant = "stable"
+)
+
+// canary 路由的灰度配置，创建后不再修改；运行期间调整配置时整体替换并重新计算处理函数链
+type canary struct {
+	handler HandlerFunc
+	config  CanaryConfig
+}
+
+// Canary 方法为路由添加灰度 Handler，按 config 将部分请求分给 handler，其余请求仍由注册时的 Handler 处理，
+// 如：g.GET("/search", search).Canary(searchV2, zinc.CanaryConfig{Percent: 5, Cookie: "search_canary"})
+func (route *Route) Canary(handler HandlerFunc, config CanaryConfig) *Route {
+	return route.update("Route.Canary", func() {
+		config.Percent = clampPercent(config.Percent)
+		route.canary = &canary{handler: handler, config: config}
+	})
+}
+
+// SetCanary 方法在服务运行期间替换路由的灰度配置（比例、时间段、Header、Cookie），可与请求处理并发调用；
+// 路由不存在或没有通过 Route.Canary 设置灰度时返回 false。
+func (engine *Engine) SetCanary(method string, pattern string, config CanaryConfig) bool {
+	return engine.updateCanary("SetCanary", method, pattern, func(current *CanaryConfig) {
+		*current = config
+	})
+}
+
+// SetCanaryPercent 方法在服务运行期间调整路由的灰度比例，可与请求处理并发调用；
+// 路由不存在或没有通过 Route.Canary 设置灰度时返回 false。
+// 设置了 Cookie 时客户端按 Cookie 中的标识分桶，提高比例后原来分到原 Handler 的部分客户端会转到灰度 Handler。
+func (engine *Engine) SetCanaryPercent(method string, pattern string, percent int) bool {
+	return engine.updateCanary("SetCanaryPercent", method, pattern, func(current *CanaryConfig) {
+		current.Percent = percent
+	})
+}
+
+// updateCanary 方法在持有 engine.mu 时用 fn 修改路由灰度配置的副本，替换后重新计算处理函数链
+func (engine *Engine) updateCanary(op string, method string, pattern string, fn func(config *CanaryConfig)) bool {
+	engine.checkOwner(op)
+	engine.mu.Lock()
+	defer engine.mu.Unlock()
+	for i := len(engine.routes) - 1; i >= 0; i-- {
+		route := engine.routes[i]
+		if route.Method == method && route.Pattern == pattern && route.canary != nil {
+			config := route.canary.config
+			fn(&config)
+			config.Percent = clampPercent(config.Percent)
+			route.canary = &canary{handler: route.canary.handler, config: config}
+			route.rebuild()
+			return true
+		}
+	}
+	return false
+}
+
+// clampPercent 将比例限制在 0-100 之间
+func clampPercent(percent int) int {
+	if percent < 0 {
+		return 0
+	}
+	if percent > 100 {
+		return 100
+	}
+	return percent
+}
+
+// split 返回按灰度配置在 stable 和灰度 Handler 之间分流的处理函数
+func (cn *canary) split(stable HandlerFunc) HandlerFunc {
+	return func(c *Context) {
+		if cn.choose(c) == canaryVariant {
+			cn.handler(c)
+			return
+		}
+		stable(c)
+	}
+}
+
+// choose 方法返回请求的分流结果：Header 指定的优先，时间段之外使用原 Handler，
+// 其余请求按 Cookie 中客户端标识的哈希值分到 0-99 的桶中，桶号小于当前比例的使用灰度 Handler；
+// 没有设置 Cookie 时每个请求随机分桶
+func (cn *canary) choose(c *Context) string {
+	if cn.config.Header != "" {
+		if v := c.requestHeader(cn.config.Header); v == canaryVariant || v == stableVariant {
+			return v
+		}
+	}
+
+	var bucket uint32
+	if cn.config.Cookie != "" {
+		// Cookie 只保存客户端标识而不保存分流结果，调整比例后按新比例重新分流
+		id := ""
+		if cookie, err := c.Req.Cookie(cn.config.Cookie); err == nil {
+			id = cookie.Value
+		}
+		if id == "" {
+			id = strconv.FormatUint(rand.Uint64(), 36)
+			http.SetCookie(c.Writer, &http.Cookie{Name: cn.config.Cookie, Value: id, Path: "/", HttpOnly: true, SameSite: http.SameSiteLaxMode})
+		}
+		h := fnv.New32a()
+		h.Write([]byte(id))
+		bucket = h.Sum32() % 100
+	} else {
+		bucket = uint32(rand.Int31n(100))
+	}
+
+	now := time.Now()
+	active := (cn.config.Start.IsZero() || !now.Before(cn.config.Start)) && (cn.config.End.IsZero() || now.Before(cn.config.End))
+	if active && bucket < uint32(cn.config.Percent) {
+		return canaryVariant
+	}
+	return stableVariant
+}
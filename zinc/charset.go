@@ -0,0 +1,27 @@
+package zinc
+
+// SetCharset 方法为本次响应指定字符集，覆盖 Engine.Charset，需要在 c.String、c.JSON 等渲染方法之前调用，
+// 如对只接受 GBK 的下游返回 text/plain; charset=gbk。
+// 字符集只影响 Content-Type 头部，响应内容需要由调用方按该字符集编码（JSON 应始终为 UTF-8）。
+func (c *Context) SetCharset(charset string) {
+	c.charset = charset
+}
+
+// responseCharset 方法返回本次响应的字符集，没有设置时为空
+func (c *Context) responseCharset() string {
+	if c.charset != "" {
+		return c.charset
+	}
+	if c.engine != nil {
+		return c.engine.Charset
+	}
+	return ""
+}
+
+// contentType 方法为 mediaType 附加本次响应的字符集
+func (c *Context) contentType(mediaType string) string {
+	if charset := c.responseCharset(); charset != "" {
+		return mediaType + "; charset=" + charset
+	}
+	return mediaType
+}
@@ -0,0 +1,138 @@
+package zinc
+
+import (
+	"fmt"
+	"text/template/parse"
+)
+
+// Diagnostic 配置自检发现的问题
+type Diagnostic struct {
+	Kind    string // 问题类型：conflict、empty-group、missing-template，LintRoutes 另有 shadowed、param-name、catch-all-mask、group-prefix
+	Message string // 问题描述
+}
+
+func (d Diagnostic) String() string {
+	return d.Kind + ": " + d.Message
+}
+
+// Check 方法在启动服务之前检查配置，返回发现的所有问题：
+// 相互覆盖的路由、没有注册任何路由的分组、模板中引用了但没有加载的模板。
+func (engine *Engine) Check() []Diagnostic {
+	var diagnostics []Diagnostic
+	diagnostics = append(diagnostics, engine.checkConflicts()...)
+	diagnostics = append(diagnostics, engine.checkGroups()...)
+	diagnostics = append(diagnostics, engine.checkTemplates()...)
+	return diagnostics
+}
+
+// checkConflicts 方法查找注册到前缀树同一节点的路由，后注册的路由会覆盖先注册的路由，
+// 如 /hello/:name 和 /hello/:id
+func (engine *Engine) checkConflicts() []Diagnostic {
+	var diagnostics []Diagnostic
+	for _, pair := range engine.overrides() {
+		route, first := pair[0], pair[1]
+		diagnostics = append(diagnostics, Diagnostic{
+			Kind:    "conflict",
+			Message: fmt.Sprintf("%s %s%s overrides %s%s", route.Method, route.Host, route.Pattern, first.Host, first.Pattern),
+		})
+	}
+	return diagnostics
+}
+
+// overrides 方法返回注册到前缀树同一节点的路由对：后注册的路由和被它覆盖的先注册的路由
+func (engine *Engine) overrides() [][2]*Route {
+	var pairs [][2]*Route
+	seen := make(map[string]*Route)
+	for _, route := range engine.routeList() {
+		key := fmt.Sprintf("%s-%s-%p", route.Host, route.Method, route.node)
+		if first, ok := seen[key]; ok {
+			pairs = append(pairs, [2]*Route{route, first})
+			continue
+		}
+		seen[key] = route
+	}
+	return pairs
+}
+
+// checkGroups 方法查找自身及下层分组都没有注册任何路由的分组
+func (engine *Engine) checkGroups() []Diagnostic {
+	var diagnostics []Diagnostic
+	for _, group := range engine.groupList() {
+		if group == engine.owner().RouterGroup || group.hasRoutes() {
+			continue
+		}
+		diagnostics = append(diagnostics, Diagnostic{
+			Kind:    "empty-group",
+			Message: fmt.Sprintf("group %s%s has no routes", group.host, group.prefix),
+		})
+	}
+	return diagnostics
+}
+
+// hasRoutes 方法判断 group 或其下层分组是否注册了路由
+func (group *RouterGroup) hasRoutes() bool {
+	for _, route := range group.engine.routeList() {
+		if route.group.inherits(group) {
+			return true
+		}
+	}
+	return false
+}
+
+// inherits 方法判断 group 是否为 ancestor 本身或其下层分组
+func (group *RouterGroup) inherits(ancestor *RouterGroup) bool {
+	for g := group; g != nil; g = g.parent {
+		if g == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// checkTemplates 方法查找已加载的模板中通过 {{template "name"}} 引用但没有加载的模板
+func (engine *Engine) checkTemplates() []Diagnostic {
+	var diagnostics []Diagnostic
+	if engine.htmlTemplates == nil {
+		return nil
+	}
+	for _, t := range engine.htmlTemplates.Templates() {
+		if t.Tree == nil {
+			continue
+		}
+		for _, name := range templateRefs(t.Tree.Root) {
+			if engine.htmlTemplates.Lookup(name) == nil {
+				diagnostics = append(diagnostics, Diagnostic{
+					Kind:    "missing-template",
+					Message: fmt.Sprintf("template %q references undefined template %q", t.Name(), name),
+				})
+			}
+		}
+	}
+	return diagnostics
+}
+
+// templateRefs 返回语法树 node 中所有 {{template "name"}} 引用的模板名
+func templateRefs(node parse.Node) []string {
+	var names []string
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			names = append(names, templateRefs(child)...)
+		}
+	case *parse.TemplateNode:
+		names = append(names, n.Name)
+	case *parse.IfNode:
+		names = append(names, templateRefs(n.List)...)
+		names = append(names, templateRefs(n.ElseList)...)
+	case *parse.RangeNode:
+		names = append(names, templateRefs(n.List)...)
+		names = append(names, templateRefs(n.ElseList)...)
+	case *parse.WithNode:
+		names = append(names, templateRefs(n.List)...)
+		names = append(names, templateRefs(n.ElseList)...)
+	}
+	return names
+}
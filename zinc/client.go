@@ -0,0 +1,109 @@
+package zinc
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// propagatedHeaders 是调用下游服务时从当前请求透传的追踪头部
+var propagatedHeaders = []string{"X-Request-Id", "Traceparent", "Tracestate", "X-B3-Traceid", "X-B3-Spanid", "X-B3-Sampled"}
+
+// OutboundCall 通过 c.HTTPClient 发起的一次下游调用的记录
+type OutboundCall struct {
+	Method   string
+	URL      string
+	Status   int           // 响应状态码，请求失败时为 0
+	Duration time.Duration // 调用耗时
+	Err      error         // 请求失败时的错误
+}
+
+// outboundLog 一个请求的下游调用记录。Context 放回对象池时不会修改它，
+// 请求结束后仍在使用的 HTTPClient 只会写入已经不再读取的旧记录
+type outboundLog struct {
+	mu    sync.Mutex
+	calls []OutboundCall
+}
+
+// contextTransport 将下游请求与当前请求绑定的 http.RoundTripper。
+// 创建时复制所需的请求数据而不持有 *Context，Context 被对象池复用后仍然可以安全使用
+type contextTransport struct {
+	ctx     context.Context // 当前请求的 context
+	headers http.Header     // 当前请求中需要透传的追踪头部
+	log     *outboundLog
+	base    http.RoundTripper
+}
+
+// RoundTrip 方法使下游请求继承当前请求的 context（截止时间、取消信号），透传追踪头部，并记录调用耗时
+func (t *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// 不能被取消的 context（如 http.NewRequest 默认的 context.Background()）改为使用当前请求的截止时间和取消信号，
+	// 其中的值仍然可以读取；RoundTripper 不能修改传入的请求，所以总是复制一份
+	ctx := req.Context()
+	if ctx.Done() == nil {
+		ctx = valueContext{Context: t.ctx, values: ctx}
+	}
+	req = req.Clone(ctx)
+	for key, values := range t.headers {
+		if req.Header.Get(key) == "" {
+			req.Header.Set(key, values[0])
+		}
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	call := OutboundCall{Method: req.Method, URL: req.URL.String(), Duration: time.Since(start), Err: err}
+	if resp != nil {
+		call.Status = resp.StatusCode
+	}
+	t.log.mu.Lock()
+	t.log.calls = append(t.log.calls, call)
+	t.log.mu.Unlock()
+	return resp, err
+}
+
+// valueContext 使用 Context 的截止时间和取消信号，先从 values 中查找值
+type valueContext struct {
+	context.Context
+	values context.Context
+}
+
+// Value 方法先从 values 中查找值，没有时再从 Context 中查找
+func (v valueContext) Value(key interface{}) interface{} {
+	if value := v.values.Value(key); value != nil {
+		return value
+	}
+	return v.Context.Value(key)
+}
+
+// HTTPClient 方法返回调用下游服务的 *http.Client：
+// 下游请求继承当前请求的截止时间和取消信号，透传 X-Request-Id、Traceparent 等追踪头部，
+// 每次调用的耗时记录在 c.OutboundCalls 中并由 Logger 输出。
+func (c *Context) HTTPClient() *http.Client {
+	headers := make(http.Header)
+	for _, key := range propagatedHeaders {
+		if value := c.Req.Header.Get(key); value != "" {
+			headers.Set(key, value)
+		}
+	}
+	c.mu.Lock()
+	if c.outbound == nil {
+		c.outbound = &outboundLog{}
+	}
+	log := c.outbound
+	c.mu.Unlock()
+	return &http.Client{Transport: &contextTransport{ctx: c.Req.Context(), headers: headers, log: log, base: http.DefaultTransport}}
+}
+
+// OutboundCalls 方法返回当前请求通过 c.HTTPClient 发起的所有下游调用记录
+func (c *Context) OutboundCalls() []OutboundCall {
+	c.mu.Lock()
+	log := c.outbound
+	c.mu.Unlock()
+	if log == nil {
+		return nil
+	}
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	return append([]OutboundCall(nil), log.calls...)
+}
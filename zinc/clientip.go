@@ -0,0 +1,97 @@
+package zinc
+
+import (
+	"net"
+	"strings"
+)
+
+// 常见部署平台上记录客户端 IP 的头部，用于 Engine.TrustedPlatform
+const (
+	PlatformCloudflare      = "CF-Connecting-IP"
+	PlatformGoogleAppEngine = "X-Appengine-Remote-Addr"
+	PlatformFlyIO           = "Fly-Client-IP"
+)
+
+// ClientIP 方法返回客户端的 IP：
+//  1. 设置了 Engine.TrustedPlatform 时，使用平台头部中的 IP（如 Cloudflare 的 CF-Connecting-IP）；
+//  2. 连接的对端是 Engine.TrustedProxies 中的代理时，从右向左解析 X-Forwarded-For，返回第一个不是可信代理的 IP，
+//     没有 X-Forwarded-For 时使用 X-Real-IP；
+//  3. 否则返回连接的对端 IP（c.Req.RemoteAddr）。
+//
+// 只应在请求确实经过平台或代理转发时信任这些头部，否则客户端可以伪造 IP。
+func (c *Context) ClientIP() string {
+	var platform string
+	var proxies []string
+	if c.engine != nil {
+		platform, proxies = c.engine.TrustedPlatform, c.engine.TrustedProxies
+	}
+	if platform != "" {
+		if ip := strings.TrimSpace(c.requestHeader(platform)); net.ParseIP(ip) != nil {
+			return ip
+		}
+	}
+
+	remote, _, err := net.SplitHostPort(strings.TrimSpace(c.Req.RemoteAddr))
+	if err != nil {
+		remote = strings.TrimSpace(c.Req.RemoteAddr)
+	}
+	trusted := parseTrustedProxies(proxies)
+	if !ipTrusted(net.ParseIP(remote), trusted) {
+		return remote
+	}
+	if forwarded := c.Req.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			ip := net.ParseIP(hop)
+			if ip == nil {
+				// 格式错误的链不可信，停在最后一个可信代理上
+				break
+			}
+			if i == 0 || !ipTrusted(ip, trusted) {
+				return hop
+			}
+		}
+		return remote
+	}
+	if ip := strings.TrimSpace(c.requestHeader("X-Real-IP")); net.ParseIP(ip) != nil {
+		return ip
+	}
+	return remote
+}
+
+// parseTrustedProxies 解析可信代理列表，项可以是 IP 或 CIDR，无法解析的项被忽略
+func parseTrustedProxies(proxies []string) []*net.IPNet {
+	nets := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				continue
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		if _, ipNet, err := net.ParseCIDR(proxy); err == nil {
+			nets = append(nets, ipNet)
+		}
+	}
+	return nets
+}
+
+// ipTrusted 判断 ip 是否属于可信代理
+func ipTrusted(ip net.IP, trusted []*net.IPNet) bool {
+	if ip == nil {
+		return false
+	}
+	for _, ipNet := range trusted {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
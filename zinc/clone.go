@@ -0,0 +1,82 @@
+package zinc
+
+import "fmt"
+
+// Clone 方法返回一个共享路由树的独立 Engine：路由（包括运行时通过 AddRoute、RemoveRoute 的修改）与原 Engine 一致，
+// 配置选项、模板、NoRoute 等在克隆时复制，之后各自修改互不影响。
+// 克隆的 Engine 通过 Use 添加的中间件只对它生效，在共享的处理函数链（包括原 Engine 的全局中间件）之前执行。
+// 克隆的 Engine 不能注册路由。
+//
+// 用于在测试中为单个用例调整中间件，或在内部端口上以不同的认证方式提供同一组路由：
+//
+//	internal := engine.Clone()
+//	internal.Use(mTLSAuth())
+//	go http.ListenAndServe(":9090", internal)
+func (engine *Engine) Clone() *Engine {
+	origin := engine.owner()
+	origin.mu.RLock()
+	defer origin.mu.RUnlock()
+
+	// 路由列表和分组不复制，通过 routeList、groupList 读取原 Engine 最新的内容
+	clone := &Engine{
+		router: origin.router,
+		hosts:  origin.hosts,
+		origin: origin,
+
+		htmlTemplates:    engine.htmlTemplates,
+		funcMap:          engine.funcMap,
+		contextFuncs:     engine.contextFuncs,
+		boundTemplates:   engine.boundTemplates,
+		noRoute:          append([]HandlerFunc(nil), engine.noRoute...),
+		preHandlers:      append([]HandlerFunc(nil), engine.preHandlers...),
+		fallbacks:        append([]FallbackResolver(nil), engine.fallbacks...),
+		namedHandlers:    engine.namedHandlers,
+		namedMiddlewares: engine.namedMiddlewares,
+
+		EngineConfig: engine.EngineConfig,
+	}
+	// 切片类型的配置单独复制，避免克隆后修改元素影响原 Engine
+	clone.TrustedProxies = append([]string(nil), engine.TrustedProxies...)
+	// 克隆的根分组只保存克隆自己的中间件
+	clone.RouterGroup = &RouterGroup{engine: clone}
+	if engine.origin != nil {
+		clone.RouterGroup.middlewares = append([]HandlerFunc(nil), engine.RouterGroup.middlewares...)
+		clone.RouterGroup.methods = append([]string(nil), engine.RouterGroup.methods...)
+	}
+	clone.pool.New = func() interface{} {
+		return &Context{engine: clone}
+	}
+	return clone
+}
+
+// owner 方法返回路由树的所有者：克隆的 Engine 返回原 Engine，否则返回 engine 本身
+func (engine *Engine) owner() *Engine {
+	if engine.origin != nil {
+		return engine.origin
+	}
+	return engine
+}
+
+// routeList 方法返回路由树所有者的路由列表的副本，按注册顺序排列；
+// 复制时持有所有者的读锁，克隆的 Engine 也能看到运行时通过 AddRoute、RemoveRoute 的修改
+func (engine *Engine) routeList() []*Route {
+	owner := engine.owner()
+	owner.mu.RLock()
+	defer owner.mu.RUnlock()
+	return append([]*Route(nil), owner.routes...)
+}
+
+// groupList 方法返回路由树所有者的分组列表的副本
+func (engine *Engine) groupList() []*RouterGroup {
+	owner := engine.owner()
+	owner.mu.RLock()
+	defer owner.mu.RUnlock()
+	return append([]*RouterGroup(nil), owner.groups...)
+}
+
+// checkOwner 方法在 engine 是克隆的 Engine 时 panic，op 为调用的操作
+func (engine *Engine) checkOwner(op string) {
+	if engine.origin != nil {
+		panic(fmt.Sprintf("zinc: %s on a cloned engine; routes are shared with the original engine, register them there", op))
+	}
+}
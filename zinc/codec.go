@@ -0,0 +1,88 @@
+package zinc
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Codec 请求体和响应的编解码器，用于 MessagePack 等二进制格式，可以通过 Engine.MsgPackCodec 替换实现
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// msgpackCodec 默认的 MessagePack 编解码器，字段名取自 msgpack 标签
+type msgpackCodec struct{}
+
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	return msgpack.Unmarshal(data, v)
+}
+
+// MsgPackBinding MessagePack 格式，通过 c.ShouldBindWith、c.ShouldBindBodyWith 等绑定时使用 Engine.MsgPackCodec
+var MsgPackBinding Binding = codecBinding{name: "msgpack", codec: msgpackCodec{}}
+
+// codecBinding 由 Codec 实现的请求体格式，strict 对其无效
+type codecBinding struct {
+	name  string
+	codec Codec
+}
+
+func (b codecBinding) Name() string {
+	return b.name
+}
+
+func (codecBinding) buffered() {}
+
+func (b codecBinding) Bind(body io.Reader, obj interface{}, strict bool) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return &BindError{Reason: ReasonInvalid, Message: err.Error(), Err: err}
+	}
+	if len(data) == 0 {
+		return &BindError{Reason: ReasonEmptyBody, Message: "request body is empty", Err: io.EOF}
+	}
+	if err := b.codec.Unmarshal(data, obj); err != nil {
+		return &BindError{Reason: ReasonSyntax, Message: err.Error(), Err: err}
+	}
+	return nil
+}
+
+// msgpack 方法返回 Engine.MsgPackCodec，没有设置时返回默认的编解码器
+func (c *Context) msgpack() Codec {
+	if c.engine != nil && c.engine.MsgPackCodec != nil {
+		return c.engine.MsgPackCodec
+	}
+	return msgpackCodec{}
+}
+
+// ShouldBindMsgPack 方法将 MessagePack 请求体解码到 obj 中，见 ShouldBindWith
+func (c *Context) ShouldBindMsgPack(obj interface{}) error {
+	return c.ShouldBindWith(obj, MsgPackBinding)
+}
+
+// BindMsgPack 方法将 MessagePack 请求体解码到 obj 中，失败时以 400 状态码中止请求
+func (c *Context) BindMsgPack(obj interface{}) error {
+	err := c.ShouldBindMsgPack(obj)
+	if err != nil {
+		c.abortBinding(err)
+	}
+	return err
+}
+
+// MsgPack 方法快速构造 MessagePack 响应报文（application/msgpack），适合对带宽敏感的移动端
+func (c *Context) MsgPack(code int, obj interface{}) {
+	data, err := c.msgpack().Marshal(obj)
+	if err != nil {
+		http.Error(c.Writer, err.Error(), 500)
+		return
+	}
+	c.SetHeader("Content-Type", "application/msgpack")
+	c.Status(code)
+	c.Writer.Write(data)
+}
@@ -0,0 +1,163 @@
+package zinc
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strings"
+)
+
+// CompressConfig 响应压缩中间件的配置
+type CompressConfig struct {
+	// Level gzip 压缩级别，为 0 时使用 gzip.DefaultCompression
+	Level int
+	// ExcludedContentTypes 不压缩的响应类型前缀，text/event-stream 总是不压缩
+	ExcludedContentTypes []string
+	// BreachMitigation 为true时，响应带有 SecretHeaders 中的任一头部时不压缩，
+	// 避免 CSRF token 等密钥与用户输入一起压缩而受到 BREACH 攻击
+	BreachMitigation bool
+	// SecretHeaders 表示响应中携带密钥的头部，为空时为 Set-Cookie 和 X-CSRF-Token
+	SecretHeaders []string
+}
+
+// NoCompress 方法使压缩中间件不压缩本次响应，需要在写出响应之前调用，如渲染带有 CSRF token 的页面
+func (c *Context) NoCompress() {
+	c.noCompress = true
+}
+
+// disableCompression 是 Route.NoCompress 插入的处理函数
+func disableCompression(c *Context) {
+	c.NoCompress()
+}
+
+// Gzip 是使用默认配置的响应压缩中间件
+func Gzip() HandlerFunc {
+	return Compress(CompressConfig{})
+}
+
+// Compress 是响应压缩中间件的构造函数。
+// 客户端支持 gzip 时压缩响应体；是否压缩在第一次写出响应时决定，
+// 以下情况不压缩：路由声明了 NoCompress 或调用了 c.NoCompress、响应已设置 Content-Encoding、
+// 响应类型被排除（包括 SSE）、写出数据之前调用了 Flush（流式响应），以及开启 BreachMitigation 时响应携带密钥。
+func Compress(config CompressConfig) HandlerFunc {
+	if config.Level == 0 {
+		config.Level = gzip.DefaultCompression
+	}
+	if config.SecretHeaders == nil {
+		config.SecretHeaders = []string{"Set-Cookie", "X-CSRF-Token"}
+	}
+	config.ExcludedContentTypes = append([]string{"text/event-stream"}, config.ExcludedContentTypes...)
+
+	return func(c *Context) {
+		if c.Method == http.MethodHead || !acceptsGzip(c.requestHeader("Accept-Encoding")) {
+			c.Next()
+			return
+		}
+		origin := c.Writer
+		writer := &gzipWriter{ResponseWriter: origin, c: c, config: &config}
+		c.Writer = writer
+		defer func() {
+			c.Writer = origin
+			writer.close()
+		}()
+		origin.Header().Add("Vary", "Accept-Encoding")
+		c.Next()
+	}
+}
+
+// acceptsGzip 判断 Accept-Encoding 是否接受 gzip 编码
+func acceptsGzip(acceptEncoding string) bool {
+	for _, coding := range strings.Split(acceptEncoding, ",") {
+		coding = strings.TrimSpace(coding)
+		name := coding
+		if i := strings.IndexByte(coding, ';'); i >= 0 {
+			name = strings.TrimSpace(coding[:i])
+			// q=0 表示明确拒绝
+			if strings.ReplaceAll(coding[i+1:], " ", "") == "q=0" {
+				continue
+			}
+		}
+		if strings.EqualFold(name, "gzip") || name == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// gzipWriter 按需压缩响应体的 http.ResponseWriter
+type gzipWriter struct {
+	http.ResponseWriter
+	c       *Context
+	config  *CompressConfig
+	decided bool         // 是否已决定压缩与否
+	gz      *gzip.Writer // 决定压缩时非空
+}
+
+// decide 方法根据响应头部和 Context 决定是否压缩，streaming 表示写出数据之前调用了 Flush
+func (w *gzipWriter) decide(streaming bool) {
+	if w.decided {
+		return
+	}
+	w.decided = true
+	header := w.ResponseWriter.Header()
+	if streaming || w.c.noCompress || header.Get("Content-Encoding") != "" ||
+		matchContentType(header.Get("Content-Type"), w.config.ExcludedContentTypes) {
+		return
+	}
+	if w.config.BreachMitigation {
+		for _, key := range w.config.SecretHeaders {
+			if header.Get(key) != "" {
+				return
+			}
+		}
+	}
+	gz, _ := gzip.NewWriterLevel(w.ResponseWriter, w.config.Level)
+	w.gz = gz
+	header.Set("Content-Encoding", "gzip")
+	header.Del("Content-Length")
+}
+
+// WriteHeader 方法在发送状态码之前决定是否压缩，没有响应体的状态码不压缩；1xx 状态码直接发送
+func (w *gzipWriter) WriteHeader(code int) {
+	if code < http.StatusOK {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if code == http.StatusNoContent || code == http.StatusNotModified {
+		w.c.noCompress = true
+	}
+	w.decide(false)
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write 方法写出（压缩后的）数据
+func (w *gzipWriter) Write(data []byte) (int, error) {
+	if !w.decided {
+		if w.ResponseWriter.Header().Get("Content-Type") == "" {
+			// 与 net/http 一致，按数据嗅探响应类型，以便判断是否被排除
+			w.ResponseWriter.Header().Set("Content-Type", http.DetectContentType(data))
+		}
+		w.decide(false)
+	}
+	if w.gz != nil {
+		return w.gz.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// Flush 方法将已压缩的数据发送给客户端；写出数据之前调用时视为流式响应，不再压缩
+func (w *gzipWriter) Flush() {
+	w.decide(true)
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// close 方法结束压缩，写出 gzip 尾部
+func (w *gzipWriter) close() {
+	if w.gz != nil {
+		w.gz.Close()
+	}
+}
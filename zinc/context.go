@@ -1,9 +1,15 @@
 package zinc
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"strings"
+	"sync"
 )
 
 type H map[string]interface{}
@@ -16,7 +22,7 @@ type Context struct {
 	// 请求信息
 	Method string            // 请求方法，如：'GET'、'POST'
 	Path string              // URL中的路径部分
-	Params map[string]string // 解析后的动态路由参数
+	Params Params            // 解析后的动态路由参数
 	// 响应信息
 	StatusCode int           // HTTP报文的状态码
 	// 中间件
@@ -24,6 +30,36 @@ type Context struct {
 	index    int             // handlers下标
 	// Engine 指针
 	engine *Engine           // 用来访问 Engine 中的 HTML 模板
+	// 响应信封
+	envelope EnvelopeFunc    // 非空时用于包装 JSON 响应和框架错误
+	// 请求级数据
+	Keys map[string]interface{} // 中间件传递给后面处理函数的数据，需要在持有 mu 时访问
+	Errors []error              // 通过 Error 记录的错误，需要在持有 mu 时访问
+	// 下游调用
+	mu            sync.Mutex     // 保护并发写入的请求级数据
+	outbound      *outboundLog   // 通过 HTTPClient 发起的下游调用记录，第一次调用 HTTPClient 时创建
+	// 响应压缩
+	noCompress bool // 为true时压缩中间件不压缩本次响应
+	// 匹配到的路由，匹配失败时为空
+	route *Route
+	// 为true时请求是长连接（调用过 Draining），Shutdown 会等待它返回
+	streaming bool
+	// 请求级临时缓冲区，由 Scratch 借出，复用 Context 时回收
+	scratch    []byte
+	scratchOff int
+	// 由 RecordResponse 安装的响应记录器，供 ResponseBody、ResponseHeader 读取
+	recorder *responseRecorder
+	// 由 ShouldBindBodyWith 读入内存的请求体
+	body         []byte
+	bodyBuffered bool
+	// c.HTML 或 c.HTMLStream 渲染的模板名，供请求检查器记录
+	template string
+	// 通过 SetCharset 设置的本次响应的字符集，为空时使用 Engine.Charset
+	charset string
+	// 通过 SetSameSite 设置的 SetCookie 默认 SameSite 属性
+	sameSite http.SameSite
+	// 外层正在执行的 ErrorHandler 数量
+	errorHandlers int
 }
 
 // newContext 是 zinc.Context 的构造函数
@@ -38,6 +74,33 @@ func newContext(w http.ResponseWriter, req *http.Request) *Context {
 	}
 }
 
+// reset 方法使从对象池取出的 Context 可以处理新的请求，Params 保留已分配的空间
+func (c *Context) reset(w http.ResponseWriter, req *http.Request) {
+	c.Writer = w
+	c.Req = req
+	c.Method = req.Method
+	c.Path = req.URL.Path
+	c.Params = c.Params[:0]
+	c.StatusCode = 0
+	c.handlers = nil
+	c.index = -1
+	c.envelope = nil
+	c.outbound = nil
+	c.noCompress = false
+	c.route = nil
+	c.Keys = nil
+	c.Errors = nil
+	c.streaming = false
+	c.recorder = nil
+	c.body = nil
+	c.bodyBuffered = false
+	c.template = ""
+	c.charset = ""
+	c.sameSite = 0
+	c.errorHandlers = 0
+	c.resetScratch()
+}
+
 // Next 方法进入后面的处理函数(中间件或用户定义的Handler)
 func (c *Context) Next() {
 	c.index++
@@ -47,32 +110,91 @@ func (c *Context) Next() {
 	}
 }
 
+// abortIndex 是 Abort 设置的处理函数下标，大于任何处理函数链的长度
+const abortIndex = math.MaxInt32 / 2
+
+// Abort 方法中止处理函数链，后面的中间件和Handler不再执行，但不写出任何响应；
+// 已经在执行的中间件在 c.Next 返回后仍会继续执行
+func (c *Context) Abort() {
+	c.index = abortIndex
+}
+
+// IsAborted 方法判断处理函数链是否已被中止
+func (c *Context) IsAborted() bool {
+	return c.index >= abortIndex
+}
+
+// AbortWithStatus 方法中止处理函数链并以状态码 code 响应，不写出响应体，如认证失败时返回 401
+func (c *Context) AbortWithStatus(code int) {
+	c.Abort()
+	c.Status(code)
+}
+
+// AbortWithStatusJSON 方法中止处理函数链并以状态码 code 返回JSON响应 obj
+func (c *Context) AbortWithStatusJSON(code int, obj interface{}) {
+	c.Abort()
+	c.JSON(code, obj)
+}
+
 // Fail 方法作为测试用的短路中间件，用发送500错误码来表示中间件起作用了
 func (c *Context) Fail(code int, err string) {
-	c.index = len(c.handlers)
+	c.Abort()
+	if c.envelope != nil {
+		c.writeJSON(code, c.envelope(code, nil, errors.New(err)))
+		return
+	}
+	if c.engine != nil && c.engine.ProblemJSON {
+		c.Problem(code, ProblemDetails{Detail: err, Instance: c.Path})
+		return
+	}
 	c.JSON(code, H{"message": err})
 }
 
 // Param 方法提供对动态路由参数的访问
 func (c *Context) Param(key string) string {
-	value, _ := c.Params[key]
-	return value
+	return c.Params.ByName(key)
 }
 
-// PostForm 方法返回c.Req内以key为键映射的表单数据（的第一个值）
+// PostForm 方法返回请求体（普通表单或 multipart 表单）中以key为键映射的表单数据，不读取查询参数；
+// 重复的字段按 Engine.QueryDuplicates 取值（默认取第一个值）
 func (c *Context) PostForm(key string) string {
-	// FormValue返回key为键查询http.Request对象的Form字段得到结果[]string切片的第一个值。
-	// Form是url.Values类型，是解析好的表单数据，包括URL字段的query参数和POST或PUT的表单数据。
-	// Values类型即map[string][]string类型，将键映射到值的列表。一般用于查询的参数和表单的属性。
-	return c.Req.FormValue(key)
+	// form 只返回请求体中的字段（http.Request的PostForm），Form字段还包括URL中的query参数。
+	// Values类型即map[string][]string类型，将键映射到值的列表。
+	return c.queryValue(c.form(), key)
 }
 
-// Query 方法返回c.Req.URL编码后的查询字符串部分（'?'后‘#’前的部分）中key为键对应的第一个值
+// Query 方法返回c.Req.URL编码后的查询字符串部分（'?'后‘#’前的部分）中key为键对应的值，
+// 重复的参数按 Engine.QueryDuplicates 取值（默认取第一个值）
 func (c *Context) Query(key string) string {
 	// c.Req.URL字段是 *url.URL类型，代表一个解析后的URL。
 	// Query方法解析URL对象的RawQuery字段（编码后的查询字符串）并返回其表示的Values类型键值对。
-	// Values对象的Get方法会获取key对应的值集的第一个值。
-	return c.Req.URL.Query().Get(key)
+	return c.queryValue(c.Req.URL.Query(), key)
+}
+
+// Set 方法在 c.Keys 中保存键值对，供后面的中间件和处理函数通过 Get 读取，c.Keys 在第一次调用时初始化
+func (c *Context) Set(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.Keys == nil {
+		c.Keys = make(map[string]interface{})
+	}
+	c.Keys[key] = value
+}
+
+// Get 方法返回 c.Keys 中 key 对应的值，exists 表示键是否存在
+func (c *Context) Get(key string) (value interface{}, exists bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, exists = c.Keys[key]
+	return
+}
+
+// MustGet 方法返回 c.Keys 中 key 对应的值，键不存在时 panic
+func (c *Context) MustGet(key string) interface{} {
+	if value, exists := c.Get(key); exists {
+		return value
+	}
+	panic("zinc: key \"" + key + "\" does not exist")
 }
 
 // Status 方法设置c中HTTP响应报文的状态码
@@ -94,17 +216,29 @@ func (c *Context) String(code int,format string,values ...interface{}) {
 	// 调用顺序Header().Set，WriteHeader()，Write()
 	// 在调用WriteHeader或Write方法后再改变Header对象是没有意义的。
 	// 如果WriteHeader没有被显式调用，第一次调用Write时会触发隐式调用WriteHeader(http.StatusOK)
-	c.SetHeader("Content-Type","text/plain")
+	c.SetHeader("Content-Type", c.contentType("text/plain"))
 	c.Status(code)
 	c.Writer.Write([]byte(fmt.Sprintf(format,values...)))
 }
 
 // HTML 方法快速构造HTML响应报文。
 func (c *Context) HTML(code int, name string, data interface{}) {
-	c.SetHeader("Content-Type", "text/html")
+	// 时间预算已经用完时不再渲染模板
+	if err := c.Req.Context().Err(); err != nil {
+		c.Fail(http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	templates, release, err := c.templates()
+	if err != nil {
+		c.Fail(500, err.Error())
+		return
+	}
+	defer release()
+	c.template = name
+	c.SetHeader("Content-Type", c.contentType("text/html"))
 	c.Status(code)
 	// 根据模板文件名 name 选择模板进行渲染。
-	err := c.engine.htmlTemplates.ExecuteTemplate(c.Writer, name, data)
+	err = templates.ExecuteTemplate(c.Writer, name, data)
 	if err != nil {
 		c.Fail(500, err.Error())
 	}
@@ -112,7 +246,27 @@ func (c *Context) HTML(code int, name string, data interface{}) {
 
 // JSON 方法快速构造JSON响应报文
 func (c *Context) JSON(code int,obj interface{}) {
-	c.SetHeader("Content-Type", "application/json")
+	// 分组设置了响应信封时先包装数据
+	if c.envelope != nil {
+		obj = c.envelope(code, obj, nil)
+	}
+	c.writeJSON(code, obj)
+}
+
+// writeJSON 方法将 obj 编码为JSON响应报文
+func (c *Context) writeJSON(code int, obj interface{}) {
+	if buf := c.scratchBuffer(); buf != nil {
+		// 开启临时缓冲区时先完整编码再写出，编码失败不会留下半截响应
+		if err := json.NewEncoder(buf).Encode(obj); err != nil {
+			http.Error(c.Writer, err.Error(), 500)
+			return
+		}
+		c.SetHeader("Content-Type", c.contentType("application/json"))
+		c.Status(code)
+		c.Writer.Write(buf.Bytes())
+		return
+	}
+	c.SetHeader("Content-Type", c.contentType("application/json"))
 	c.Status(code)
 	// Encoder类型的作用是将json对象写入输出流。
 	// NewEncoder方法创建一个将数据写入输出流的*Encoder。
@@ -123,6 +277,43 @@ func (c *Context) JSON(code int,obj interface{}) {
 	}
 }
 
+// JSONWithETag 方法快速构造带 ETag 的JSON响应报文。
+// ETag 为JSON编码结果的哈希值，与请求头部 If-None-Match 匹配时只返回 304 状态码，不写出响应体。
+func (c *Context) JSONWithETag(code int, obj interface{}) {
+	if c.envelope != nil {
+		obj = c.envelope(code, obj, nil)
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		http.Error(c.Writer, err.Error(), 500)
+		return
+	}
+	sum := sha1.Sum(data)
+	etag := `"` + hex.EncodeToString(sum[:]) + `"`
+	c.SetHeader("ETag", etag)
+	if etagMatch(c.requestHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.SetHeader("Content-Type", c.contentType("application/json"))
+	c.Status(code)
+	c.Writer.Write(data)
+}
+
+// etagMatch 判断 If-None-Match 头部的值 header 是否包含 etag（弱比较）
+func etagMatch(header string, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
 // Data 方法快速构造data（[]byte类型）响应报文
 func (c *Context) Data(code int, data []byte) {
 	c.Status(code)
@@ -0,0 +1,63 @@
+package zinc
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// CookieOptions c.SetCookie 的选项
+type CookieOptions struct {
+	MaxAge   int    // 有效期（秒），为 0 时为会话 Cookie，小于 0 时删除 Cookie
+	Path     string // 为空时为 /
+	Domain   string
+	Secure   bool // 只通过 HTTPS 发送
+	HttpOnly bool // 不允许脚本读取
+	// SameSite 为零值时使用 c.SetSameSite 设置的默认值
+	SameSite http.SameSite
+}
+
+// Cookie 方法返回请求中名为 name 的 Cookie 的值（已按 URL 编码解码），不存在时返回 http.ErrNoCookie
+func (c *Context) Cookie(name string) (string, error) {
+	cookie, err := c.Req.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	value, err := url.QueryUnescape(cookie.Value)
+	if err != nil {
+		// 不是由 SetCookie 写入的值按原样返回
+		return cookie.Value, nil
+	}
+	return value, nil
+}
+
+// SetCookie 方法在响应中设置 Cookie，value 按 URL 编码写入（Cookie 方法读取时解码），如：
+//
+//	c.SetCookie("session", token, zinc.CookieOptions{MaxAge: 3600, Secure: true, HttpOnly: true})
+func (c *Context) SetCookie(name string, value string, opts CookieOptions) {
+	if opts.Path == "" {
+		opts.Path = "/"
+	}
+	if opts.SameSite == 0 {
+		opts.SameSite = c.sameSite
+	}
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    url.QueryEscape(value),
+		MaxAge:   opts.MaxAge,
+		Path:     opts.Path,
+		Domain:   opts.Domain,
+		Secure:   opts.Secure,
+		HttpOnly: opts.HttpOnly,
+		SameSite: opts.SameSite,
+	})
+}
+
+// SetSameSite 方法设置本次请求中 SetCookie 的默认 SameSite 属性，可以在中间件中为整个分组设置，如：
+//
+//	g.Use(func(c *zinc.Context) {
+//		c.SetSameSite(http.SameSiteStrictMode)
+//		c.Next()
+//	})
+func (c *Context) SetSameSite(sameSite http.SameSite) {
+	c.sameSite = sameSite
+}
@@ -0,0 +1,100 @@
+package zinc
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// DuplicatePolicy 同名查询参数、表单字段或请求头部重复出现时的处理方式。
+// 统一的处理方式可以避免 role=user&role=admin 这样的重复参数在不同组件中被解释为不同的值。
+type DuplicatePolicy int
+
+const (
+	FirstWins        DuplicatePolicy = iota // 取第一个值，与 url.Values.Get 一致（默认）
+	LastWins                                // 取最后一个值
+	RejectDuplicates                        // 路由匹配之前以 400 状态码拒绝带有重复参数的请求
+)
+
+// pick 方法按处理方式从重复的值中取出一个，values 为空时返回空字符串。
+// 查询参数和头部的重复在路由之前已被 RejectDuplicates 拒绝；请求体中的表单字段无法提前检查，
+// 绑定到结构体时返回 ReasonDuplicate 错误，通过 PostForm 等读取时视为缺失，返回空字符串。
+func (p DuplicatePolicy) pick(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	switch {
+	case p == LastWins:
+		return values[len(values)-1]
+	case p == RejectDuplicates && len(values) > 1:
+		return ""
+	}
+	return values[0]
+}
+
+// listHeaders 按 RFC 7230 可以合法重复出现的列表型请求头部，RejectDuplicates 不拒绝这些头部
+var listHeaders = map[string]bool{
+	"Accept":            true,
+	"Accept-Charset":    true,
+	"Accept-Encoding":   true,
+	"Accept-Language":   true,
+	"Cache-Control":     true,
+	"Connection":        true,
+	"Cookie":            true,
+	"Forwarded":         true,
+	"If-Match":          true,
+	"If-None-Match":     true,
+	"Pragma":            true,
+	"Te":                true,
+	"Trailer":           true,
+	"Upgrade":           true,
+	"Via":               true,
+	"Warning":           true,
+	"X-Forwarded-For":   true,
+	"X-Forwarded-Proto": true,
+}
+
+// duplicateKey 方法按 QueryDuplicates 和 HeaderDuplicates 检查请求，
+// 返回第一个违反 RejectDuplicates 的查询参数或头部名称，没有时返回空字符串
+func (engine *Engine) duplicateKey(req *http.Request) string {
+	if engine.QueryDuplicates == RejectDuplicates {
+		for key, values := range req.URL.Query() {
+			if len(values) > 1 {
+				return key
+			}
+		}
+	}
+	if engine.HeaderDuplicates == RejectDuplicates {
+		for key, values := range req.Header {
+			if len(values) > 1 && !listHeaders[key] {
+				return key
+			}
+		}
+	}
+	return ""
+}
+
+// queryPolicy 方法返回查询参数和表单字段的重复处理方式
+func (c *Context) queryPolicy() DuplicatePolicy {
+	if c.engine == nil {
+		return FirstWins
+	}
+	return c.engine.QueryDuplicates
+}
+
+// queryValue 方法按重复处理方式返回 values 中 key 对应的值
+func (c *Context) queryValue(values url.Values, key string) string {
+	return c.queryPolicy().pick(values[key])
+}
+
+// headerPolicy 方法返回请求头部的重复处理方式
+func (c *Context) headerPolicy() DuplicatePolicy {
+	if c.engine == nil {
+		return FirstWins
+	}
+	return c.engine.HeaderDuplicates
+}
+
+// requestHeader 方法按重复处理方式返回请求头部 key 对应的值
+func (c *Context) requestHeader(key string) string {
+	return c.headerPolicy().pick(c.Req.Header.Values(key))
+}
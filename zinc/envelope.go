@@ -0,0 +1,23 @@
+package zinc
+
+// EnvelopeFunc 响应信封函数，将 JSON 响应包装为旧客户端兼容的格式。
+// 正常响应时 err 为 nil、data 为原始数据；框架错误（如 Fail）时 data 为 nil、err 为错误信息。
+type EnvelopeFunc func(code int, data interface{}, err error) interface{}
+
+// LegacyEnvelope 将响应包装为 {"code":0,"data":...,"msg":""} 格式，错误时 code 为 HTTP 状态码
+func LegacyEnvelope(code int, data interface{}, err error) interface{} {
+	if err != nil {
+		return H{"code": code, "data": nil, "msg": err.Error()}
+	}
+	return H{"code": 0, "data": data, "msg": ""}
+}
+
+// Envelope 是响应信封中间件的构造函数。
+// 注册到分组后，该分组内 c.JSON 渲染的数据和框架错误都会经过 fn 包装，
+// 如：g.Use(zinc.Envelope(zinc.LegacyEnvelope))
+func Envelope(fn EnvelopeFunc) HandlerFunc {
+	return func(c *Context) {
+		c.envelope = fn
+		c.Next()
+	}
+}
@@ -0,0 +1,122 @@
+package zinc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// HTTPError 带有 HTTP 状态码的错误，Message 是返回给客户端的错误信息，Err 是内部原因（不返回给客户端）
+type HTTPError struct {
+	Code    int
+	Message string
+	Err     error
+}
+
+// NewHTTPError 是 zinc.HTTPError 的构造函数，message 为空时使用状态码的默认描述
+func NewHTTPError(code int, message string, err error) *HTTPError {
+	if message == "" {
+		message = http.StatusText(code)
+	}
+	return &HTTPError{Code: code, Message: message, Err: err}
+}
+
+// Error 方法返回错误信息，包含内部原因
+func (e *HTTPError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap 方法返回内部原因，支持 errors.Is 和 errors.As
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// Error 方法记录处理请求时发生的错误并返回 err，通常与 Abort 一起使用：
+//
+//	if err != nil {
+//		c.Error(err)
+//		c.Abort()
+//		return
+//	}
+//
+// 错误由 ErrorHandler 中间件统一转换为响应，也可以通过 c.Errors 读取。
+func (c *Context) Error(err error) error {
+	if err == nil {
+		panic("zinc: c.Error called with a nil error")
+	}
+	c.mu.Lock()
+	c.Errors = append(c.Errors, err)
+	c.mu.Unlock()
+	return err
+}
+
+// ErrorHandlerConfig 错误处理中间件的配置
+type ErrorHandlerConfig struct {
+	// Map 自定义错误到 HTTPError 的映射，返回 nil 时使用默认映射，
+	// 如将 sql.ErrNoRows 映射为 404
+	Map func(err error) *HTTPError
+}
+
+// ErrorHandler 是使用默认映射的错误处理中间件
+func ErrorHandler() HandlerFunc {
+	return ErrorHandlerWith(ErrorHandlerConfig{})
+}
+
+// ErrorHandlerWith 是错误处理中间件的构造函数。
+// 后面的处理函数通过 c.Error 记录了错误、且还没有写出响应时，中间件将最后一个错误转换为响应：
+// 先调用 config.Map，再按默认映射处理：HTTPError 使用其状态码和信息，超时为 503，其他错误为 500（不暴露内部错误信息）。
+// 响应与 Fail 一样以 JSON 返回，分组设置了响应信封时经过信封包装。
+func ErrorHandlerWith(config ErrorHandlerConfig) HandlerFunc {
+	return func(c *Context) {
+		// 内层的 abortWithError 据此把错误留给本中间件转换
+		c.errorHandlers++
+		defer func() {
+			c.errorHandlers--
+		}()
+		c.Next()
+		c.mu.Lock()
+		var err error
+		if len(c.Errors) > 0 {
+			err = c.Errors[len(c.Errors)-1]
+		}
+		c.mu.Unlock()
+		// 已经写出响应时无法再修改
+		if err == nil || c.StatusCode != 0 {
+			return
+		}
+		var httpErr *HTTPError
+		if config.Map != nil {
+			httpErr = config.Map(err)
+		}
+		if httpErr == nil {
+			httpErr = defaultHTTPError(err)
+		}
+		c.Fail(httpErr.Code, httpErr.Message)
+	}
+}
+
+// abortWithError 方法中止处理函数链：外层有 ErrorHandler 时由它将已通过 c.Error 记录的 err 转换为响应，
+// 否则按默认映射直接以 JSON 输出（HTTPError 使用其状态码和信息，其他错误为 500，不暴露内部错误信息）
+func (c *Context) abortWithError(err error) {
+	c.Abort()
+	if c.errorHandlers > 0 {
+		return
+	}
+	httpErr := defaultHTTPError(err)
+	c.Fail(httpErr.Code, httpErr.Message)
+}
+
+// defaultHTTPError 是错误到 HTTPError 的默认映射
+func defaultHTTPError(err error) *HTTPError {
+	var httpErr *HTTPError
+	switch {
+	case errors.As(err, &httpErr):
+		return httpErr
+	case errors.Is(err, context.DeadlineExceeded):
+		return NewHTTPError(http.StatusServiceUnavailable, "", err)
+	}
+	return NewHTTPError(http.StatusInternalServerError, "", err)
+}
@@ -0,0 +1,91 @@
+package zinc
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// FallbackResolver 路由匹配失败时依次尝试的处理函数，处理了请求（写出了响应）时返回 true，
+// 返回 false 时交给下一个 FallbackResolver，最后是 NoRoute 设置的处理函数链
+type FallbackResolver func(c *Context) bool
+
+// Fallback 方法追加路由匹配失败时的 FallbackResolver，它们在全局中间件之后、NoRoute 之前按注册顺序执行。
+// 用于逐步迁移旧系统（绞杀者模式），如：
+//
+//	engine.Fallback(
+//		zinc.SPAFallback("/app", "./dist/index.html"),
+//		zinc.RedirectFallback(legacyLinks),
+//		zinc.ProxyFallback("http://legacy.internal:8080"),
+//	)
+//	engine.NoRoute(notFoundPage)
+func (engine *Engine) Fallback(resolvers ...FallbackResolver) {
+	engine.checkMutable("Fallback")
+	engine.fallbacks = append(engine.fallbacks, resolvers...)
+}
+
+// runFallbacks 方法依次执行 FallbackResolver，有一个处理了请求时跳过后面的 NoRoute 处理函数链
+func (engine *Engine) runFallbacks(c *Context) {
+	for _, resolve := range engine.fallbacks {
+		if resolve(c) {
+			c.index = len(c.handlers)
+			return
+		}
+	}
+}
+
+// SPAFallback 返回单页应用的 FallbackResolver：prefix 下接受 HTML 的 GET、HEAD 请求，
+// 且路径的最后一段没有扩展名（不是静态资源）时，返回 index 文件，由前端路由处理
+func SPAFallback(prefix string, index string) FallbackResolver {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return func(c *Context) bool {
+		if c.Method != http.MethodGet && c.Method != http.MethodHead {
+			return false
+		}
+		if c.Path != prefix && !strings.HasPrefix(c.Path, prefix+"/") {
+			return false
+		}
+		if path.Ext(c.Path) != "" {
+			return false
+		}
+		if accept := c.requestHeader("Accept"); accept != "" && !strings.Contains(accept, "text/html") && !strings.Contains(accept, "*/*") {
+			return false
+		}
+		http.ServeFile(c.Writer, c.Req, index)
+		return true
+	}
+}
+
+// RedirectFallback 返回按重定向表重定向的 FallbackResolver，键为旧地址（精确匹配），
+// 值为新地址，与 Engine.Redirects 一样可以带有状态码前缀（默认为 301），请求的查询字符串保留到新地址上
+func RedirectFallback(table map[string]string) FallbackResolver {
+	handlers := make(map[string]HandlerFunc, len(table))
+	for from, target := range table {
+		code, target := parseRedirect(from, target)
+		handlers[from] = redirectHandler(code, target)
+	}
+	return func(c *Context) bool {
+		handler, ok := handlers[c.Path]
+		if !ok {
+			return false
+		}
+		handler(c)
+		return true
+	}
+}
+
+// ProxyFallback 返回将请求反向代理到 upstream（如旧的单体应用）的 FallbackResolver，它处理所有请求，
+// 通常放在最后；upstream 不是合法的地址时 panic
+func ProxyFallback(upstream string) FallbackResolver {
+	target, err := url.Parse(upstream)
+	if err != nil || target.Scheme == "" || target.Host == "" {
+		panic("zinc: invalid fallback upstream " + upstream)
+	}
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	return func(c *Context) bool {
+		proxy.ServeHTTP(c.Writer, c.Req)
+		return true
+	}
+}
@@ -0,0 +1,74 @@
+package zinc
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// File 方法以本地文件 name 的内容响应请求，支持 Range 断点续传和条件请求：
+// 响应带有 Last-Modified、Date 和由文件大小与修改时间生成的强 ETag，If-Range 只有在验证器仍然匹配时才返回部分内容，
+// 文件在两次请求之间被修改时返回完整的 200 响应，客户端不会把新旧内容拼接在一起。文件不存在时返回 404。
+func (c *Context) File(name string) {
+	c.FileFromFS(filepath.Base(name), http.Dir(filepath.Dir(name)))
+}
+
+// FileFromFS 方法以文件系统 fs 中的文件 name 响应请求，见 File
+func (c *Context) FileFromFS(name string, fs http.FileSystem) {
+	f, err := fs.Open(name)
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil || stat.IsDir() {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	c.serveContent(stat.Name(), f, stat)
+}
+
+// serveContent 方法以 content 响应请求，在 http.ServeContent 之前补充 ETag、Date，并按验证器的强弱处理 If-Range
+func (c *Context) serveContent(name string, content io.ReadSeeker, stat os.FileInfo) {
+	now := time.Now()
+	header := c.Writer.Header()
+	if header.Get("Date") == "" {
+		header.Set("Date", now.UTC().Format(http.TimeFormat))
+	}
+	etag := header.Get("ETag")
+	if etag == "" {
+		etag = `"` + strconv.FormatInt(stat.Size(), 36) + "-" + strconv.FormatInt(stat.ModTime().UnixNano(), 36) + `"`
+		header.Set("ETag", etag)
+	}
+
+	req := c.Req
+	if ifRange := c.requestHeader("If-Range"); ifRange != "" && !ifRangeMatch(ifRange, etag, stat.ModTime(), now) {
+		// 验证器不匹配时忽略 Range，返回完整内容；复制请求以免影响后面的中间件
+		req = new(http.Request)
+		*req = *c.Req
+		req.Header = c.Req.Header.Clone()
+		req.Header.Del("Range")
+		req.Header.Del("If-Range")
+	}
+	http.ServeContent(c.Writer, req, name, stat.ModTime(), content)
+}
+
+// ifRangeMatch 判断 If-Range 头部的值是否与当前的验证器匹配。
+// ETag 使用强比较；日期只有在是强验证器时才匹配，即修改时间至少早于当前时间一秒（RFC 7232 2.2.2），
+// 否则同一秒内再次修改的文件无法区分。
+func ifRangeMatch(ifRange string, etag string, modtime time.Time, now time.Time) bool {
+	if strings.HasPrefix(ifRange, `"`) || strings.HasPrefix(ifRange, "W/") {
+		return ifRange == etag && !strings.HasPrefix(etag, "W/")
+	}
+	t, err := http.ParseTime(ifRange)
+	if err != nil || modtime.IsZero() {
+		return false
+	}
+	modtime = modtime.Truncate(time.Second)
+	return modtime.Equal(t) && now.Sub(modtime) >= time.Second
+}
@@ -0,0 +1,266 @@
+package zinc
+
+import (
+	"container/list"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// FlowStore 多步骤表单（向导）数据的存储，键为 flow ID，值为各步骤序列化后的数据。
+// zinc 没有内置的 session，可以用 Redis 等实现该接口，单机部署时可以使用 MemoryFlowStore。
+type FlowStore interface {
+	Get(id string) (steps map[string][]byte, ok bool)
+	Set(id string, steps map[string][]byte)
+	Delete(id string)
+}
+
+// FlowStepSetter 可以原子地保存单个步骤的 FlowStore。Flow.Save 优先使用 SetStep，
+// 否则先 Get 再 Set，并发保存同一向导的不同步骤时可能互相覆盖
+type FlowStepSetter interface {
+	SetStep(id string, step string, data []byte)
+}
+
+// defaultMaxFlows MemoryFlowStore.MaxFlows 为 0 时保存的向导数量上限
+const defaultMaxFlows = 10000
+
+// MemoryFlowStore 保存在内存中的 FlowStore，超过 ttl 没有更新的向导数据会被丢弃；
+// 向导数量超过 MaxFlows 时丢弃最久没有更新的向导
+type MemoryFlowStore struct {
+	// MaxFlows 保存的向导数量上限，为 0 时为 10000
+	MaxFlows int
+
+	mu    sync.Mutex
+	ttl   time.Duration
+	flows map[string]*list.Element // 值为 *memoryFlow
+	order *list.List               // 按更新时间排列，最近更新的在前
+}
+
+// memoryFlow MemoryFlowStore 中的一个向导
+type memoryFlow struct {
+	id      string
+	steps   map[string][]byte
+	expires time.Time
+}
+
+// NewMemoryFlowStore 是 zinc.MemoryFlowStore 的构造函数
+func NewMemoryFlowStore(ttl time.Duration) *MemoryFlowStore {
+	return &MemoryFlowStore{ttl: ttl, flows: make(map[string]*list.Element), order: list.New()}
+}
+
+// Get 方法返回 flow ID 对应的数据
+func (s *MemoryFlowStore) Get(id string) (map[string][]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	flow, ok := s.lookup(id)
+	if !ok {
+		return nil, false
+	}
+	steps := make(map[string][]byte, len(flow.steps))
+	for step, data := range flow.steps {
+		steps[step] = data
+	}
+	return steps, true
+}
+
+// Set 方法保存 flow ID 对应的数据并刷新过期时间
+func (s *MemoryFlowStore) Set(id string, steps map[string][]byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.touch(id).steps = steps
+}
+
+// SetStep 方法保存 flow ID 中步骤 step 的数据并刷新过期时间，其他步骤的数据保持不变
+func (s *MemoryFlowStore) SetStep(id string, step string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	flow := s.touch(id)
+	if flow.steps == nil {
+		flow.steps = make(map[string][]byte)
+	}
+	flow.steps[step] = data
+}
+
+// Delete 方法删除 flow ID 对应的数据
+func (s *MemoryFlowStore) Delete(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.flows[id]; ok {
+		s.order.Remove(e)
+		delete(s.flows, id)
+	}
+}
+
+// lookup 方法返回没有过期的向导，调用时需要持有 mu
+func (s *MemoryFlowStore) lookup(id string) (*memoryFlow, bool) {
+	e, ok := s.flows[id]
+	if !ok {
+		return nil, false
+	}
+	flow := e.Value.(*memoryFlow)
+	if time.Now().After(flow.expires) {
+		s.order.Remove(e)
+		delete(s.flows, id)
+		return nil, false
+	}
+	return flow, true
+}
+
+// touch 方法返回 flow ID 对应的向导（不存在或已过期时创建）并刷新过期时间，
+// 同时丢弃已过期和超出数量上限的向导，调用时需要持有 mu
+func (s *MemoryFlowStore) touch(id string) *memoryFlow {
+	now := time.Now()
+	flow, ok := s.lookup(id)
+	if ok {
+		s.order.MoveToFront(s.flows[id])
+	} else {
+		flow = &memoryFlow{id: id}
+		s.flows[id] = s.order.PushFront(flow)
+	}
+	flow.expires = now.Add(s.ttl)
+
+	max := s.MaxFlows
+	if max <= 0 {
+		max = defaultMaxFlows
+	}
+	// 最久没有更新的向导在末尾
+	for e := s.order.Back(); e != nil && e != s.flows[id]; e = s.order.Back() {
+		oldest := e.Value.(*memoryFlow)
+		if s.order.Len() <= max && now.Before(oldest.expires) {
+			break
+		}
+		s.order.Remove(e)
+		delete(s.flows, oldest.id)
+	}
+	return flow
+}
+
+// Validator 可以自我校验的步骤数据，Flow.Save 在保存之前调用 Validate
+type Validator interface {
+	Validate() error
+}
+
+// Flow 多步骤表单流程，如注册向导。每个步骤的数据单独保存，最后一步再一起读取。
+type Flow struct {
+	c     *Context
+	name  string
+	id    string
+	store FlowStore
+}
+
+// Flow 方法返回名为 name 的向导，flow ID 保存在名为 zinc_flow_<name> 的 Cookie 中，第一次访问时生成。
+// 需要先设置 Engine.FlowStore。Cookie 默认带有 Secure 属性，只通过 HTTP 访问时需要设置 Engine.FlowCookieInsecure。
+//
+// 如：
+//
+//	flow := c.Flow("signup")
+//	if err := flow.Save("account", &account); err != nil { ... }
+//	if flow.Completed("account", "profile") { flow.Load("account", &account); ... ; flow.Clear() }
+func (c *Context) Flow(name string) *Flow {
+	store := c.engine.FlowStore
+	if store == nil {
+		panic("zinc: Engine.FlowStore must be set to use c.Flow")
+	}
+	flow := &Flow{c: c, name: name, store: store}
+	// 只接受存储中存在的 flow ID，客户端提供的未知 ID（如攻击者预先设置的 Cookie）会被替换为新生成的 ID
+	if cookie, err := c.Req.Cookie(flow.cookieName()); err == nil && cookie.Value != "" {
+		if _, ok := store.Get(cookie.Value); ok {
+			flow.id = cookie.Value
+			return flow
+		}
+	}
+	id, err := newFlowID()
+	if err != nil {
+		panic(fmt.Sprintf("zinc: generate flow ID: %v", err))
+	}
+	flow.id = id
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     flow.cookieName(),
+		Value:    flow.id,
+		Path:     "/",
+		Secure:   !c.engine.FlowCookieInsecure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return flow
+}
+
+// cookieName 方法返回保存 flow ID 的 Cookie 名称
+func (f *Flow) cookieName() string {
+	return "zinc_flow_" + f.name
+}
+
+// newFlowID 返回随机生成的 flow ID
+func newFlowID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// ID 方法返回 flow ID
+func (f *Flow) ID() string {
+	return f.id
+}
+
+// Save 方法校验并保存步骤 step 的数据 v，v 实现了 Validator 时先调用 Validate，校验失败时不保存
+func (f *Flow) Save(step string, v interface{}) error {
+	if validator, ok := v.(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			return err
+		}
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if setter, ok := f.store.(FlowStepSetter); ok {
+		setter.SetStep(f.id, step, data)
+		return nil
+	}
+	steps, ok := f.store.Get(f.id)
+	if !ok {
+		steps = make(map[string][]byte)
+	}
+	steps[step] = data
+	f.store.Set(f.id, steps)
+	return nil
+}
+
+// Load 方法将步骤 step 保存的数据解析到 v 中，步骤还没有保存时返回 false
+func (f *Flow) Load(step string, v interface{}) bool {
+	steps, ok := f.store.Get(f.id)
+	if !ok {
+		return false
+	}
+	data, ok := steps[step]
+	if !ok {
+		return false
+	}
+	return json.Unmarshal(data, v) == nil
+}
+
+// Completed 方法判断 steps 中的步骤是否都已保存
+func (f *Flow) Completed(steps ...string) bool {
+	saved, ok := f.store.Get(f.id)
+	if !ok {
+		return len(steps) == 0
+	}
+	for _, step := range steps {
+		if _, ok := saved[step]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Clear 方法删除向导的所有数据并使 Cookie 失效，通常在最后一步完成后调用
+func (f *Flow) Clear() {
+	f.store.Delete(f.id)
+	http.SetCookie(f.c.Writer, &http.Cookie{Name: f.cookieName(), Value: "", Path: "/", MaxAge: -1})
+}
@@ -0,0 +1,332 @@
+package zinc
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errNotStructPointer 绑定的目标不是结构体指针时返回的错误
+var errNotStructPointer = errors.New("zinc: binding target must be a pointer to a struct")
+
+// structValue 返回 obj 指向的结构体，obj 不是非空的结构体指针时返回错误
+func structValue(obj interface{}) (reflect.Value, error) {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, errNotStructPointer
+	}
+	return v.Elem(), nil
+}
+
+// fieldKey 返回结构体字段在 tag 标签中的名称，没有标签时为字段名；标签为 "-" 或字段未导出时 ok 为 false
+func fieldKey(field reflect.StructField, tag string) (key string, ok bool) {
+	if field.PkgPath != "" {
+		return "", false
+	}
+	name := field.Tag.Get(tag)
+	if i := strings.IndexByte(name, ','); i >= 0 {
+		name = name[:i]
+	}
+	if name == "-" {
+		return "", false
+	}
+	if name == "" {
+		name = field.Name
+	}
+	return name, true
+}
+
+// formSource 绑定到结构体的字符串值的来源
+type formSource interface {
+	// lookup 方法返回 key 对应的所有值
+	lookup(key string) []string
+}
+
+// formValues 查询参数、表单字段、路由参数等，键区分大小写
+type formValues map[string][]string
+
+func (v formValues) lookup(key string) []string {
+	return v[key]
+}
+
+// headerValues 请求头部，键不区分大小写
+type headerValues http.Header
+
+func (h headerValues) lookup(key string) []string {
+	return http.Header(h).Values(key)
+}
+
+// mapForm 将 values 中的值按 tag 标签绑定到 obj 指向的结构体的字段上，非切片字段的重复值按 policy 取值
+// （RejectDuplicates 时返回 ReasonDuplicate 错误），
+// present 非空时记录出现在 values 中的字段名（结构体字段名）。值无法转换为字段类型时返回 *BindError。
+//
+// 标签支持以下选项：
+//
+//	form:"limit,default=50"     缺失时使用默认值，切片以分号分隔多个默认值，如 default=a;b
+//	form:"q,omitempty"          空字符串视为缺失（非字符串类型的字段总是如此）
+//
+// 缺失的指针字段保持为 nil，可以据此区分没有提供的参数。
+func mapForm(obj interface{}, values formSource, tag string, policy DuplicatePolicy, present FieldSet) error {
+	v, err := structValue(obj)
+	if err != nil {
+		return err
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key, ok := fieldKey(field, tag)
+		if !ok {
+			continue
+		}
+		vals := values.lookup(key)
+		opts := parseTagOptions(field.Tag.Get(tag))
+		if emptyValues(vals) && (opts.omitEmpty || baseKind(field.Type) != reflect.String) {
+			// 空值视为缺失，指针字段保持为 nil
+			vals = nil
+		}
+		if len(vals) == 0 {
+			if !opts.hasDefault {
+				continue
+			}
+			defaults := []string{opts.defaultValue}
+			if baseKind(field.Type) == reflect.Slice {
+				defaults = strings.Split(opts.defaultValue, ";")
+			}
+			if err := setField(v.Field(i), defaults, field.Tag.Get("time_format"), policy); err != nil {
+				panic(fmt.Sprintf("zinc: invalid default %q for field %s: %v", opts.defaultValue, field.Name, err))
+			}
+			continue
+		}
+		if policy == RejectDuplicates && len(vals) > 1 && baseKind(field.Type) != reflect.Slice {
+			return &BindError{Field: key, Reason: ReasonDuplicate, Message: "duplicate value for " + key}
+		}
+		if err := setField(v.Field(i), vals, field.Tag.Get("time_format"), policy); err != nil {
+			return &BindError{Field: key, Reason: ReasonType, Message: err.Error(), Err: err}
+		}
+		if present != nil {
+			present[field.Name] = struct{}{}
+		}
+	}
+	return nil
+}
+
+// tagOptions 绑定标签中名称之后的选项
+type tagOptions struct {
+	defaultValue string
+	hasDefault   bool
+	omitEmpty    bool
+}
+
+// parseTagOptions 解析标签 value 中名称之后逗号分隔的选项
+func parseTagOptions(value string) tagOptions {
+	var opts tagOptions
+	parts := strings.Split(value, ",")
+	for _, part := range parts[1:] {
+		switch {
+		case strings.HasPrefix(part, "default="):
+			opts.defaultValue, opts.hasDefault = strings.TrimPrefix(part, "default="), true
+		case part == "omitempty":
+			opts.omitEmpty = true
+		}
+	}
+	return opts
+}
+
+// emptyValues 判断 vals 是否只包含空字符串
+func emptyValues(vals []string) bool {
+	for _, val := range vals {
+		if val != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// baseKind 返回类型 t 去掉指针后的种类
+func baseKind(t reflect.Type) reflect.Kind {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind()
+}
+
+// setField 将字符串形式的值 vals 转换为字段的类型并赋值，切片字段使用所有值，其他字段使用 policy 取出的值，
+// layout 为时间字段的格式（time_format 标签），为空时使用 RFC 3339
+func setField(field reflect.Value, vals []string, layout string, policy DuplicatePolicy) error {
+	switch field.Kind() {
+	case reflect.Ptr:
+		value := reflect.New(field.Type().Elem())
+		if err := setField(value.Elem(), vals, layout, policy); err != nil {
+			return err
+		}
+		field.Set(value)
+		return nil
+	case reflect.Slice:
+		slice := reflect.MakeSlice(field.Type(), len(vals), len(vals))
+		for i, val := range vals {
+			if err := setValue(slice.Index(i), val, layout); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+	return setValue(field, policy.pick(vals), layout)
+}
+
+// setValue 将字符串 val 转换为 v 的类型并赋值，非字符串类型的空值保留零值
+func setValue(v reflect.Value, val string, layout string) error {
+	if val == "" && v.Kind() != reflect.String {
+		return nil
+	}
+	invalid := func(want string) error {
+		return fmt.Errorf("cannot parse %q as %s", val, want)
+	}
+	if ok, err := decodeValue(v, val); ok {
+		if err != nil {
+			return invalid(v.Type().String())
+		}
+		return nil
+	}
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return invalid("duration")
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	if v.Type() == reflect.TypeOf(time.Time{}) {
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		t, err := time.Parse(layout, val)
+		if err != nil {
+			return invalid("time in format " + layout)
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+	if ok, err := unmarshalText(v, val); ok {
+		if err != nil {
+			return invalid(v.Type().String())
+		}
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(val)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return invalid("bool")
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(val, 10, v.Type().Bits())
+		if err != nil {
+			return invalid(v.Type().String())
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(val, 10, v.Type().Bits())
+		if err != nil {
+			return invalid(v.Type().String())
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(val, v.Type().Bits())
+		if err != nil {
+			return invalid(v.Type().String())
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// ShouldBindQuery 方法将URL查询参数按 form 标签绑定到 obj（结构体指针）中，如：
+//
+//	type ListQuery struct {
+//		Page  int       `form:"page"`
+//		Tags  []string  `form:"tag"`                             // 重复的参数 ?tag=a&tag=b
+//		Since time.Time `form:"since" time_format:"2006-01-02"` // 默认为 RFC 3339
+//	}
+//
+// 支持字符串、布尔、整数、浮点数、time.Duration、time.Time 以及它们的指针和切片，
+// 没有标签的字段使用字段名，重复的参数绑定到非切片字段时按 Engine.QueryDuplicates 取值，
+// 其他类型可以通过 RegisterDecoder 注册解码函数或实现 encoding.TextUnmarshaler。
+// 绑定后按 validate 标签校验（见 RegisterValidator）。值无法转换或校验失败时返回 *BindError，Field 为出错的参数名。
+func (c *Context) ShouldBindQuery(obj interface{}) error {
+	if err := mapForm(obj, formValues(c.Req.URL.Query()), "form", c.queryPolicy(), nil); err != nil {
+		return err
+	}
+	return validateStruct(obj, "form")
+}
+
+// BindQuery 方法与 ShouldBindQuery 相同，绑定失败时以 400 状态码中止请求
+func (c *Context) BindQuery(obj interface{}) error {
+	err := c.ShouldBindQuery(obj)
+	if err != nil {
+		c.abortBinding(err)
+	}
+	return err
+}
+
+// ShouldBindUri 方法将动态路由参数按 uri 标签绑定到 obj（结构体指针）中，支持的类型与 ShouldBindQuery 相同，如：
+//
+//	// g.GET("/orders/:id", ...)
+//	var uri struct {
+//		ID int64 `uri:"id"`
+//	}
+//	if c.BindUri(&uri) != nil {
+//		return
+//	}
+func (c *Context) ShouldBindUri(obj interface{}) error {
+	values := make(formValues, len(c.Params))
+	for _, p := range c.Params {
+		values[p.Key] = append(values[p.Key], p.Value)
+	}
+	if err := mapForm(obj, values, "uri", FirstWins, nil); err != nil {
+		return err
+	}
+	return validateStruct(obj, "uri")
+}
+
+// BindUri 方法与 ShouldBindUri 相同，绑定失败时以 400 状态码中止请求
+func (c *Context) BindUri(obj interface{}) error {
+	err := c.ShouldBindUri(obj)
+	if err != nil {
+		c.abortBinding(err)
+	}
+	return err
+}
+
+// ShouldBindHeader 方法将请求头部按 header 标签（不区分大小写）绑定到 obj（结构体指针）中，
+// 支持的类型与 ShouldBindQuery 相同，重复的头部绑定到非切片字段时按 Engine.HeaderDuplicates 取值，如：
+//
+//	var h struct {
+//		APIVersion int    `header:"X-Api-Version"`
+//		RequestID  string `header:"X-Request-Id"`
+//		Features   []string `header:"X-Feature"`
+//	}
+func (c *Context) ShouldBindHeader(obj interface{}) error {
+	if err := mapForm(obj, headerValues(c.Req.Header), "header", c.headerPolicy(), nil); err != nil {
+		return err
+	}
+	return validateStruct(obj, "header")
+}
+
+// BindHeader 方法与 ShouldBindHeader 相同，绑定失败时以 400 状态码中止请求
+func (c *Context) BindHeader(obj interface{}) error {
+	err := c.ShouldBindHeader(obj)
+	if err != nil {
+		c.abortBinding(err)
+	}
+	return err
+}
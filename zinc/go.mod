@@ -1,3 +1,12 @@
 module zinc
 
-go 1.16
+go 1.18
+
+require (
+	github.com/BurntSushi/toml v1.3.2
+	github.com/vmihailenco/msgpack/v5 v5.3.5
+	google.golang.org/protobuf v1.34.1
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
@@ -0,0 +1,92 @@
+package zinc
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Draining 方法返回服务开始优雅关闭时被关闭的 channel，供 SSE、WebSocket 等长连接处理函数使用：
+// 收到通知后向客户端发送关闭（goaway）消息并尽快返回，而不是在部署时被中途切断。
+// 调用过 Draining 的请求被视为长连接，Shutdown 会等待它们返回（最多 Engine.DrainGracePeriod）。
+//
+// 如：
+//
+//	for {
+//		select {
+//		case event := <-events:
+//			fmt.Fprintf(c.Writer, "data: %s\n\n", event)
+//			c.Writer.(http.Flusher).Flush()
+//		case <-c.Draining():
+//			fmt.Fprint(c.Writer, "event: goaway\ndata: reconnect\n\n")
+//			return
+//		case <-c.Done():
+//			return
+//		}
+//	}
+func (c *Context) Draining() <-chan struct{} {
+	if !c.streaming {
+		c.streaming = true
+		atomic.AddInt64(&c.engine.streams, 1)
+	}
+	return c.engine.drainChan()
+}
+
+// drainChan 方法返回优雅关闭的通知 channel
+func (engine *Engine) drainChan() chan struct{} {
+	engine.drainOnce.Do(func() {
+		engine.draining = make(chan struct{})
+	})
+	return engine.draining
+}
+
+// releaseStream 方法在长连接请求结束时调用
+func (engine *Engine) releaseStream(c *Context) {
+	if c.streaming {
+		atomic.AddInt64(&engine.streams, -1)
+	}
+}
+
+// Shutdown 方法优雅关闭 Run 启动的服务：
+// 首先通知所有通过 c.Draining 等待的长连接，等待它们返回，最多等待 DrainGracePeriod（为 0 时为 5 秒）；
+// 然后关闭监听器，等待其余请求处理完成或 ctx 结束。
+func (engine *Engine) Shutdown(ctx context.Context) error {
+	draining := engine.drainChan()
+	engine.mu.Lock()
+	select {
+	case <-draining:
+	default:
+		close(draining)
+	}
+	server := engine.server
+	engine.mu.Unlock()
+
+	grace := engine.DrainGracePeriod
+	if grace == 0 {
+		grace = 5 * time.Second
+	}
+	deadline := time.NewTimer(grace)
+	defer deadline.Stop()
+	// 与 http.Server.Shutdown 一样轮询等待
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for atomic.LoadInt64(&engine.streams) > 0 {
+		select {
+		case <-ticker.C:
+		case <-deadline.C:
+			return engine.shutdownServer(ctx, server)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return engine.shutdownServer(ctx, server)
+}
+
+// shutdownServer 方法关闭 Run 启动的 http 服务器，服务没有通过 Run 启动时什么也不做
+func (engine *Engine) shutdownServer(ctx context.Context, server *http.Server) error {
+	if server == nil {
+		return nil
+	}
+	return server.Shutdown(ctx)
+}
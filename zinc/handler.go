@@ -0,0 +1,36 @@
+package zinc
+
+import (
+	"errors"
+	"net/http"
+)
+
+// JSONHandler 将类型化的处理函数 fn 适配为 HandlerFunc。
+// 适配后的 Handler 先通过 ShouldBindJSON 把 JSON 请求体解析到 Req 并校验（见 RegisterValidator），再调用 fn：
+// fn 返回 error 时通过 c.Error 记录并中止，由 ErrorHandler 转换为响应（没有 ErrorHandler 时按默认映射输出，
+// 非 HTTPError 的错误为 500，不暴露内部错误信息），否则以 200 状态码将 Resp 渲染为 JSON。
+//
+// 如：e.POST("/users", zinc.JSONHandler(func(c *zinc.Context, req CreateUserReq) (User, error) { ... }))
+func JSONHandler[Req any, Resp any](fn func(*Context, Req) (Resp, error)) HandlerFunc {
+	return func(c *Context) {
+		var req Req
+		// 与 ShouldBindJSON 相同地解析和校验请求体，空请求体（如 GET 请求）保持 Req 为零值
+		if err := c.ShouldBindJSON(&req); err != nil {
+			var bindErr *BindError
+			if !errors.As(err, &bindErr) || bindErr.Reason != ReasonEmptyBody {
+				code := http.StatusBadRequest
+				if errors.Is(err, ErrRequestBodyTooLarge) {
+					code = http.StatusRequestEntityTooLarge
+				}
+				c.abortWithError(c.Error(NewHTTPError(code, err.Error(), err)))
+				return
+			}
+		}
+		resp, err := fn(c, req)
+		if err != nil {
+			c.abortWithError(c.Error(err))
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
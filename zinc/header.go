@@ -0,0 +1,129 @@
+package zinc
+
+import (
+	"strconv"
+	"strings"
+)
+
+// GetHeader 方法返回请求头部 key 的值（不区分大小写），同名头部重复出现时按 Engine.HeaderDuplicates 处理
+func (c *Context) GetHeader(key string) string {
+	return c.requestHeader(key)
+}
+
+// ContentType 方法返回请求的媒体类型，去掉了参数并转为小写，如 "application/json; charset=UTF-8" 返回 "application/json"
+func (c *Context) ContentType() string {
+	return mediaType(c.requestHeader("Content-Type"))
+}
+
+// mediaType 返回 Content-Type、Accept 项中的媒体类型部分
+func mediaType(value string) string {
+	if i := strings.IndexByte(value, ';'); i >= 0 {
+		value = value[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(value))
+}
+
+// IsWebsocket 方法判断请求是否为 WebSocket 握手（Connection 包含 upgrade 且 Upgrade 为 websocket）
+func (c *Context) IsWebsocket() bool {
+	if !strings.EqualFold(strings.TrimSpace(c.requestHeader("Upgrade")), "websocket") {
+		return false
+	}
+	for _, value := range c.Req.Header.Values("Connection") {
+		for _, token := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), "upgrade") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// acceptRange Accept 头部中的一项
+type acceptRange struct {
+	mediaType string
+	q         float64
+}
+
+// specificity 返回媒体范围的具体程度：type/subtype 为 2，type/* 为 1，*/* 为 0
+func (r acceptRange) specificity() int {
+	switch {
+	case r.mediaType == "*/*":
+		return 0
+	case strings.HasSuffix(r.mediaType, "/*"):
+		return 1
+	}
+	return 2
+}
+
+// matches 方法判断媒体范围是否包含 offer
+func (r acceptRange) matches(offer string) bool {
+	switch r.specificity() {
+	case 0:
+		return true
+	case 1:
+		return strings.HasPrefix(offer, r.mediaType[:len(r.mediaType)-1])
+	}
+	return r.mediaType == offer
+}
+
+// parseAccept 解析 Accept 头部
+func parseAccept(header string) []acceptRange {
+	var ranges []acceptRange
+	for _, item := range strings.Split(header, ",") {
+		r := acceptRange{mediaType: mediaType(item), q: 1}
+		if r.mediaType == "" {
+			continue
+		}
+		for _, param := range strings.Split(item, ";")[1:] {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(key, "q") {
+				if q, err := strconv.ParseFloat(value, 64); err == nil {
+					r.q = q
+				}
+			}
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges
+}
+
+// acceptQuality 返回 offer 的 q 值，取自包含它的最具体的媒体范围，没有媒体范围包含它时为 0
+func acceptQuality(ranges []acceptRange, offer string) float64 {
+	q, specificity := 0.0, -1
+	for _, r := range ranges {
+		if r.matches(offer) && r.specificity() > specificity {
+			q, specificity = r.q, r.specificity()
+		}
+	}
+	return q
+}
+
+// Accepts 方法按请求头部 Accept 从 offers 中选择客户端最偏好的媒体类型，q 值相同时选择排在前面的 offer，如：
+//
+//	switch c.Accepts("application/json", "text/html") {
+//	case "text/html":
+//		c.HTML(http.StatusOK, "user.tmpl", user)
+//	case "application/json":
+//		c.JSON(http.StatusOK, user)
+//	default:
+//		c.Status(http.StatusNotAcceptable)
+//	}
+//
+// 没有 Accept 头部时返回第一个 offer；没有可接受的 offer（包括 q=0 的项）时返回空字符串。
+func (c *Context) Accepts(offers ...string) string {
+	if len(offers) == 0 {
+		return ""
+	}
+	header := strings.Join(c.Req.Header.Values("Accept"), ",")
+	if strings.TrimSpace(header) == "" {
+		return offers[0]
+	}
+	ranges := parseAccept(header)
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		if q := acceptQuality(ranges, mediaType(offer)); q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
@@ -0,0 +1,239 @@
+package zinc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Host 方法创建一个只匹配请求头部 Host 为 host 的分组，
+// 如：api := e.Host("api.example.com")，api.GET("/users", ...) 只响应 api.example.com/users。
+// 该分组继承全局中间件；请求的 Host 没有匹配的路由时回退到不区分 Host 的普通路由。
+func (engine *Engine) Host(host string) *RouterGroup {
+	return engine.hostGroup(hostname(host))
+}
+
+// hostGroup 方法创建 Host 分组，key 为 engine.hosts 中对应路由结构的键
+func (engine *Engine) hostGroup(key string) *RouterGroup {
+	group := &RouterGroup{
+		host:   key,
+		parent: engine.RouterGroup,
+		engine: engine,
+	}
+	engine.groups = append(engine.groups, group)
+	return group
+}
+
+// Subdomain 方法创建一个只匹配指定子域名的分组，如：e.Subdomain("admin") 匹配 admin.example.com；
+// subdomain 以`:`开头时匹配任意子域名，并将其作为路由参数，如：e.Subdomain(":tenant") 匹配 acme.example.com，
+// c.Param("tenant") 为 acme；subdomain 为空时匹配没有子域名的 Host，如 example.com。
+// 设置了 Engine.BaseDomain 时子域名为 Host 去掉基础域名后的部分（可以包含多段，如 eu.admin），
+// 不属于基础域名的 Host 不匹配任何子域名分组；否则子域名为 Host 的第一段，只有至少包含三段的 Host（或 *.localhost）才被视为带有子域名。
+// 精确的 Host 分组优先于子域名分组，静态子域名优先于`:`子域名。
+func (engine *Engine) Subdomain(subdomain string) *RouterGroup {
+	subdomain = strings.ToLower(subdomain)
+	if strings.HasPrefix(subdomain, ":") {
+		// 参数名保存在子域名分组的路由结构上，所有`:`子域名分组共用同一个路由结构，参数名必须相同
+		r := engine.routerFor(":.*")
+		if r.subdomainParam != "" && r.subdomainParam != subdomain[1:] {
+			panic(fmt.Sprintf("zinc: subdomain %q conflicts with existing subdomain \":%s\"", subdomain, r.subdomainParam))
+		}
+		r.subdomainParam = subdomain[1:]
+		subdomain = ":"
+	}
+	return engine.hostGroup(subdomain + ".*")
+}
+
+// subdomainOf 方法返回 host 的子域名，host 不属于 BaseDomain 时 ok 为 false
+func (engine *Engine) subdomainOf(host string) (subdomain string, ok bool) {
+	if base := hostname(engine.BaseDomain); base != "" {
+		if host == base {
+			return "", true
+		}
+		if strings.HasSuffix(host, "."+base) {
+			return host[:len(host)-len(base)-1], true
+		}
+		return "", false
+	}
+	if i := strings.IndexByte(host, '.'); i > 0 && (strings.Count(host, ".") >= 2 || strings.HasSuffix(host, ".localhost")) {
+		return host[:i], true
+	}
+	return "", true
+}
+
+// routerFor 方法返回 host 对应的路由结构，host 为空时返回普通路由结构
+func (engine *Engine) routerFor(host string) *router {
+	if host == "" {
+		return engine.router
+	}
+	if engine.hosts == nil {
+		engine.hosts = make(map[string]*router)
+	}
+	r, ok := engine.hosts[host]
+	if !ok {
+		r = newRouter()
+		// 匹配失败时回退到普通路由结构
+		r.fallback = engine.router
+		engine.hosts[host] = r
+	}
+	return r
+}
+
+// matchRouter 方法返回请求头部 Host 对应的路由结构，没有为该 Host 注册路由时返回普通路由结构；
+// 匹配到`:`子域名分组时，subdomain 为捕获的子域名
+func (engine *Engine) matchRouter(c *Context) (r *router, subdomain string) {
+	if len(engine.hosts) == 0 {
+		return engine.router, ""
+	}
+	host := hostname(c.Req.Host)
+	if r, ok := engine.hosts[host]; ok {
+		return r, ""
+	}
+	// 按子域名匹配
+	if label, ok := engine.subdomainOf(host); ok {
+		if r, ok := engine.hosts[label+".*"]; ok {
+			return r, ""
+		}
+		if r, ok := engine.hosts[":.*"]; ok && label != "" {
+			return r, label
+		}
+	}
+	return engine.router, ""
+}
+
+// hostname 返回去掉端口并转为小写的主机名，如 API.example.com:8080 返回 api.example.com
+func hostname(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(host)
+}
+
+// VirtualHost 按 Host 将请求分发到不同 Engine 的前端路由，
+// 每个 Engine 拥有各自的模板、中间件和静态文件目录，如：
+//
+//	vh := zinc.NewVirtualHost()
+//	vh.Handle("api.example.com", api)
+//	vh.Handle("*.example.com", site)
+//	vh.Run(":9999")
+type VirtualHost struct {
+	hosts     map[string]*Engine // 精确匹配的 Host
+	wildcards []wildcardHost     // 形如 *.example.com 的通配 Host，按注册顺序匹配
+	fallback  *Engine            // 没有匹配的 Host 时使用的 Engine
+	mu        sync.Mutex         // 保护 server
+	server    *http.Server       // Run 启动的 http 服务器，供 Shutdown 关闭
+}
+
+// wildcardHost 通配 Host，suffix 为去掉`*`后的后缀，如 .example.com
+type wildcardHost struct {
+	suffix string
+	engine *Engine
+}
+
+// NewVirtualHost 是 zinc.VirtualHost 的构造函数
+func NewVirtualHost() *VirtualHost {
+	return &VirtualHost{hosts: make(map[string]*Engine)}
+}
+
+// Handle 方法将 Host 模式 pattern 映射到 engine。
+// pattern 可以是精确的主机名（api.example.com），也可以是通配子域名（*.example.com）。
+func (v *VirtualHost) Handle(pattern string, engine *Engine) {
+	pattern = hostname(pattern)
+	if strings.HasPrefix(pattern, "*.") {
+		v.wildcards = append(v.wildcards, wildcardHost{suffix: pattern[1:], engine: engine})
+		return
+	}
+	v.hosts[pattern] = engine
+}
+
+// Default 方法设置没有匹配的 Host 时使用的 Engine
+func (v *VirtualHost) Default(engine *Engine) {
+	v.fallback = engine
+}
+
+// match 方法返回 host 对应的 Engine，精确匹配优先于通配匹配
+func (v *VirtualHost) match(host string) *Engine {
+	host = hostname(host)
+	if engine, ok := v.hosts[host]; ok {
+		return engine
+	}
+	for _, w := range v.wildcards {
+		if strings.HasSuffix(host, w.suffix) {
+			return w.engine
+		}
+	}
+	return v.fallback
+}
+
+// ServeHTTP 方法将请求交给 Host 对应的 Engine 处理，没有对应的 Engine 时返回 404
+func (v *VirtualHost) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	engine := v.match(req.Host)
+	if engine == nil {
+		http.NotFound(w, req)
+		return
+	}
+	engine.ServeHTTP(w, req)
+}
+
+// engines 方法返回所有映射的 Engine，同一个 Engine 只出现一次
+func (v *VirtualHost) engines() []*Engine {
+	seen := make(map[*Engine]bool)
+	var engines []*Engine
+	add := func(engine *Engine) {
+		if engine != nil && !seen[engine] {
+			seen[engine] = true
+			engines = append(engines, engine)
+		}
+	}
+	for _, engine := range v.hosts {
+		add(engine)
+	}
+	for _, w := range v.wildcards {
+		add(w.engine)
+	}
+	add(v.fallback)
+	return engines
+}
+
+// Run 方法冻结所有 Engine 的路由表并启动一个 http 服务器
+func (v *VirtualHost) Run(addr string) (err error) {
+	for _, engine := range v.engines() {
+		engine.Freeze()
+	}
+	server := &http.Server{Addr: addr, Handler: v}
+	v.mu.Lock()
+	v.server = server
+	v.mu.Unlock()
+	return server.ListenAndServe()
+}
+
+// Shutdown 方法优雅关闭 Run 启动的服务：所有 Engine 同时按 Engine.Shutdown 通知并等待各自的长连接，
+// 之后关闭监听器，等待其余请求处理完成或 ctx 结束
+func (v *VirtualHost) Shutdown(ctx context.Context) error {
+	engines := v.engines()
+	errs := make(chan error, len(engines))
+	for _, engine := range engines {
+		go func(engine *Engine) {
+			errs <- engine.Shutdown(ctx)
+		}(engine)
+	}
+	var err error
+	for range engines {
+		if e := <-errs; e != nil && err == nil {
+			err = e
+		}
+	}
+	if err != nil {
+		return err
+	}
+	v.mu.Lock()
+	server := v.server
+	v.mu.Unlock()
+	if server == nil {
+		return nil
+	}
+	return server.Shutdown(ctx)
+}
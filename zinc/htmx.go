@@ -0,0 +1,57 @@
+package zinc
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+)
+
+// HTMLFragment 方法只渲染名为 name 的嵌套模板（通过 {{define}} 或 {{block}} 定义），不包含外层布局，
+// 用于 HTMX 等只需要局部更新的场景，如：c.HTMLFragment(http.StatusOK, "users/row", user)。
+// 模板先渲染到缓冲区，渲染失败时返回 500 而不会输出不完整的片段。
+func (c *Context) HTMLFragment(code int, name string, data interface{}) {
+	// 设置了 SetContextFuncMap 时不能直接执行 engine.htmlTemplates，否则之后无法再复制模板集
+	templates, release, err := c.templates()
+	if err != nil {
+		c.Fail(http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer release()
+	tmpl := templates.Lookup(name)
+	if tmpl == nil {
+		c.Fail(http.StatusInternalServerError, fmt.Sprintf("template fragment %q is not defined", name))
+		return
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		c.Fail(http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.SetHeader("Content-Type", c.contentType("text/html"))
+	c.Status(code)
+	c.Writer.Write(buf.Bytes())
+}
+
+// HTMLOrFragment 方法对 HTMX 请求只渲染片段 fragment，对普通请求渲染完整页面 page
+func (c *Context) HTMLOrFragment(code int, page string, fragment string, data interface{}) {
+	if c.IsHTMX() {
+		c.HTMLFragment(code, fragment, data)
+		return
+	}
+	c.HTML(code, page, data)
+}
+
+// IsHTMX 方法判断请求是否由 HTMX 发起（请求头部 HX-Request 为 true）
+func (c *Context) IsHTMX() bool {
+	return c.requestHeader("HX-Request") == "true"
+}
+
+// HTMXTarget 方法返回 HTMX 请求的目标元素 id（请求头部 HX-Target）
+func (c *Context) HTMXTarget() string {
+	return c.requestHeader("HX-Target")
+}
+
+// HTMXTrigger 方法返回触发 HTMX 请求的元素 id（请求头部 HX-Trigger）
+func (c *Context) HTMXTrigger() string {
+	return c.requestHeader("HX-Trigger")
+}
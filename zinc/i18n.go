@@ -0,0 +1,376 @@
+package zinc
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Language 方法返回客户端首选的语言（Accept-Language 中权重最高的语言的主标签，小写），如 zh、en；
+// 中间件可以通过 c.Set("lang", ...) 覆盖（如根据用户设置），没有时返回 en
+func (c *Context) Language() string {
+	if lang := c.GetString("lang"); lang != "" {
+		return lang
+	}
+	best, bestQ := "en", -1.0
+	for _, part := range strings.Split(c.requestHeader("Accept-Language"), ",") {
+		tag, q := strings.TrimSpace(part), 1.0
+		if i := strings.IndexByte(tag, ';'); i >= 0 {
+			if v, err := strconv.ParseFloat(strings.TrimPrefix(strings.TrimSpace(tag[i+1:]), "q="), 64); err == nil {
+				q = v
+			}
+			tag = strings.TrimSpace(tag[:i])
+		}
+		if tag == "" || tag == "*" || q <= bestQ {
+			continue
+		}
+		best, bestQ = primaryLanguage(tag), q
+	}
+	return best
+}
+
+// primaryLanguage 返回语言标签的主标签（小写），如 zh-CN 返回 zh
+func primaryLanguage(tag string) string {
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	return strings.ToLower(tag)
+}
+
+// FormatMessage 方法按客户端语言格式化 ICU 风格的消息，见 FormatMessage
+func (c *Context) FormatMessage(message string, args map[string]interface{}) (string, error) {
+	return FormatMessage(c.Language(), message, args)
+}
+
+// FormatNumber 方法按客户端语言格式化数字，见 FormatNumber
+func (c *Context) FormatNumber(n float64, decimals int) string {
+	return FormatNumber(c.Language(), n, decimals)
+}
+
+// FormatDate 方法按客户端语言格式化日期，见 FormatDate
+func (c *Context) FormatDate(t time.Time) string {
+	return FormatDate(c.Language(), t)
+}
+
+// PluralCategory 返回数量 n 在语言 lang 中的复数类别：zero、one、two、few、many 或 other（CLDR 规则的常用子集）
+func PluralCategory(lang string, n float64) string {
+	integer := n == math.Trunc(n)
+	i := int64(math.Abs(n))
+	switch primaryLanguage(lang) {
+	case "zh", "ja", "ko", "vi", "th", "id":
+		return "other"
+	case "fr", "pt":
+		if i == 0 || i == 1 {
+			return "one"
+		}
+		return "other"
+	case "ru", "uk", "be", "sr", "hr", "bs":
+		if !integer {
+			return "other"
+		}
+		switch {
+		case i%10 == 1 && i%100 != 11:
+			return "one"
+		case i%10 >= 2 && i%10 <= 4 && (i%100 < 12 || i%100 > 14):
+			return "few"
+		}
+		return "many"
+	case "pl":
+		if !integer {
+			return "other"
+		}
+		switch {
+		case i == 1:
+			return "one"
+		case i%10 >= 2 && i%10 <= 4 && (i%100 < 12 || i%100 > 14):
+			return "few"
+		}
+		return "many"
+	case "ar":
+		if !integer {
+			return "other"
+		}
+		switch {
+		case i == 0:
+			return "zero"
+		case i == 1:
+			return "one"
+		case i == 2:
+			return "two"
+		case i%100 >= 3 && i%100 <= 10:
+			return "few"
+		case i%100 >= 11:
+			return "many"
+		}
+		return "other"
+	}
+	// en、de 等语言
+	if integer && i == 1 {
+		return "one"
+	}
+	return "other"
+}
+
+// FormatMessage 按语言 lang 格式化 ICU MessageFormat 风格的消息，支持：
+//
+//	{name}                                         替换为参数
+//	{count, plural, =0 {无} one {# 项} other {# 项}}  按数量选择，# 替换为本地化的数量
+//	{gender, select, male {他} female {她} other {TA}}  按参数值选择
+//
+// 选择分支中可以嵌套以上格式，消息格式错误或缺少参数时返回错误。
+func FormatMessage(lang string, message string, args map[string]interface{}) (string, error) {
+	p := &messageParser{lang: lang, src: message, args: args}
+	out, err := p.parse(false, "")
+	if err != nil {
+		return "", fmt.Errorf("zinc: format message %q: %v", message, err)
+	}
+	return out, nil
+}
+
+// messageParser ICU 风格消息的解析器
+type messageParser struct {
+	lang string
+	src  string
+	pos  int
+	args map[string]interface{}
+}
+
+// parse 方法解析到消息结束（nested 为 true 时到匹配的 }），hash 非空时将 # 替换为 hash
+func (p *messageParser) parse(nested bool, hash string) (string, error) {
+	var b strings.Builder
+	for p.pos < len(p.src) {
+		ch := p.src[p.pos]
+		switch {
+		case ch == '}' && nested:
+			p.pos++
+			return b.String(), nil
+		case ch == '{':
+			p.pos++
+			s, err := p.argument()
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(s)
+		case ch == '#' && hash != "":
+			p.pos++
+			b.WriteString(hash)
+		case ch == '\'' && p.pos+1 < len(p.src) && p.src[p.pos+1] == '\'':
+			// '' 表示单引号
+			p.pos += 2
+			b.WriteByte('\'')
+		default:
+			p.pos++
+			b.WriteByte(ch)
+		}
+	}
+	if nested {
+		return "", fmt.Errorf("unclosed {")
+	}
+	return b.String(), nil
+}
+
+// argument 方法解析 { 之后的参数，直到匹配的 }
+func (p *messageParser) argument() (string, error) {
+	end := strings.IndexAny(p.src[p.pos:], ",}")
+	if end < 0 {
+		return "", fmt.Errorf("unclosed {")
+	}
+	name := strings.TrimSpace(p.src[p.pos : p.pos+end])
+	value, ok := p.args[name]
+	if !ok {
+		return "", fmt.Errorf("missing argument %q", name)
+	}
+	p.pos += end
+	if p.src[p.pos] == '}' {
+		p.pos++
+		if n, ok := toFloat(value); ok {
+			return FormatNumber(p.lang, n, -1), nil
+		}
+		return fmt.Sprint(value), nil
+	}
+
+	// {name, kind, cases}
+	p.pos++
+	end = strings.IndexByte(p.src[p.pos:], ',')
+	if end < 0 {
+		return "", fmt.Errorf("missing format type for %q", name)
+	}
+	kind := strings.TrimSpace(p.src[p.pos : p.pos+end])
+	p.pos += end + 1
+
+	var keys []string
+	hash := ""
+	switch kind {
+	case "plural":
+		n, ok := toFloat(value)
+		if !ok {
+			return "", fmt.Errorf("argument %q is not a number", name)
+		}
+		keys = []string{"=" + strconv.FormatFloat(n, 'f', -1, 64), PluralCategory(p.lang, n), "other"}
+		hash = FormatNumber(p.lang, n, -1)
+	case "select":
+		keys = []string{fmt.Sprint(value), "other"}
+	default:
+		return "", fmt.Errorf("unknown format type %q", kind)
+	}
+	return p.cases(keys, hash)
+}
+
+// cases 方法解析选择分支直到匹配的 }，返回 keys 中第一个存在的分支的结果
+func (p *messageParser) cases(keys []string, hash string) (string, error) {
+	results := make(map[string]string)
+	for {
+		for p.pos < len(p.src) && strings.IndexByte(" \t\n", p.src[p.pos]) >= 0 {
+			p.pos++
+		}
+		if p.pos >= len(p.src) {
+			return "", fmt.Errorf("unclosed {")
+		}
+		if p.src[p.pos] == '}' {
+			p.pos++
+			break
+		}
+		open := strings.IndexByte(p.src[p.pos:], '{')
+		if open < 0 {
+			return "", fmt.Errorf("missing { after case")
+		}
+		key := strings.TrimSpace(p.src[p.pos : p.pos+open])
+		p.pos += open + 1
+		result, err := p.parse(true, hash)
+		if err != nil {
+			return "", err
+		}
+		results[key] = result
+	}
+	for _, key := range keys {
+		if result, ok := results[key]; ok {
+			return result, nil
+		}
+	}
+	return "", fmt.Errorf("missing other case")
+}
+
+// toFloat 将数字类型的参数转换为 float64
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// numberSeparators 各语言的千位分隔符和小数点，\u202f 为窄不换行空格
+var numberSeparators = map[string][2]string{
+	"de": {".", ","}, "es": {".", ","}, "it": {".", ","}, "nl": {".", ","}, "pt": {".", ","}, "id": {".", ","},
+	"fr": {"\u202f", ","}, "ru": {" ", ","}, "uk": {" ", ","}, "pl": {" ", ","}, "cs": {" ", ","},
+}
+
+// FormatNumber 按语言 lang 的千位分隔符和小数点格式化 n，decimals 为小数位数，为负数时使用最少的必要位数
+func FormatNumber(lang string, n float64, decimals int) string {
+	separators, ok := numberSeparators[primaryLanguage(lang)]
+	if !ok {
+		separators = [2]string{",", "."}
+	}
+	s := strconv.FormatFloat(math.Abs(n), 'f', decimals, 64)
+	integer, fraction := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		integer, fraction = s[:i], s[i+1:]
+	}
+	var b strings.Builder
+	if n < 0 {
+		b.WriteByte('-')
+	}
+	for i := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			b.WriteString(separators[0])
+		}
+		b.WriteByte(integer[i])
+	}
+	if fraction != "" {
+		b.WriteString(separators[1])
+		b.WriteString(fraction)
+	}
+	return b.String()
+}
+
+// dateLayouts 各语言的日期格式
+var dateLayouts = map[string]string{
+	"en": "Jan 2, 2006",
+	"zh": "2006年1月2日",
+	"ja": "2006年1月2日",
+	"ko": "2006. 1. 2.",
+	"de": "02.01.2006",
+	"ru": "02.01.2006",
+	"pl": "02.01.2006",
+	"fr": "02/01/2006",
+	"es": "02/01/2006",
+	"it": "02/01/2006",
+	"pt": "02/01/2006",
+}
+
+// FormatDate 按语言 lang 的习惯格式化日期，未知语言使用 ISO 8601 格式（2006-01-02）
+func FormatDate(lang string, t time.Time) string {
+	layout, ok := dateLayouts[primaryLanguage(lang)]
+	if !ok {
+		layout = "2006-01-02"
+	}
+	return t.Format(layout)
+}
+
+// I18nFuncs 返回本地化格式化的模板函数，可以通过 engine.SetFuncMap 注册，语言作为第一个参数传入：
+//
+//	{{ formatMessage .Lang "{n, plural, one {# file} other {# files}}" "n" .Count }}
+//	{{ formatNumber .Lang .Price 2 }}
+//	{{ formatDate .Lang .CreatedAt }}
+//	{{ plural .Lang .Count }}
+func I18nFuncs() template.FuncMap {
+	return template.FuncMap{
+		"formatMessage": func(lang string, message string, pairs ...interface{}) (string, error) {
+			if len(pairs)%2 != 0 {
+				return "", fmt.Errorf("zinc: formatMessage expects key/value pairs")
+			}
+			args := make(map[string]interface{}, len(pairs)/2)
+			for i := 0; i < len(pairs); i += 2 {
+				args[fmt.Sprint(pairs[i])] = pairs[i+1]
+			}
+			return FormatMessage(lang, message, args)
+		},
+		"formatNumber": func(lang string, n interface{}, decimals int) (string, error) {
+			f, ok := toFloat(n)
+			if !ok {
+				return "", fmt.Errorf("zinc: formatNumber expects a number, got %T", n)
+			}
+			return FormatNumber(lang, f, decimals), nil
+		},
+		"formatDate": FormatDate,
+		"plural": func(lang string, n interface{}) string {
+			f, _ := toFloat(n)
+			return PluralCategory(lang, f)
+		},
+	}
+}
@@ -0,0 +1,155 @@
+package zinc
+
+import (
+	"html/template"
+	"net"
+	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// inspectorPrefix 请求检查器的路由前缀
+const inspectorPrefix = "/_zinc"
+
+// InspectedRequest 请求检查器记录的一次请求
+type InspectedRequest struct {
+	Time     time.Time     `json:"time"`
+	Method   string        `json:"method"`
+	URI      string        `json:"uri"`
+	Route    string        `json:"route"` // 匹配到的路由，没有匹配时为空
+	Status   int           `json:"status"`
+	Duration time.Duration `json:"duration"` // 纳秒
+	Header   http.Header   `json:"header"`   // 请求头部，Authorization、Cookie 等已屏蔽
+	Handlers []string      `json:"handlers"` // 处理函数链（中间件和 Handler）的函数名
+	Aborted  bool          `json:"aborted"`
+	Template string        `json:"template,omitempty"` // c.HTML 或 c.HTMLStream 渲染的模板
+	Errors   []string      `json:"errors,omitempty"`   // 通过 c.Error 记录的错误
+}
+
+// inspector 保存最近 size 个请求的环形缓冲区
+type inspector struct {
+	mu       sync.Mutex
+	entries  []InspectedRequest
+	next     int
+	redactor *Redactor
+}
+
+// Inspector 方法开启开发用的请求检查器，记录最近 size 个请求（耗时、头部、匹配的路由、处理函数链、
+// 渲染的模板、记录的错误），在 /_zinc 页面查看，/_zinc/requests 返回JSON。
+// 检查器作为全局中间件注册，只应在开发环境中开启：页面会暴露请求的细节。
+// 没有传入 auth 时页面只允许本机直接访问（经过反向代理的请求被拒绝），
+// 否则 auth 作为页面路由的中间件执行，由它鉴权，如 engine.Inspector(100, requireAdmin)。
+func (engine *Engine) Inspector(size int, auth ...HandlerFunc) {
+	if size <= 0 {
+		size = 100
+	}
+	if len(auth) == 0 {
+		auth = []HandlerFunc{loopbackOnly}
+	}
+	ins := &inspector{entries: make([]InspectedRequest, 0, size), redactor: DefaultRedactor()}
+	engine.Use(ins.record)
+	engine.GET(inspectorPrefix, ins.page).Use(auth...)
+	engine.GET(inspectorPrefix+"/requests", func(c *Context) {
+		c.JSON(http.StatusOK, ins.recent())
+	}).Use(auth...)
+}
+
+// loopbackOnly 中间件只允许本机直接发起的请求，带有转发头部的请求视为经过反向代理，被拒绝
+func loopbackOnly(c *Context) {
+	host, _, err := net.SplitHostPort(c.Req.RemoteAddr)
+	ip := net.ParseIP(host)
+	proxied := c.requestHeader("X-Forwarded-For") != "" || c.requestHeader("X-Real-IP") != "" || c.requestHeader("Forwarded") != ""
+	if err != nil || ip == nil || !ip.IsLoopback() || proxied {
+		c.Fail(http.StatusForbidden, "Forbidden")
+		return
+	}
+	c.Next()
+}
+
+// record 中间件在请求结束后记录请求
+func (ins *inspector) record(c *Context) {
+	if strings.HasPrefix(c.Path, inspectorPrefix) {
+		c.Next()
+		return
+	}
+	start := time.Now()
+	c.Next()
+
+	entry := InspectedRequest{
+		Time:     start,
+		Method:   c.Method,
+		URI:      ins.redactor.RedactURI(c.Req.RequestURI),
+		Status:   c.StatusCode,
+		Duration: time.Since(start),
+		Header:   ins.redactor.RedactHeader(c.Req.Header),
+		Aborted:  c.IsAborted(),
+		Template: c.template,
+	}
+	if c.route != nil {
+		entry.Route = c.route.Pattern
+	}
+	for _, handler := range c.handlers {
+		entry.Handlers = append(entry.Handlers, funcName(handler))
+	}
+	c.mu.Lock()
+	for _, err := range c.Errors {
+		entry.Errors = append(entry.Errors, err.Error())
+	}
+	c.mu.Unlock()
+
+	ins.mu.Lock()
+	if len(ins.entries) < cap(ins.entries) {
+		ins.entries = append(ins.entries, entry)
+	} else {
+		ins.entries[ins.next] = entry
+	}
+	ins.next = (ins.next + 1) % cap(ins.entries)
+	ins.mu.Unlock()
+}
+
+// recent 方法返回记录的请求，最近的在前
+func (ins *inspector) recent() []InspectedRequest {
+	ins.mu.Lock()
+	defer ins.mu.Unlock()
+	recent := make([]InspectedRequest, 0, len(ins.entries))
+	for i := 1; i <= len(ins.entries); i++ {
+		recent = append(recent, ins.entries[(ins.next-i+len(ins.entries))%len(ins.entries)])
+	}
+	return recent
+}
+
+// funcName 返回处理函数的函数名，如 main.main.func1
+func funcName(handler HandlerFunc) string {
+	if fn := runtime.FuncForPC(reflect.ValueOf(handler).Pointer()); fn != nil {
+		return fn.Name()
+	}
+	return "unknown"
+}
+
+// inspectorPage 请求检查器页面的模板
+var inspectorPage = template.Must(template.New("inspector").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>zinc inspector</title>
+<style>body{font:13px monospace;margin:1em}table{border-collapse:collapse;width:100%}
+td,th{border-bottom:1px solid #ddd;padding:4px;text-align:left;vertical-align:top}
+.err{color:#b00}details{margin:0}</style></head>
+<body><h1>Recent requests</h1><table>
+<tr><th>Time</th><th>Request</th><th>Route</th><th>Status</th><th>Duration</th><th>Details</th></tr>
+{{range .}}<tr><td>{{.Time.Format "15:04:05.000"}}</td><td>{{.Method}} {{.URI}}</td><td>{{.Route}}</td>
+<td>{{.Status}}{{if .Aborted}} aborted{{end}}</td><td>{{.Duration}}</td><td><details><summary>{{len .Handlers}} handlers{{if .Template}}, template {{.Template}}{{end}}{{if .Errors}}, <span class="err">{{len .Errors}} errors</span>{{end}}</summary>
+<ol>{{range .Handlers}}<li>{{.}}</li>{{end}}</ol>
+{{range .Errors}}<p class="err">{{.}}</p>{{end}}
+<ul>{{range $k, $v := .Header}}<li>{{$k}}: {{$v}}</li>{{end}}</ul></details></td></tr>
+{{else}}<tr><td colspan="6">No requests yet.</td></tr>{{end}}
+</table></body></html>`))
+
+// page 方法渲染请求检查器页面
+func (ins *inspector) page(c *Context) {
+	c.SetHeader("Content-Type", "text/html; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := inspectorPage.Execute(c.Writer, ins.recent()); err != nil {
+		c.Error(err)
+	}
+}
@@ -0,0 +1,71 @@
+package zinc
+
+import (
+	"hash/fnv"
+	"net/http"
+	"time"
+)
+
+// KeyLockConfig 按键串行化请求的中间件的配置
+type KeyLockConfig struct {
+	// Key 返回请求的键，键相同的请求依次执行；返回空字符串时不加锁
+	Key func(c *Context) string
+	// Stripes 锁的分片数，默认为 256。键按哈希分配到分片，不同的键可能共用一个分片而互相等待，
+	// 分片越多误等待越少
+	Stripes int
+	// Timeout 等待锁的最长时间，默认为 5 秒；超时以 503 状态码拒绝请求
+	Timeout time.Duration
+}
+
+// ParamKey 返回以路由参数 name 作为键的 KeyLockConfig.Key，如 ParamKey("accountID")
+func ParamKey(name string) func(c *Context) string {
+	return func(c *Context) string {
+		return c.Param(name)
+	}
+}
+
+// KeyLock 是按键串行化请求的中间件的构造函数，用于防止同一资源（如同一账户）的并发写请求交错执行，
+// 不需要分布式锁（只在单个进程内生效）：
+//
+//	accounts.POST("/:accountID/transfer", transfer).Use(zinc.KeyLock(zinc.KeyLockConfig{Key: zinc.ParamKey("accountID")}))
+//
+// 等待锁时请求被取消（如客户端断开）也会放弃等待。
+func KeyLock(config KeyLockConfig) HandlerFunc {
+	if config.Stripes <= 0 {
+		config.Stripes = 256
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 5 * time.Second
+	}
+	// 容量为 1 的通道作为可以超时等待的互斥锁
+	stripes := make([]chan struct{}, config.Stripes)
+	for i := range stripes {
+		stripes[i] = make(chan struct{}, 1)
+	}
+	return func(c *Context) {
+		key := config.Key(c)
+		if key == "" {
+			c.Next()
+			return
+		}
+		h := fnv.New32a()
+		h.Write([]byte(key))
+		lock := stripes[h.Sum32()%uint32(len(stripes))]
+
+		timer := time.NewTimer(config.Timeout)
+		defer timer.Stop()
+		select {
+		case lock <- struct{}{}:
+		case <-timer.C:
+			c.Fail(http.StatusServiceUnavailable, "Service Unavailable: resource is busy")
+			return
+		case <-c.Req.Context().Done():
+			c.Abort()
+			return
+		}
+		defer func() {
+			<-lock
+		}()
+		c.Next()
+	}
+}
@@ -0,0 +1,78 @@
+package zinc
+
+import "time"
+
+// Get 返回 c.Keys 中 key 对应的 T 类型的值，键不存在或值不是 T 类型时 ok 为 false。
+//
+// 如：user, ok := zinc.Get[*User](c, "user")
+func Get[T any](c *Context, key string) (value T, ok bool) {
+	v, exists := c.Get(key)
+	if !exists {
+		return value, false
+	}
+	value, ok = v.(T)
+	return value, ok
+}
+
+// MustGet 返回 c.Keys 中 key 对应的 T 类型的值，键不存在或值不是 T 类型时 panic
+func MustGet[T any](c *Context, key string) T {
+	value, ok := Get[T](c, key)
+	if !ok {
+		panic("zinc: key \"" + key + "\" does not exist or has a different type")
+	}
+	return value
+}
+
+// GetString 方法返回 c.Keys 中 key 对应的字符串，不存在或类型不符时返回空字符串
+func (c *Context) GetString(key string) string {
+	value, _ := Get[string](c, key)
+	return value
+}
+
+// GetBool 方法返回 c.Keys 中 key 对应的布尔值，不存在或类型不符时返回 false
+func (c *Context) GetBool(key string) bool {
+	value, _ := Get[bool](c, key)
+	return value
+}
+
+// GetInt 方法返回 c.Keys 中 key 对应的整数，不存在或类型不符时返回 0
+func (c *Context) GetInt(key string) int {
+	value, _ := Get[int](c, key)
+	return value
+}
+
+// GetInt64 方法返回 c.Keys 中 key 对应的 int64，不存在或类型不符时返回 0
+func (c *Context) GetInt64(key string) int64 {
+	value, _ := Get[int64](c, key)
+	return value
+}
+
+// GetFloat64 方法返回 c.Keys 中 key 对应的 float64，不存在或类型不符时返回 0
+func (c *Context) GetFloat64(key string) float64 {
+	value, _ := Get[float64](c, key)
+	return value
+}
+
+// GetTime 方法返回 c.Keys 中 key 对应的时间，不存在或类型不符时返回零值
+func (c *Context) GetTime(key string) time.Time {
+	value, _ := Get[time.Time](c, key)
+	return value
+}
+
+// GetDuration 方法返回 c.Keys 中 key 对应的时长，不存在或类型不符时返回 0
+func (c *Context) GetDuration(key string) time.Duration {
+	value, _ := Get[time.Duration](c, key)
+	return value
+}
+
+// GetStringSlice 方法返回 c.Keys 中 key 对应的字符串切片，不存在或类型不符时返回 nil
+func (c *Context) GetStringSlice(key string) []string {
+	value, _ := Get[[]string](c, key)
+	return value
+}
+
+// GetStringMap 方法返回 c.Keys 中 key 对应的 map[string]interface{}，不存在或类型不符时返回 nil
+func (c *Context) GetStringMap(key string) map[string]interface{} {
+	value, _ := Get[map[string]interface{}](c, key)
+	return value
+}
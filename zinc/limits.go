@@ -0,0 +1,53 @@
+package zinc
+
+import (
+	"net/http"
+	"strings"
+)
+
+// checkLimits 方法按 MaxQueryLength、MaxQueryParams、MaxHeaderCount 和 MaxHeaderBytes 检查请求，
+// 超过限制时返回 414 或 431 状态码，否则返回 0。检查时不解析查询字符串，避免为异常的输入分配内存。
+func (engine *Engine) checkLimits(req *http.Request) int {
+	query := req.URL.RawQuery
+	if engine.MaxQueryLength > 0 && len(query) > engine.MaxQueryLength {
+		return http.StatusRequestURITooLong
+	}
+	if engine.MaxQueryParams > 0 && queryParamCount(query) > engine.MaxQueryParams {
+		return http.StatusRequestURITooLong
+	}
+	if engine.MaxHeaderCount > 0 && len(req.Header) > engine.MaxHeaderCount {
+		return http.StatusRequestHeaderFieldsTooLarge
+	}
+	if engine.MaxHeaderBytes > 0 && headerSize(req.Header) > engine.MaxHeaderBytes {
+		return http.StatusRequestHeaderFieldsTooLarge
+	}
+	return 0
+}
+
+// queryParamCount 返回查询字符串中参数的数量，空的参数（如 a=1&&b=2 中间的部分）不计入
+func queryParamCount(query string) int {
+	count := 0
+	for query != "" {
+		var part string
+		if i := strings.IndexAny(query, "&;"); i >= 0 {
+			part, query = query[:i], query[i+1:]
+		} else {
+			part, query = query, ""
+		}
+		if part != "" {
+			count++
+		}
+	}
+	return count
+}
+
+// headerSize 返回请求头部名称和值的总字节数
+func headerSize(header http.Header) int {
+	size := 0
+	for key, values := range header {
+		for _, value := range values {
+			size += len(key) + len(value)
+		}
+	}
+	return size
+}
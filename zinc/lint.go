@@ -0,0 +1,165 @@
+package zinc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LintRoutes 检查 engine 的路由表，返回发现的问题，适合在项目自己的测试中调用，保持大型路由表的整洁：
+//
+//   - shadowed：注册到同一节点的路由，后注册的覆盖先注册的，如 /hello/:name 和通过 AddRoute 注册的 /hello/:id
+//   - param-name：前缀相同、位置相同的参数使用了不同的参数名，如 /users/:id 和 /users/:uid/posts
+//   - catch-all-mask：Host 分组的通配符路由使普通路由在该 Host 下无法匹配，如 Host("api.example.com") 的 /static/*filepath
+//     和普通路由 /static/app.js（Host 分组只在没有匹配时才回退到普通路由）；或同一 Host 下的通配符路由抢先匹配了
+//     参数路由的部分请求，如 /static/*filepath 和 /:dir/app.js（/static/app.js 由通配符路由处理）
+//   - group-prefix：分组前缀与子分组前缀或路由地址之间缺少斜杠，如 Group("/api").GET("users") 注册为 /apiusers
+//
+// 如：
+//
+//	for _, d := range zinc.LintRoutes(engine) {
+//		t.Error(d)
+//	}
+func LintRoutes(engine *Engine) []Diagnostic {
+	var diagnostics []Diagnostic
+	for _, pair := range engine.overrides() {
+		route, first := pair[0], pair[1]
+		diagnostics = append(diagnostics, Diagnostic{
+			Kind:    "shadowed",
+			Message: fmt.Sprintf("%s %s%s shadows %s%s", route.Method, route.Host, route.Pattern, first.Host, first.Pattern),
+		})
+	}
+	routes := engine.routeList()
+	diagnostics = append(diagnostics, lintParamNames(routes)...)
+	diagnostics = append(diagnostics, lintCatchAlls(routes)...)
+	diagnostics = append(diagnostics, lintGroupPrefixes(engine)...)
+	return diagnostics
+}
+
+// lintParamNames 查找前缀相同、位置相同、约束相同但参数名不同的参数
+func lintParamNames(routes []*Route) []Diagnostic {
+	var diagnostics []Diagnostic
+	// 键为 Host、请求方法、参数之前的路径和约束，值为第一次出现的参数名及其路由
+	type first struct {
+		name  string
+		route *Route
+	}
+	seen := make(map[string]first)
+	reported := make(map[string]bool)
+	for _, route := range routes {
+		parts := parsePattern(route.Pattern)
+		for index, part := range parts {
+			if part[0] != ':' {
+				continue
+			}
+			key := route.Host + " " + route.Method + " /" + strings.Join(parts[:index], "/") + " <" + constraintOf(part) + ">"
+			name := paramName(part)
+			f, ok := seen[key]
+			if !ok {
+				seen[key] = first{name: name, route: route}
+				continue
+			}
+			if f.name != name && !reported[key+" "+name] {
+				reported[key+" "+name] = true
+				diagnostics = append(diagnostics, Diagnostic{
+					Kind:    "param-name",
+					Message: fmt.Sprintf("%s %s%s names parameter %q, but %s%s names it %q", route.Method, route.Host, route.Pattern, name, f.route.Host, f.route.Pattern, f.name),
+				})
+			}
+		}
+	}
+	return diagnostics
+}
+
+// lintCatchAlls 查找被通配符路由遮住的路由：Host 分组的通配符路由遮住普通路由，
+// 或同一 Host（包括普通路由之间）的通配符路由在参数位置上抢先匹配了另一条路由
+func lintCatchAlls(routes []*Route) []Diagnostic {
+	var diagnostics []Diagnostic
+	for _, catchAll := range routes {
+		parts := parsePattern(catchAll.Pattern)
+		if len(parts) == 0 || parts[len(parts)-1][0] != '*' {
+			continue
+		}
+		prefix := parts[:len(parts)-1]
+		for _, route := range routes {
+			if route == catchAll || route.Method != catchAll.Method {
+				continue
+			}
+			routeParts := parsePattern(route.Pattern)
+			if len(routeParts) <= len(prefix) {
+				continue
+			}
+			switch {
+			case catchAll.Host != "" && route.Host == "" && coversParts(prefix, routeParts):
+				diagnostics = append(diagnostics, Diagnostic{
+					Kind:    "catch-all-mask",
+					Message: fmt.Sprintf("%s %s%s masks %s on host %s", catchAll.Method, catchAll.Host, catchAll.Pattern, route.Pattern, catchAll.Host),
+				})
+			case catchAll.Host == route.Host && preemptsParts(prefix, routeParts):
+				diagnostics = append(diagnostics, Diagnostic{
+					Kind:    "catch-all-mask",
+					Message: fmt.Sprintf("%s %s%s masks %s%s where its parameters match %s", catchAll.Method, catchAll.Host, catchAll.Pattern, route.Host, route.Pattern, "/"+strings.Join(prefix, "/")),
+				})
+			}
+		}
+	}
+	return diagnostics
+}
+
+// coversParts 判断 prefix 是否匹配 parts 开头的部分，prefix 中的参数匹配任意part
+func coversParts(prefix []string, parts []string) bool {
+	for index, part := range prefix {
+		if part[0] != ':' && part != parts[index] {
+			return false
+		}
+	}
+	return true
+}
+
+// preemptsParts 判断同一棵路由树中以 prefix 开头的通配符路由是否抢先匹配了 parts 的部分请求：
+// 静态part优先于参数，所以只有 parts 在 prefix 的静态part的位置上是（满足约束的）参数时才会被遮住
+func preemptsParts(prefix []string, parts []string) bool {
+	preempts := false
+	for index, part := range prefix {
+		other := parts[index]
+		switch {
+		case part[0] != ':' && other[0] != ':':
+			if part != other {
+				return false
+			}
+		case part[0] != ':':
+			if expr := constraintOf(other); expr != "" && !compileConstraint(expr).MatchString(part) {
+				return false
+			}
+			preempts = true
+		case other[0] != ':':
+			// 静态part优先匹配，通配符路由所在的分支不会被尝试
+			return false
+		}
+	}
+	return preempts
+}
+
+// lintGroupPrefixes 查找前缀与上层分组前缀或路由地址直接拼接、中间缺少斜杠的分组
+func lintGroupPrefixes(engine *Engine) []Diagnostic {
+	var diagnostics []Diagnostic
+	joined := func(prefix string, rest string) bool {
+		return prefix != "" && rest != "" && !strings.HasSuffix(prefix, "/") && !strings.HasPrefix(rest, "/")
+	}
+	for _, group := range engine.groupList() {
+		if group.parent != nil && joined(group.parent.prefix, group.prefix[len(group.parent.prefix):]) {
+			diagnostics = append(diagnostics, Diagnostic{
+				Kind:    "group-prefix",
+				Message: fmt.Sprintf("group %s%s is joined to its parent %s without a slash", group.host, group.prefix, group.parent.prefix),
+			})
+		}
+	}
+	for _, route := range engine.routeList() {
+		if joined(route.group.prefix, route.Pattern[len(route.group.prefix):]) {
+			diagnostics = append(diagnostics, Diagnostic{
+				Kind:    "group-prefix",
+				Message: fmt.Sprintf("%s %s%s is joined to group prefix %s without a slash", route.Method, route.Host, route.Pattern, route.group.prefix),
+			})
+		}
+	}
+	return diagnostics
+}
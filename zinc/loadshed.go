@@ -0,0 +1,127 @@
+package zinc
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Priority 请求优先级，过载时优先拒绝低优先级的请求
+type Priority int
+
+const (
+	PriorityLow      Priority = iota // 低优先级，最先被拒绝
+	PriorityNormal                   // 普通优先级，在途请求达到上限时被拒绝
+	PriorityCritical                 // 关键请求，从不被拒绝
+)
+
+// LoadShedConfig 负载保护中间件的配置
+type LoadShedConfig struct {
+	// Classify 返回请求的优先级，为空时所有请求均为普通优先级。
+	// 请求头部由客户端控制，只有在请求来自可信的内部服务时才应使用 PriorityFromHeader
+	Classify func(c *Context) Priority
+	// MaxInFlight 在途请求数上限，为 0 时不限制。
+	// 普通优先级请求在达到上限时被拒绝，低优先级请求在达到上限的一半时被拒绝。
+	MaxInFlight int64
+	// MaxLatency 请求耗时（指数加权平均）的上限，为 0 时不限制；超过时拒绝低优先级请求，
+	// 但每个 MaxLatency 间隔放行一个低优先级请求更新平均耗时，使负载下降后能够恢复
+	MaxLatency time.Duration
+	// RetryAfter 拒绝请求时 Retry-After 头部的秒数，为 0 时不设置
+	RetryAfter int
+	// OnShed 请求被拒绝时的回调，可用于上报指标
+	OnShed func(c *Context, priority Priority)
+}
+
+// PriorityFromHeader 按请求头部 X-Priority（low、normal、critical）对请求分类，缺省为普通优先级，
+// 可以作为 LoadShedConfig.Classify 使用
+func PriorityFromHeader(c *Context) Priority {
+	switch strings.ToLower(c.requestHeader("X-Priority")) {
+	case "low":
+		return PriorityLow
+	case "critical":
+		return PriorityCritical
+	}
+	return PriorityNormal
+}
+
+// LoadShedding 是负载保护中间件的构造函数。
+// 中间件统计在途请求数和请求耗时，过载时按优先级以 503 状态码拒绝请求。
+func LoadShedding(config LoadShedConfig) HandlerFunc {
+	if config.Classify == nil {
+		config.Classify = func(*Context) Priority { return PriorityNormal }
+	}
+	var inFlight int64
+	var mu sync.Mutex
+	var latency time.Duration // 请求耗时的指数加权平均值
+	var probe time.Time       // 上一次因耗时过高而放行探测请求的时间
+
+	// slow 判断平均耗时是否超过上限，超过时每个 MaxLatency 间隔放行一次
+	slow := func() bool {
+		if config.MaxLatency <= 0 {
+			return false
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if latency <= config.MaxLatency {
+			return false
+		}
+		if now := time.Now(); now.Sub(probe) >= config.MaxLatency {
+			probe = now
+			return false
+		}
+		return true
+	}
+
+	// admit 判断是否放行请求，放行时将请求计入在途请求数
+	admit := func(priority Priority) bool {
+		if priority == PriorityLow && slow() {
+			return false
+		}
+		if priority == PriorityCritical || config.MaxInFlight <= 0 {
+			atomic.AddInt64(&inFlight, 1)
+			return true
+		}
+		limit := config.MaxInFlight
+		if priority == PriorityLow {
+			limit /= 2
+		}
+		// 检查和计入之间不能有其他请求插入，否则并发请求会同时通过检查
+		for {
+			current := atomic.LoadInt64(&inFlight)
+			if current >= limit {
+				return false
+			}
+			if atomic.CompareAndSwapInt64(&inFlight, current, current+1) {
+				return true
+			}
+		}
+	}
+
+	return func(c *Context) {
+		priority := config.Classify(c)
+		if !admit(priority) {
+			if config.OnShed != nil {
+				config.OnShed(c, priority)
+			}
+			if config.RetryAfter > 0 {
+				c.SetHeader("Retry-After", strconv.Itoa(config.RetryAfter))
+			}
+			c.Fail(http.StatusServiceUnavailable, "Service Unavailable")
+			return
+		}
+
+		t := time.Now()
+		defer func() {
+			atomic.AddInt64(&inFlight, -1)
+			elapsed := time.Since(t)
+			mu.Lock()
+			// 新样本权重为 1/8
+			latency += (elapsed - latency) / 8
+			mu.Unlock()
+		}()
+		c.Next()
+	}
+}
@@ -2,16 +2,86 @@ package zinc
 
 import (
 	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// LoggerConfig 访问日志中间件的配置
+type LoggerConfig struct {
+	// Redactor 写日志前屏蔽敏感的查询参数、头部和 JSON 字段，为空时使用 Engine.Redactor
+	Redactor *Redactor
+	// LogHeaders 为true时在日志中附加请求头部
+	LogHeaders bool
+	// LogBody 为true时在日志中附加已读入内存的 JSON 请求体（由 ShouldBindBodyWith、GetRawData 等读入），
+	// 中间件本身不读取请求体
+	LogBody bool
+}
+
+// Logger 访问日志中间件，使用默认的脱敏规则
 func Logger() HandlerFunc {
+	return LoggerWith(LoggerConfig{})
+}
+
+// LoggerWithRedactor 访问日志中间件，写日志前使用 redactor 屏蔽 URI 中的敏感参数
+func LoggerWithRedactor(redactor *Redactor) HandlerFunc {
+	return LoggerWith(LoggerConfig{Redactor: redactor})
+}
+
+// LoggerWith 是可配置的访问日志中间件的构造函数，见 LoggerConfig
+func LoggerWith(config LoggerConfig) HandlerFunc {
 	return func(c *Context) {
+		redactor := config.Redactor
+		if redactor == nil {
+			redactor = c.engine.redactor()
+		}
 		// 启动计时器
 		t := time.Now()
 		// 处理请求
 		c.Next()
 		// 计算解决时间
-		log.Printf("[%d] %s in %v", c.StatusCode, c.Req.RequestURI, time.Since(t))
+		elapsed := time.Since(t)
+		// 被中间件中止的请求在状态码后标记 aborted
+		status := strconv.Itoa(c.StatusCode)
+		if c.IsAborted() {
+			status += " aborted"
+		}
+		line := "[" + status + "] " + redactor.RedactURI(c.Req.RequestURI) + " in " + elapsed.String()
+		if calls := c.OutboundCalls(); len(calls) > 0 {
+			// 汇总下游调用的次数和耗时
+			var outbound time.Duration
+			for _, call := range calls {
+				outbound += call.Duration
+			}
+			line += " (" + strconv.Itoa(len(calls)) + " outbound calls in " + outbound.String() + ")"
+		}
+		if config.LogHeaders {
+			line += " headers=" + formatHeader(redactor.RedactHeader(c.Req.Header))
+		}
+		if config.LogBody && len(c.body) > 0 && strings.Contains(c.ContentType(), "json") {
+			line += " body=" + string(redactor.RedactJSON(c.body))
+		}
+		log.Print(line)
+	}
+}
+
+// formatHeader 将头部按名称排序后格式化为一行，如 {Accept: */*; Authorization: ******}
+func formatHeader(header http.Header) string {
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteByte('{')
+	for index, key := range keys {
+		if index > 0 {
+			b.WriteString("; ")
+		}
+		b.WriteString(key + ": " + strings.Join(header[key], ", "))
 	}
+	b.WriteByte('}')
+	return b.String()
 }
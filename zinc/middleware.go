@@ -0,0 +1,17 @@
+package zinc
+
+import "strings"
+
+// OnMethods 返回只对 methods 中的请求方法执行 middleware 的中间件，其余请求直接进入后面的处理函数。
+// 如：g.Use(zinc.OnMethods([]string{"POST", "PUT"}, csrf()))
+func OnMethods(methods []string, middleware HandlerFunc) HandlerFunc {
+	return func(c *Context) {
+		for _, method := range methods {
+			if strings.EqualFold(method, c.Method) {
+				middleware(c)
+				return
+			}
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,146 @@
+package zinc
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// MirrorConfig 请求镜像（影子流量）中间件的配置，Handler 和 URL 至少设置一个
+type MirrorConfig struct {
+	// Percent 镜像请求的比例（0~100），如 10 表示随机镜像 10% 的请求
+	Percent float64
+	// Handler 非空时将镜像请求交给 Handler 处理，如同一进程中的新版本实现
+	Handler http.Handler
+	// URL 非空时将镜像请求发送到该上游地址（如 http://canary:8080），请求路径和查询参数保持不变
+	URL string
+	// Client 发送镜像请求使用的客户端，为空时使用超时时间为 5 秒的客户端
+	Client *http.Client
+	// MaxBodyBytes 镜像请求体的大小上限，为 0 时为 1MB；请求体超过上限的请求不镜像
+	MaxBodyBytes int64
+	// MaxConcurrent 同时进行的镜像请求数上限，为 0 时为 64；达到上限时新的请求不镜像，避免上游变慢时 goroutine 堆积
+	MaxConcurrent int
+	// ForwardCredentials 为true时镜像请求保留 Authorization、Cookie 等凭据头部，默认删除，
+	// 避免将用户凭据发送给影子环境
+	ForwardCredentials bool
+}
+
+// credentialHeaders 镜像请求默认删除的凭据头部
+var credentialHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+
+// Mirror 是请求镜像中间件的构造函数。
+// 被抽样的请求会在原请求处理的同时，以异步的方式复制一份（包括请求体，带有 X-Shadow-Request 头部）
+// 发送给 config.Handler 或 config.URL，镜像的响应被丢弃，不影响原请求的处理和响应。
+func Mirror(config MirrorConfig) HandlerFunc {
+	if config.Client == nil {
+		config.Client = &http.Client{Timeout: 5 * time.Second}
+	}
+	if config.MaxBodyBytes == 0 {
+		config.MaxBodyBytes = 1 << 20
+	}
+	if config.MaxConcurrent == 0 {
+		config.MaxConcurrent = 64
+	}
+	sem := make(chan struct{}, config.MaxConcurrent)
+	return func(c *Context) {
+		if rand.Float64()*100 >= config.Percent {
+			c.Next()
+			return
+		}
+		select {
+		case sem <- struct{}{}:
+		default:
+			// 镜像请求已达上限，丢弃本次镜像
+			c.Next()
+			return
+		}
+		body, ok := mirrorBody(c, config.MaxBodyBytes)
+		if !ok {
+			<-sem
+			c.Next()
+			return
+		}
+		// 镜像请求不随原请求结束而取消
+		shadow := c.Req.Clone(context.Background())
+		shadow.Header.Set("X-Shadow-Request", "1")
+		if !config.ForwardCredentials {
+			for _, key := range credentialHeaders {
+				shadow.Header.Del(key)
+			}
+		}
+		go func() {
+			defer func() { <-sem }()
+			sendMirror(config, shadow, body)
+		}()
+		c.Next()
+	}
+}
+
+// mirrorBody 读取不超过 limit 字节的请求体并恢复 c.Req.Body，请求体超过上限时 ok 为 false
+func mirrorBody(c *Context, limit int64) (body []byte, ok bool) {
+	if c.Req.Body == nil || c.Req.Body == http.NoBody {
+		return nil, true
+	}
+	data, err := io.ReadAll(io.LimitReader(c.Req.Body, limit+1))
+	// 已读取的部分和剩余的请求体拼接起来，原请求仍然可以完整读取
+	c.Req.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), c.Req.Body), c.Req.Body}
+	if err != nil || int64(len(data)) > limit {
+		return nil, false
+	}
+	return data, true
+}
+
+// sendMirror 发送镜像请求并丢弃响应
+func sendMirror(config MirrorConfig, shadow *http.Request, body []byte) {
+	defer func() {
+		if err := recover(); err != nil {
+			log.Printf("mirror: %v", err)
+		}
+	}()
+	if config.Handler != nil {
+		shadow.Body = io.NopCloser(bytes.NewReader(body))
+		config.Handler.ServeHTTP(&discardWriter{header: make(http.Header)}, shadow)
+	}
+	if config.URL == "" {
+		return
+	}
+	req, err := http.NewRequest(shadow.Method, strings.TrimSuffix(config.URL, "/")+shadow.URL.RequestURI(), bytes.NewReader(body))
+	if err != nil {
+		log.Printf("mirror: %v", err)
+		return
+	}
+	req.Header = shadow.Header
+	resp, err := config.Client.Do(req)
+	if err != nil {
+		log.Printf("mirror: %v", err)
+		return
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+}
+
+// discardWriter 丢弃所有输出的 http.ResponseWriter
+type discardWriter struct {
+	header http.Header
+}
+
+// Header 方法返回响应头部
+func (w *discardWriter) Header() http.Header {
+	return w.header
+}
+
+// Write 方法丢弃数据
+func (w *discardWriter) Write(data []byte) (int, error) {
+	return len(data), nil
+}
+
+// WriteHeader 方法丢弃状态码
+func (w *discardWriter) WriteHeader(int) {}
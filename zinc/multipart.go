@@ -0,0 +1,287 @@
+package zinc
+
+import (
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+)
+
+// ErrPartTooLarge 读取的 multipart 部分超过 MultipartReader.MaxPartBytes 时返回的错误
+var ErrPartTooLarge = errors.New("zinc: multipart part exceeds the size limit")
+
+// ErrTooManyParts multipart 请求体的部分数超过 MultipartReader.MaxParts 时返回的错误
+var ErrTooManyParts = errors.New("zinc: multipart body has too many parts")
+
+// MultipartReader 流式读取 multipart/form-data 请求体，每个部分到达时交给回调处理，
+// 不在内存或临时文件中缓冲，适合将大文件直接转存到对象存储
+type MultipartReader struct {
+	// MaxPartBytes 每个部分的字节数上限，为 0 时不限制；超过时 Part.Read 返回 ErrPartTooLarge
+	MaxPartBytes int64
+	// MaxParts 部分数的上限，为 0 时不限制；超过时 Each 返回 ErrTooManyParts
+	MaxParts int
+	// Inspectors 检查每个文件部分的内容，见 PartInspector
+	Inspectors []PartInspector
+
+	reader *multipart.Reader
+}
+
+// Part multipart 请求体中的一个部分，Read 方法遵守 MaxPartBytes 的限制
+type Part struct {
+	*multipart.Part
+	limit  int64
+	read   int64
+	checks []partCheck
+	done   bool // 已读到末尾并完成检查
+}
+
+// MultipartReader 方法返回流式读取请求体的 MultipartReader，请求不是 multipart/form-data 时返回错误。
+// 与 ParseMultipartForm 和 c.PostForm 互斥：请求体只能读取一次。
+func (c *Context) MultipartReader() (*MultipartReader, error) {
+	reader, err := c.Req.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+	return &MultipartReader{reader: reader}, nil
+}
+
+// Each 方法依次读取每个部分并调用 fn，fn 返回错误或读取出错时停止并返回该错误。
+// fn 不需要读完部分的内容，剩余的内容会被丢弃；Part 在 fn 返回后不能再使用。如：
+//
+//	mr, err := c.MultipartReader()
+//	mr.MaxPartBytes = 1 << 30
+//	err = mr.Each(func(part *zinc.Part) error {
+//		if part.FileName() == "" {
+//			return nil
+//		}
+//		return bucket.Upload(c.Req.Context(), part.FileName(), part)
+//	})
+//	if errors.Is(err, zinc.ErrPartTooLarge) {
+//		c.Fail(http.StatusRequestEntityTooLarge, err.Error())
+//	}
+func (mr *MultipartReader) Each(fn func(part *Part) error) error {
+	for count := 1; ; count++ {
+		p, err := mr.reader.NextPart()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if mr.MaxParts > 0 && count > mr.MaxParts {
+			p.Close()
+			return ErrTooManyParts
+		}
+		part := &Part{Part: p, limit: mr.MaxPartBytes}
+		if p.FileName() != "" {
+			if part.checks, err = startChecks(mr.Inspectors, partInfo(p.FormName(), p.FileName(), p.Header)); err != nil {
+				p.Close()
+				return err
+			}
+		}
+		err = fn(part)
+		if err == nil && !part.done && len(part.checks) > 0 {
+			// fn 没有读完时读取剩余的内容，检查仍然对整个部分生效
+			_, err = io.Copy(io.Discard, part)
+		}
+		part.abortChecks()
+		p.Close()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Read 方法读取部分的内容，累计超过 MaxPartBytes 时返回 ErrPartTooLarge
+func (p *Part) Read(data []byte) (int, error) {
+	if p.limit > 0 {
+		if p.read > p.limit {
+			return 0, ErrPartTooLarge
+		}
+		// 最多多读一个字节，用于判断是否超出限制
+		if room := p.limit - p.read + 1; int64(len(data)) > room {
+			data = data[:room]
+		}
+	}
+	n, err := p.Part.Read(data)
+	p.read += int64(n)
+	if p.limit > 0 && p.read > p.limit {
+		return n - int(p.read-p.limit), ErrPartTooLarge
+	}
+	if len(p.checks) > 0 {
+		// 内容先交给检查器，检查失败的数据不返回给调用方
+		if checkErr := writeChecks(p.checks, data[:n]); checkErr != nil {
+			return 0, checkErr
+		}
+		if errors.Is(err, io.EOF) && !p.done {
+			p.done = true
+			if checkErr := finishChecks(p.checks); checkErr != nil {
+				return n, checkErr
+			}
+		}
+	}
+	return n, err
+}
+
+// abortChecks 方法关闭未完成的检查器，释放外部扫描等资源
+func (p *Part) abortChecks() {
+	if !p.done {
+		p.done = true
+		for _, check := range p.checks {
+			check.w.Close()
+		}
+	}
+}
+
+// PartInfo 被检查的文件部分的描述
+type PartInfo struct {
+	FormName string               // 表单字段名
+	FileName string               // 客户端提供的文件名
+	Header   textproto.MIMEHeader // 部分的头部，包括客户端声明的 Content-Type
+}
+
+// PartInspector 上传文件的检查器（如大小限制、按文件头识别类型、调用外部病毒扫描），
+// 用于 MultipartReader.Inspectors 和 InspectUploads 中间件。
+//
+// Inspect 方法在每个文件部分开始时调用，返回错误时直接拒绝上传；返回的 io.WriteCloser 依次收到部分的全部内容，
+// Write 返回错误时立即拒绝，Close 在内容结束后调用，返回错误时拒绝。返回 nil 时不检查该部分。
+// 检查在处理函数保存内容之前完成：流式读取时，检查失败的数据不会交给读取方，
+// 最后一次 Read 在 Close 通过后才返回 io.EOF。
+type PartInspector interface {
+	Inspect(info PartInfo) (io.WriteCloser, error)
+}
+
+// InspectionError 上传文件未通过检查的错误，Err 为检查器返回的错误
+type InspectionError struct {
+	FormName string
+	FileName string
+	Err      error
+}
+
+// Error 方法返回错误描述
+func (e *InspectionError) Error() string {
+	return "upload " + e.FormName + " (" + e.FileName + ") rejected: " + e.Err.Error()
+}
+
+// Unwrap 方法返回检查器返回的错误
+func (e *InspectionError) Unwrap() error {
+	return e.Err
+}
+
+// partCheck 一个进行中的检查
+type partCheck struct {
+	info PartInfo
+	w    io.WriteCloser
+}
+
+// partInfo 返回文件部分的描述
+func partInfo(formName string, fileName string, header textproto.MIMEHeader) PartInfo {
+	return PartInfo{FormName: formName, FileName: fileName, Header: header}
+}
+
+// startChecks 依次调用检查器的 Inspect 方法，任一检查器拒绝时关闭已开始的检查并返回 *InspectionError
+func startChecks(inspectors []PartInspector, info PartInfo) ([]partCheck, error) {
+	var checks []partCheck
+	for _, inspector := range inspectors {
+		w, err := inspector.Inspect(info)
+		if err != nil {
+			for _, check := range checks {
+				check.w.Close()
+			}
+			return nil, &InspectionError{FormName: info.FormName, FileName: info.FileName, Err: err}
+		}
+		if w != nil {
+			checks = append(checks, partCheck{info: info, w: w})
+		}
+	}
+	return checks, nil
+}
+
+// writeChecks 将 data 写给每个检查器
+func writeChecks(checks []partCheck, data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	for _, check := range checks {
+		if _, err := check.w.Write(data); err != nil {
+			return &InspectionError{FormName: check.info.FormName, FileName: check.info.FileName, Err: err}
+		}
+	}
+	return nil
+}
+
+// finishChecks 关闭所有检查器，返回第一个拒绝的结果
+func finishChecks(checks []partCheck) error {
+	var first error
+	for _, check := range checks {
+		if err := check.w.Close(); err != nil && first == nil {
+			first = &InspectionError{FormName: check.info.FormName, FileName: check.info.FileName, Err: err}
+		}
+	}
+	return first
+}
+
+// InspectUploads 是上传检查中间件的构造函数。multipart/form-data 请求先按 ParseMultipartForm 解析，
+// 每个上传的文件依次交给 inspectors 检查，全部通过后才调用后面的处理函数，处理函数中的 c.Req.FormFile、
+// ShouldBindForm 等直接使用解析的结果。检查未通过时记录 *InspectionError（c.Error），
+// 并以 422 状态码（超出大小限制，即错误为 ErrPartTooLarge 时为 413）中止请求；
+// 检查器返回 *UploadError（如 MaxFileSize、SniffContentType）时响应中包含它的字段。
+// 需要流式处理大文件时使用 c.MultipartReader 并设置 MultipartReader.Inspectors。
+func InspectUploads(inspectors ...PartInspector) HandlerFunc {
+	return func(c *Context) {
+		if !strings.HasPrefix(c.requestHeader("Content-Type"), "multipart/form-data") {
+			c.Next()
+			return
+		}
+		if err := c.Req.ParseMultipartForm(defaultMultipartMemory); err != nil {
+			c.Error(err)
+			c.Fail(http.StatusBadRequest, err.Error())
+			return
+		}
+		for formName, files := range c.Req.MultipartForm.File {
+			for _, file := range files {
+				if err := inspectFile(inspectors, formName, file); err != nil {
+					c.abortUpload(err)
+					return
+				}
+			}
+		}
+		c.Next()
+	}
+}
+
+// inspectFile 将已解析的上传文件交给检查器
+func inspectFile(inspectors []PartInspector, formName string, file *multipart.FileHeader) error {
+	checks, err := startChecks(inspectors, partInfo(formName, file.Filename, file.Header))
+	if err != nil || len(checks) == 0 {
+		return err
+	}
+	f, err := file.Open()
+	if err != nil {
+		finishChecks(checks)
+		return err
+	}
+	defer f.Close()
+	buf := make([]byte, 32<<10)
+	for {
+		n, err := f.Read(buf)
+		if checkErr := writeChecks(checks, buf[:n]); checkErr != nil {
+			finishChecks(checks)
+			return checkErr
+		}
+		if errors.Is(err, io.EOF) {
+			return finishChecks(checks)
+		}
+		if err != nil {
+			finishChecks(checks)
+			return err
+		}
+	}
+}
+
+// Size 方法返回已经读取的字节数
+func (p *Part) Size() int64 {
+	return p.read
+}
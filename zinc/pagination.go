@@ -0,0 +1,122 @@
+package zinc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Pagination 分页参数，从查询字符串 ?page=2&limit=20 或 ?cursor=xxx&limit=20 中解析
+type Pagination struct {
+	Page   int    `json:"page"`             // 页码，从 1 开始
+	Limit  int    `json:"limit"`            // 每页条数
+	Cursor string `json:"cursor,omitempty"` // 游标分页时的游标，为空表示第一页
+}
+
+// PaginationConfig 分页参数的默认值与上限
+type PaginationConfig struct {
+	DefaultLimit int // 未提供 limit 时的每页条数
+	MaxLimit     int // limit 的上限，超过时截断
+}
+
+// DefaultPaginationConfig 是 c.Pagination 使用的默认配置
+var DefaultPaginationConfig = PaginationConfig{DefaultLimit: 20, MaxLimit: 100}
+
+// fallbackPageLimit 配置的 DefaultLimit 不大于 0 时使用的每页条数
+const fallbackPageLimit = 20
+
+// pageLimit 返回 limit，不大于 0 时（如 DefaultLimit 为 0 或手动构造的 Pagination）返回默认的每页条数
+func pageLimit(limit int) int {
+	if limit > 0 {
+		return limit
+	}
+	if DefaultPaginationConfig.DefaultLimit > 0 {
+		return DefaultPaginationConfig.DefaultLimit
+	}
+	return fallbackPageLimit
+}
+
+// PageResult 标准的分页JSON响应结构
+type PageResult struct {
+	Data       interface{} `json:"data"`
+	Page       int         `json:"page,omitempty"`
+	Limit      int         `json:"limit"`
+	Total      int         `json:"total,omitempty"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+// Offset 方法返回页码分页时对应的偏移量，可直接用于数据库查询
+func (p Pagination) Offset() int {
+	return (p.Page - 1) * p.Limit
+}
+
+// Pagination 方法按默认配置从查询字符串解析分页参数
+func (c *Context) Pagination() Pagination {
+	return c.PaginationWith(DefaultPaginationConfig)
+}
+
+// PaginationWith 方法按配置 config 从查询字符串解析分页参数。
+// 缺失或非法的 page、limit 使用默认值，limit 超过上限时截断为 MaxLimit。
+func (c *Context) PaginationWith(config PaginationConfig) Pagination {
+	p := Pagination{Page: 1, Limit: pageLimit(config.DefaultLimit), Cursor: c.Query("cursor")}
+	if page, err := strconv.Atoi(c.Query("page")); err == nil && page > 0 {
+		p.Page = page
+	}
+	if limit, err := strconv.Atoi(c.Query("limit")); err == nil && limit > 0 {
+		p.Limit = limit
+	}
+	if config.MaxLimit > 0 && p.Limit > config.MaxLimit {
+		p.Limit = config.MaxLimit
+	}
+	return p
+}
+
+// pageURL 方法返回将当前请求的查询参数 key 替换为 value 后的URL
+func (c *Context) pageURL(values map[string]string) string {
+	query := c.Req.URL.Query()
+	for key, value := range values {
+		query.Set(key, value)
+	}
+	return c.Req.URL.Path + "?" + query.Encode()
+}
+
+// SetPaginationLinks 方法根据总条数 total 设置 Link 头部（first、prev、next、last）和 X-Total-Count 头部，
+// p.Limit 不大于 0 时按默认的每页条数计算
+func (c *Context) SetPaginationLinks(p Pagination, total int) {
+	p.Limit = pageLimit(p.Limit)
+	limit := strconv.Itoa(p.Limit)
+	last := (total + p.Limit - 1) / p.Limit
+	if last < 1 {
+		last = 1
+	}
+	link := func(page int, rel string) string {
+		return fmt.Sprintf(`<%s>; rel="%s"`, c.pageURL(map[string]string{"page": strconv.Itoa(page), "limit": limit}), rel)
+	}
+
+	links := []string{link(1, "first")}
+	if p.Page > 1 {
+		links = append(links, link(p.Page-1, "prev"))
+	}
+	if p.Page < last {
+		links = append(links, link(p.Page+1, "next"))
+	}
+	links = append(links, link(last, "last"))
+	c.SetHeader("Link", strings.Join(links, ", "))
+	c.SetHeader("X-Total-Count", strconv.Itoa(total))
+}
+
+// Paginated 方法设置分页头部，并以标准分页结构渲染页码分页的JSON响应
+func (c *Context) Paginated(code int, p Pagination, total int, data interface{}) {
+	p.Limit = pageLimit(p.Limit)
+	c.SetPaginationLinks(p, total)
+	c.JSON(code, PageResult{Data: data, Page: p.Page, Limit: p.Limit, Total: total})
+}
+
+// PaginatedCursor 方法以标准分页结构渲染游标分页的JSON响应，next 非空时设置指向下一页的 Link 头部
+func (c *Context) PaginatedCursor(code int, p Pagination, next string, data interface{}) {
+	if next != "" {
+		url := c.pageURL(map[string]string{"cursor": next, "limit": strconv.Itoa(p.Limit)})
+		c.SetHeader("Link", fmt.Sprintf(`<%s>; rel="next"`, url))
+	}
+	c.JSON(code, PageResult{Data: data, Limit: p.Limit, NextCursor: next})
+}
@@ -0,0 +1,38 @@
+package zinc
+
+// Param 一个动态路由参数（键值对）
+type Param struct {
+	Key   string
+	Value string
+}
+
+// Params 动态路由参数列表，按参数在路由中出现的顺序排列。
+// 列表随 Context 复用，请求结束后不能再持有。
+type Params []Param
+
+// Get 方法返回第一个键为 key 的参数值，不存在时 ok 为 false
+func (ps Params) Get(key string) (value string, ok bool) {
+	for _, p := range ps {
+		if p.Key == key {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
+
+// ByName 方法返回第一个键为 key 的参数值，不存在时返回空字符串
+func (ps Params) ByName(key string) string {
+	value, _ := ps.Get(key)
+	return value
+}
+
+// set 方法设置键为 key 的参数值，不存在时追加到列表末尾
+func (ps *Params) set(key string, value string) {
+	for i := range *ps {
+		if (*ps)[i].Key == key {
+			(*ps)[i].Value = value
+			return
+		}
+	}
+	*ps = append(*ps, Param{Key: key, Value: value})
+}
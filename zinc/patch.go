@@ -0,0 +1,106 @@
+package zinc
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// FieldSet 部分更新（PATCH）请求中出现的字段，键为结构体字段名。
+// 用于区分“没有提供该字段”和“将该字段设置为零值”。
+type FieldSet map[string]struct{}
+
+// Has 方法判断结构体字段 field 是否出现在请求中
+func (fs FieldSet) Has(field string) bool {
+	_, ok := fs[field]
+	return ok
+}
+
+// ShouldBindPatch 方法将部分更新的请求体绑定到 obj（结构体指针），并返回请求体中出现的字段。
+// 支持 application/json（按 json 标签匹配，与 encoding/json 一样不区分大小写）
+// 和 application/x-www-form-urlencoded（按 form 标签匹配）请求体。
+// 请求体超过 Engine.MaxBodyBytes 时返回 ErrRequestBodyTooLarge。
+func (c *Context) ShouldBindPatch(obj interface{}) (FieldSet, error) {
+	v, err := structValue(obj)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(FieldSet)
+	contentType := c.requestHeader("Content-Type")
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		data, err := c.bufferBody()
+		if err != nil {
+			return nil, err
+		}
+		form, err := url.ParseQuery(string(data))
+		if err != nil {
+			return nil, err
+		}
+		return fields, mapForm(obj, formValues(form), "form", c.queryPolicy(), fields)
+	}
+
+	if c.Req.Body == nil {
+		return nil, errors.New("request body is required")
+	}
+	data, err := c.bufferBody()
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, obj); err != nil {
+		return nil, err
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	t := v.Type()
+	for key := range raw {
+		for i := 0; i < t.NumField(); i++ {
+			if name, ok := fieldKey(t.Field(i), "json"); ok && strings.EqualFold(name, key) {
+				fields[t.Field(i).Name] = struct{}{}
+				break
+			}
+		}
+	}
+	return fields, nil
+}
+
+// BindPatch 方法与 ShouldBindPatch 相同，绑定失败时以 400 状态码中止请求
+func (c *Context) BindPatch(obj interface{}) (FieldSet, error) {
+	fields, err := c.ShouldBindPatch(obj)
+	if errors.Is(err, ErrRequestBodyTooLarge) {
+		c.Fail(http.StatusRequestEntityTooLarge, "Request Entity Too Large")
+	} else if err != nil {
+		c.Fail(http.StatusBadRequest, err.Error())
+	}
+	return fields, err
+}
+
+// ApplyPatch 将 patch 中出现在 fields 里的字段复制到 dst，dst 和 patch 必须是指向同一结构体类型的指针。
+//
+// 如：
+//
+//	var patch User
+//	fields, err := c.BindPatch(&patch)
+//	zinc.ApplyPatch(&user, &patch, fields)
+func ApplyPatch(dst interface{}, patch interface{}, fields FieldSet) error {
+	d, err := structValue(dst)
+	if err != nil {
+		return err
+	}
+	p, err := structValue(patch)
+	if err != nil {
+		return err
+	}
+	if d.Type() != p.Type() {
+		return errors.New("zinc: ApplyPatch requires dst and patch of the same type")
+	}
+	for name := range fields {
+		if field := p.FieldByName(name); field.IsValid() {
+			d.FieldByName(name).Set(field)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package zinc
+
+import (
+	"net/url"
+)
+
+// form 方法解析并返回请求体中的表单字段（application/x-www-form-urlencoded 或 multipart/form-data），
+// 不包含URL查询参数，避免查询字符串中的同名参数冒充表单字段
+func (c *Context) form() url.Values {
+	// ParseMultipartForm 只在第一次调用时解析，请求不是 multipart 时仍会解析普通表单；错误与 FormValue 一样被忽略。
+	// multipart 请求的普通字段同时保存在 MultipartForm.Value 和 PostForm 中
+	c.Req.ParseMultipartForm(defaultMultipartMemory)
+	return c.Req.PostForm
+}
+
+// GetPostForm 方法返回表单字段 key 的值，字段不存在时 ok 为 false，用于区分提交了空值和没有提交该字段
+func (c *Context) GetPostForm(key string) (value string, ok bool) {
+	values, ok := c.form()[key]
+	if !ok {
+		return "", false
+	}
+	return c.queryPolicy().pick(values), true
+}
+
+// DefaultPostForm 方法返回表单字段 key 的值，字段不存在时返回 def；字段存在但为空时返回空字符串
+func (c *Context) DefaultPostForm(key string, def string) string {
+	if value, ok := c.GetPostForm(key); ok {
+		return value
+	}
+	return def
+}
+
+// PostFormArray 方法返回重复出现的表单字段 key 的所有值（如多选框），字段不存在时返回空切片
+func (c *Context) PostFormArray(key string) []string {
+	values := c.form()[key]
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// PostFormMap 方法返回形如 prefix[key]=value 的表单字段组成的映射，见 QueryMap
+func (c *Context) PostFormMap(prefix string) map[string]string {
+	return c.valuesMap(c.form(), prefix)
+}
@@ -0,0 +1,55 @@
+package zinc
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ProblemDetails RFC 7807 定义的机器可读的错误详情（application/problem+json）
+type ProblemDetails struct {
+	Type     string // 错误类型的 URI，为空时为 about:blank
+	Title    string // 错误类型的简短描述，为空时为状态码的默认描述
+	Status   int    // HTTP 状态码，由 c.Problem 填写
+	Detail   string // 本次错误的具体描述
+	Instance string // 本次错误的 URI，如请求路径
+	// Extensions 扩展字段，与标准字段一起输出在顶层，如 {"errors": [...]}
+	Extensions map[string]interface{}
+}
+
+// MarshalJSON 方法将标准字段和扩展字段编码为同一个 JSON 对象，空的可选字段不输出
+func (p ProblemDetails) MarshalJSON() ([]byte, error) {
+	fields := make(map[string]interface{}, len(p.Extensions)+5)
+	for key, value := range p.Extensions {
+		fields[key] = value
+	}
+	fields["type"] = p.Type
+	fields["title"] = p.Title
+	fields["status"] = p.Status
+	if p.Detail != "" {
+		fields["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		fields["instance"] = p.Instance
+	}
+	return json.Marshal(fields)
+}
+
+// Problem 方法以 application/problem+json 格式返回错误详情 p，p.Status 被设置为 code，
+// Type 和 Title 为空时使用 about:blank 和状态码的默认描述
+func (c *Context) Problem(code int, p ProblemDetails) {
+	p.Status = code
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(code)
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		http.Error(c.Writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	c.SetHeader("Content-Type", c.contentType("application/problem+json"))
+	c.Status(code)
+	c.Writer.Write(data)
+}
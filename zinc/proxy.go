@@ -0,0 +1,385 @@
+package zinc
+
+import (
+	"container/list"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CachedResponse 缓存的上游响应
+type CachedResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+	// Stored 响应被缓存（或最近一次验证）的时间
+	Stored time.Time
+	// MaxAge 响应的新鲜期，来自上游的 s-maxage 或 max-age，no-cache 时为 0
+	MaxAge time.Duration
+	// StaleWhileRevalidate 新鲜期过后仍可直接返回、同时在后台重新验证的时长
+	StaleWhileRevalidate time.Duration
+}
+
+// age 方法返回响应已缓存的时长
+func (resp *CachedResponse) age() time.Duration {
+	return time.Since(resp.Stored)
+}
+
+// ResponseStore 缓存上游响应的存储，可以用 Redis 等实现该接口
+type ResponseStore interface {
+	Get(key string) (*CachedResponse, bool)
+	Set(key string, resp *CachedResponse)
+}
+
+// defaultMaxResponses MemoryResponseStore.MaxEntries 为 0 时缓存的响应数量上限
+const defaultMaxResponses = 1000
+
+// MemoryResponseStore 保存在内存中的 ResponseStore，响应数量超过 MaxEntries 时丢弃最久没有使用的响应
+type MemoryResponseStore struct {
+	// MaxEntries 缓存的响应数量上限，为 0 时为 1000
+	MaxEntries int
+
+	mu        sync.Mutex
+	responses map[string]*list.Element // 值为 *memoryResponse
+	order     *list.List               // 按使用时间排列，最近使用的在前
+}
+
+// memoryResponse MemoryResponseStore 中的一个响应
+type memoryResponse struct {
+	key  string
+	resp *CachedResponse
+}
+
+// NewMemoryResponseStore 是 zinc.MemoryResponseStore 的构造函数
+func NewMemoryResponseStore() *MemoryResponseStore {
+	return &MemoryResponseStore{responses: make(map[string]*list.Element), order: list.New()}
+}
+
+// Get 方法返回缓存的响应
+func (s *MemoryResponseStore) Get(key string) (*CachedResponse, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.responses[key]
+	if !ok {
+		return nil, false
+	}
+	s.order.MoveToFront(e)
+	return e.Value.(*memoryResponse).resp, true
+}
+
+// Set 方法缓存响应
+func (s *MemoryResponseStore) Set(key string, resp *CachedResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.responses[key]; ok {
+		e.Value.(*memoryResponse).resp = resp
+		s.order.MoveToFront(e)
+		return
+	}
+	s.responses[key] = s.order.PushFront(&memoryResponse{key: key, resp: resp})
+	max := s.MaxEntries
+	if max <= 0 {
+		max = defaultMaxResponses
+	}
+	for s.order.Len() > max {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.responses, oldest.Value.(*memoryResponse).key)
+	}
+}
+
+// CachingProxyConfig 缓存代理的配置
+type CachingProxyConfig struct {
+	// Upstream 上游地址，如 http://api.internal:8080，请求路径和查询参数保持不变
+	Upstream string
+	// Client 请求上游使用的客户端，为空时使用超时时间为 30 秒的客户端
+	Client *http.Client
+	// Store 缓存响应的存储，为空时使用 MemoryResponseStore
+	Store ResponseStore
+	// StaleWhileRevalidate 为 true 时遵循上游的 stale-while-revalidate 指令：
+	// 过期不久的响应直接返回，同时在后台重新验证
+	StaleWhileRevalidate bool
+	// MaxBodyBytes 缓存的响应体大小上限，为 0 时为 1MB；超过上限的响应照常返回但不缓存
+	MaxBodyBytes int64
+}
+
+// hopHeaders 是不在代理和客户端之间转发的逐跳头部
+var hopHeaders = []string{"Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization", "Te", "Trailer", "Transfer-Encoding", "Upgrade"}
+
+// CachingProxy 是缓存代理处理函数的构造函数，将 GET、HEAD 请求转发给上游并按上游的
+// Cache-Control 缓存响应：新鲜的响应直接返回，过期的响应带上 If-None-Match、If-Modified-Since 向上游验证，
+// 上游返回 304 时继续使用缓存。带有 Vary 的响应按 Vary 中列出的请求头部分别缓存。
+// 其他方法的请求、带有 Authorization 或 Cookie 的请求直接转发，不使用缓存；带有 Set-Cookie 的响应不缓存。
+// 响应头部 X-Cache 表示缓存的使用情况：HIT、MISS、REVALIDATED 或 STALE。
+//
+// 如：engine.GET("/api/*path", zinc.CachingProxy(zinc.CachingProxyConfig{Upstream: "http://api.internal"}))
+func CachingProxy(config CachingProxyConfig) HandlerFunc {
+	if config.Client == nil {
+		config.Client = &http.Client{Timeout: 30 * time.Second}
+	}
+	if config.Store == nil {
+		config.Store = NewMemoryResponseStore()
+	}
+	if config.MaxBodyBytes == 0 {
+		config.MaxBodyBytes = 1 << 20
+	}
+	p := &cachingProxy{config: config}
+	return p.handle
+}
+
+// revalidateTimeout 后台重新验证的超时时间，避免上游没有响应时验证一直占用 goroutine
+const revalidateTimeout = 30 * time.Second
+
+// cachingProxy 缓存代理
+type cachingProxy struct {
+	config     CachingProxyConfig
+	revalidate sync.Map // 正在后台重新验证的缓存键，避免同一响应并发验证
+}
+
+// handle 方法处理一次代理请求
+func (p *cachingProxy) handle(c *Context) {
+	// 带有凭据的请求的响应可能因用户而异，不读取也不写入共享的缓存
+	if c.Method != http.MethodGet && c.Method != http.MethodHead || hasCredentials(c.Req) {
+		resp, _, err := p.fetch(c.Req.Context(), "", c.Req, nil)
+		if err != nil {
+			p.fail(c, err)
+			return
+		}
+		p.write(c, resp, "MISS")
+		return
+	}
+
+	key := c.Req.URL.RequestURI()
+	cached, ok := p.lookup(key, c.Req)
+	switch {
+	case ok && cached.age() < cached.MaxAge:
+		p.write(c, cached, "HIT")
+		return
+	case ok && p.config.StaleWhileRevalidate && cached.age() < cached.MaxAge+cached.StaleWhileRevalidate:
+		if _, running := p.revalidate.LoadOrStore(key, true); !running {
+			// 后台验证不随当前请求结束而取消
+			req := c.Req.Clone(context.Background())
+			go func() {
+				defer p.revalidate.Delete(key)
+				ctx, cancel := context.WithTimeout(context.Background(), revalidateTimeout)
+				defer cancel()
+				if _, _, err := p.fetch(ctx, key, req, cached); err != nil {
+					log.Printf("caching proxy: %v", err)
+				}
+			}()
+		}
+		p.write(c, cached, "STALE")
+		return
+	}
+
+	resp, revalidated, err := p.fetch(c.Req.Context(), key, c.Req, cached)
+	if err != nil {
+		p.fail(c, err)
+		return
+	}
+	status := "MISS"
+	if revalidated {
+		status = "REVALIDATED"
+	}
+	p.write(c, resp, status)
+}
+
+// fail 方法以 502 状态码结束请求。错误中带有上游地址，只通过 c.Error 记录，响应中使用通用的错误信息
+func (p *cachingProxy) fail(c *Context, err error) {
+	c.abortWithError(c.Error(NewHTTPError(http.StatusBadGateway, http.StatusText(http.StatusBadGateway), err)))
+}
+
+// lookup 方法返回请求对应的缓存响应，响应带有 Vary 时按请求头部查找对应的版本
+func (p *cachingProxy) lookup(key string, r *http.Request) (*CachedResponse, bool) {
+	cached, ok := p.config.Store.Get(key)
+	if !ok {
+		return nil, false
+	}
+	if vary := varyOf(cached.Header); vary != "" {
+		return p.config.Store.Get(varyKey(key, vary, r.Header))
+	}
+	return cached, true
+}
+
+// store 方法以 key 缓存响应，响应带有 Vary 时同时以加上请求头部的键缓存，lookup 据此查找对应的版本
+func (p *cachingProxy) store(key string, r *http.Request, resp *CachedResponse) {
+	vary := varyOf(resp.Header)
+	if vary == "*" {
+		return
+	}
+	p.config.Store.Set(key, resp)
+	if vary != "" {
+		p.config.Store.Set(varyKey(key, vary, r.Header), resp)
+	}
+}
+
+// varyOf 返回响应的所有 Vary 头部
+func varyOf(header http.Header) string {
+	return strings.TrimSpace(strings.Join(header.Values("Vary"), ","))
+}
+
+// varyKey 返回加上 Vary 中列出的请求头部的值的缓存键
+func varyKey(key string, vary string, header http.Header) string {
+	var b strings.Builder
+	b.WriteString(key)
+	for _, name := range strings.Split(vary, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			b.WriteString("\n" + http.CanonicalHeaderKey(name) + ": " + strings.Join(header.Values(name), ","))
+		}
+	}
+	return b.String()
+}
+
+// hasCredentials 判断请求是否带有 Authorization 或 Cookie
+func hasCredentials(r *http.Request) bool {
+	return r.Header.Get("Authorization") != "" || r.Header.Get("Cookie") != ""
+}
+
+// fetch 方法将请求转发给上游，cached 非空时发送条件请求；可缓存的响应以 key 写入 Store，key 为空时不缓存。
+// 上游返回 304 时刷新 cached 的缓存时间并返回，revalidated 为 true。
+func (p *cachingProxy) fetch(ctx context.Context, key string, r *http.Request, cached *CachedResponse) (resp *CachedResponse, revalidated bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, r.Method, strings.TrimSuffix(p.config.Upstream, "/")+r.URL.RequestURI(), r.Body)
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header = r.Header.Clone()
+	for _, key := range hopHeaders {
+		req.Header.Del(key)
+	}
+	cacheable := r.Method == http.MethodGet && key != ""
+	if cacheable {
+		// 客户端自己的条件请求头部由代理处理，不转发给上游
+		req.Header.Del("If-None-Match")
+		req.Header.Del("If-Modified-Since")
+		if cached != nil {
+			if etag := cached.Header.Get("ETag"); etag != "" {
+				req.Header.Set("If-None-Match", etag)
+			}
+			if lastModified := cached.Header.Get("Last-Modified"); lastModified != "" {
+				req.Header.Set("If-Modified-Since", lastModified)
+			}
+		}
+	}
+
+	upstream, err := p.config.Client.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer upstream.Body.Close()
+
+	if cached != nil && upstream.StatusCode == http.StatusNotModified {
+		refreshed := *cached
+		refreshed.Header = cached.Header.Clone()
+		for _, key := range []string{"Cache-Control", "Date", "Expires", "ETag"} {
+			if value := upstream.Header.Get(key); value != "" {
+				refreshed.Header.Set(key, value)
+			}
+		}
+		refreshed.Stored = time.Now()
+		refreshed.MaxAge, refreshed.StaleWhileRevalidate, _ = cacheLifetime(refreshed.Header)
+		p.store(key, r, &refreshed)
+		return &refreshed, true, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(upstream.Body, p.config.MaxBodyBytes+1))
+	if err != nil {
+		return nil, false, err
+	}
+	result := &CachedResponse{Status: upstream.StatusCode, Header: upstream.Header, Body: body, Stored: time.Now()}
+	if int64(len(body)) > p.config.MaxBodyBytes {
+		// 超过上限的响应体不缓存，剩余部分继续读取后一起返回
+		rest, err := io.ReadAll(upstream.Body)
+		if err != nil {
+			return nil, false, err
+		}
+		result.Body = append(body, rest...)
+		return result, false, nil
+	}
+	maxAge, swr, storable := cacheLifetime(upstream.Header)
+	if cacheable && storable && upstream.StatusCode == http.StatusOK {
+		result.MaxAge, result.StaleWhileRevalidate = maxAge, swr
+		p.store(key, r, result)
+	}
+	return result, false, nil
+}
+
+// write 方法将响应写给客户端，客户端的 If-None-Match 与响应的 ETag 匹配时只返回 304
+func (p *cachingProxy) write(c *Context, resp *CachedResponse, status string) {
+	header := c.Writer.Header()
+	for key, values := range resp.Header {
+		header[key] = append([]string(nil), values...)
+	}
+	for _, key := range hopHeaders {
+		header.Del(key)
+	}
+	header.Del("Content-Length")
+	header.Set("X-Cache", status)
+	if status != "MISS" {
+		header.Set("Age", strconv.Itoa(int(resp.age().Seconds())))
+	}
+	if etag := resp.Header.Get("ETag"); etag != "" && resp.Status == http.StatusOK && etagMatch(c.requestHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Status(resp.Status)
+	if c.Method != http.MethodHead {
+		c.Writer.Write(resp.Body)
+	}
+}
+
+// cacheLifetime 根据 Cache-Control 计算响应的新鲜期和 stale-while-revalidate 时长。
+// no-store、private 和带有 Set-Cookie 的响应不可缓存；no-cache 或带 ETag、Last-Modified 但没有 max-age 的响应可缓存但每次都需要验证。
+func cacheLifetime(header http.Header) (maxAge time.Duration, swr time.Duration, ok bool) {
+	// Set-Cookie 是发给单个客户端的，缓存后会被其他客户端拿到
+	if len(header.Values("Set-Cookie")) > 0 {
+		return 0, 0, false
+	}
+	directives := parseCacheControl(header.Get("Cache-Control"))
+	if _, noStore := directives["no-store"]; noStore {
+		return 0, 0, false
+	}
+	if _, private := directives["private"]; private {
+		return 0, 0, false
+	}
+	seconds := func(name string) (time.Duration, bool) {
+		n, err := strconv.Atoi(directives[name])
+		if err != nil || n < 0 {
+			return 0, false
+		}
+		return time.Duration(n) * time.Second, true
+	}
+	swr, _ = seconds("stale-while-revalidate")
+	if _, noCache := directives["no-cache"]; noCache {
+		return 0, 0, true
+	}
+	if d, ok := seconds("s-maxage"); ok {
+		return d, swr, true
+	}
+	if d, ok := seconds("max-age"); ok {
+		return d, swr, true
+	}
+	validatable := header.Get("ETag") != "" || header.Get("Last-Modified") != ""
+	return 0, 0, validatable
+}
+
+// parseCacheControl 解析 Cache-Control 头部，返回指令名（小写）到值的映射
+func parseCacheControl(value string) map[string]string {
+	directives := make(map[string]string)
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, arg := part, ""
+		if i := strings.IndexByte(part, '='); i >= 0 {
+			name, arg = part[:i], strings.Trim(part[i+1:], `"`)
+		}
+		directives[strings.ToLower(name)] = arg
+	}
+	return directives
+}
@@ -0,0 +1,50 @@
+package zinc
+
+import (
+	"net/url"
+	"strings"
+)
+
+// GetQuery 方法返回查询参数 key 的值，参数不存在时 ok 为 false，用于区分 ?key= 和没有该参数
+func (c *Context) GetQuery(key string) (value string, ok bool) {
+	values, ok := c.Req.URL.Query()[key]
+	if !ok {
+		return "", false
+	}
+	return c.queryPolicy().pick(values), true
+}
+
+// DefaultQuery 方法返回查询参数 key 的值，参数不存在时返回 def；参数存在但为空（?key=）时返回空字符串
+func (c *Context) DefaultQuery(key string, def string) string {
+	if value, ok := c.GetQuery(key); ok {
+		return value
+	}
+	return def
+}
+
+// QueryArray 方法返回重复出现的查询参数 key 的所有值，如 ?tag=a&tag=b 返回 [a b]，参数不存在时返回空切片。
+// Engine.QueryDuplicates 为 RejectDuplicates 时带有重复参数的请求在路由之前已被拒绝。
+func (c *Context) QueryArray(key string) []string {
+	values := c.Req.URL.Query()[key]
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// QueryMap 方法返回形如 prefix[key]=value 的查询参数组成的映射，
+// 如 ?filter[status]=open&filter[owner]=me 的 c.QueryMap("filter") 返回 {status: open, owner: me}
+func (c *Context) QueryMap(prefix string) map[string]string {
+	return c.valuesMap(c.Req.URL.Query(), prefix)
+}
+
+// valuesMap 方法收集 values 中形如 prefix[key] 的键，重复的值按 Engine.QueryDuplicates 取值
+func (c *Context) valuesMap(values url.Values, prefix string) map[string]string {
+	m := make(map[string]string)
+	for name, vals := range values {
+		if len(name) > len(prefix)+2 && strings.HasPrefix(name, prefix) && name[len(prefix)] == '[' && name[len(name)-1] == ']' {
+			m[name[len(prefix)+1:len(name)-1]] = c.queryPolicy().pick(vals)
+		}
+	}
+	return m
+}
@@ -0,0 +1,81 @@
+package zinc
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// SortField 排序字段，Desc 为true表示降序
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// QueryDSL 从查询字符串解析出的排序和过滤条件，
+// 如 ?sort=-created_at,name&filter[status]=active 解析为
+// Sort: [{created_at true} {name false}]，Filter: {status: active}
+type QueryDSL struct {
+	Sort   []SortField
+	Filter map[string]string
+}
+
+// QueryDSLConfig 排序和过滤字段的白名单
+type QueryDSLConfig struct {
+	SortFields   []string // 允许排序的字段
+	FilterFields []string // 允许过滤的字段
+}
+
+// contains 判断 list 中是否包含 item
+func contains(list []string, item string) bool {
+	for _, v := range list {
+		if v == item {
+			return true
+		}
+	}
+	return false
+}
+
+// ShouldBindQueryDSL 方法按白名单 config 解析查询字符串中的 sort 和 filter[...] 参数，
+// 出现不在白名单中的字段时返回错误。
+func (c *Context) ShouldBindQueryDSL(config QueryDSLConfig) (QueryDSL, error) {
+	dsl := QueryDSL{Filter: make(map[string]string)}
+	query := c.Req.URL.Query()
+
+	for _, field := range strings.Split(c.queryValue(query, "sort"), ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		sortField := SortField{Field: field}
+		// `-`前缀表示降序，`+`前缀表示升序
+		if field[0] == '-' || field[0] == '+' {
+			sortField = SortField{Field: field[1:], Desc: field[0] == '-'}
+		}
+		if !contains(config.SortFields, sortField.Field) {
+			return dsl, fmt.Errorf("unknown sort field %q", sortField.Field)
+		}
+		dsl.Sort = append(dsl.Sort, sortField)
+	}
+
+	for key, values := range query {
+		if !strings.HasPrefix(key, "filter[") || !strings.HasSuffix(key, "]") {
+			continue
+		}
+		field := key[len("filter[") : len(key)-1]
+		if !contains(config.FilterFields, field) {
+			return dsl, fmt.Errorf("unknown filter field %q", field)
+		}
+		dsl.Filter[field] = c.queryPolicy().pick(values)
+	}
+	return dsl, nil
+}
+
+// BindQueryDSL 方法与 ShouldBindQueryDSL 相同，解析失败时以 400 状态码中止请求
+func (c *Context) BindQueryDSL(config QueryDSLConfig) (QueryDSL, error) {
+	dsl, err := c.ShouldBindQueryDSL(config)
+	if err != nil {
+		c.Fail(http.StatusBadRequest, err.Error())
+	}
+	return dsl, err
+}
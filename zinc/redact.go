@@ -0,0 +1,160 @@
+package zinc
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Redactor 脱敏组件，在日志或上报输出之前屏蔽敏感的头部、查询参数和 JSON 字段。
+type Redactor struct {
+	Headers  []string         // 需要屏蔽的头部名，不区分大小写，如 Authorization、Cookie
+	Fields   []string         // 需要屏蔽的字段，可以是字段名（password）或点分路径（user.token）
+	Patterns []*regexp.Regexp // 匹配字段点分路径的正则，匹配成功即屏蔽
+	Mask     string           // 替换敏感值的掩码
+}
+
+// DefaultRedactor 返回屏蔽常见敏感信息的 Redactor
+func DefaultRedactor() *Redactor {
+	return &Redactor{
+		Headers: []string{"Authorization", "Cookie", "Set-Cookie", "Proxy-Authorization"},
+		Fields:  []string{"password", "token", "access_token", "secret"},
+		Mask:    "******",
+	}
+}
+
+// matchHeader 方法判断头部 key 是否需要屏蔽
+func (r *Redactor) matchHeader(key string) bool {
+	for _, h := range r.Headers {
+		if strings.EqualFold(h, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchField 方法判断字段是否需要屏蔽，name 为字段名，path 为从根开始的点分路径
+func (r *Redactor) matchField(name string, path string) bool {
+	for _, f := range r.Fields {
+		if strings.EqualFold(f, name) || strings.EqualFold(f, path) {
+			return true
+		}
+	}
+	for _, re := range r.Patterns {
+		if re.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactHeader 方法返回屏蔽敏感值后的头部副本，不修改原对象
+func (r *Redactor) RedactHeader(header http.Header) http.Header {
+	out := make(http.Header, len(header))
+	for key, values := range header {
+		if r.matchHeader(key) {
+			out[key] = []string{r.Mask}
+			continue
+		}
+		out[key] = append([]string(nil), values...)
+	}
+	return out
+}
+
+// RedactURI 方法屏蔽 uri 查询字符串中的敏感参数，如 /login?token=xxx；参数的顺序和其他参数的编码保持不变
+func (r *Redactor) RedactURI(uri string) string {
+	i := strings.IndexByte(uri, '?')
+	if i < 0 {
+		return uri
+	}
+	pairs := strings.Split(uri[i+1:], "&")
+	changed := false
+	for index, pair := range pairs {
+		rawKey, _, _ := strings.Cut(pair, "=")
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil || !r.matchField(key, key) {
+			continue
+		}
+		pairs[index] = rawKey + "=" + url.QueryEscape(r.Mask)
+		changed = true
+	}
+	if !changed {
+		return uri
+	}
+	return uri[:i+1] + strings.Join(pairs, "&")
+}
+
+// unredactableBody 无法解析而不能安全屏蔽的请求体的替代文本
+const unredactableBody = "[unredactable body]"
+
+// RedactJSON 方法屏蔽 JSON 文本中的敏感字段；body 不是一个完整的 JSON 值时（如被截断、NDJSON）
+// 无法判断哪些内容是敏感的，返回 [unredactable body] 而不是原文
+func (r *Redactor) RedactJSON(body []byte) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	// 保持数字原样，避免大整数精度丢失
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return []byte(unredactableBody)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return []byte(unredactableBody)
+	}
+	out, err := json.Marshal(r.redactValue(v, ""))
+	if err != nil {
+		return []byte(unredactableBody)
+	}
+	return out
+}
+
+// redactRequest 方法返回屏蔽了敏感查询参数、头部和 JSON 字段的请求副本以及已读入内存的请求体，
+// 供 Recovery 的日志和 PanicReporter 使用；副本的请求体为空
+func (r *Redactor) redactRequest(req *http.Request, body []byte) (*http.Request, []byte) {
+	redacted := req.Clone(req.Context())
+	redacted.RequestURI = r.RedactURI(req.RequestURI)
+	if req.URL.RawQuery != "" {
+		redacted.URL.RawQuery = strings.TrimPrefix(r.RedactURI("?"+req.URL.RawQuery), "?")
+	}
+	redacted.Header = r.RedactHeader(req.Header)
+	redacted.Body = http.NoBody
+	if len(body) > 0 {
+		body = r.RedactJSON(body)
+	}
+	return redacted, body
+}
+
+// redactor 方法返回 Engine.Redactor，没有设置时返回默认的 Redactor
+func (engine *Engine) redactor() *Redactor {
+	if engine != nil && engine.Redactor != nil {
+		return engine.Redactor
+	}
+	return DefaultRedactor()
+}
+
+// redactValue 方法递归遍历 JSON 值，屏蔽命中的字段
+func (r *Redactor) redactValue(v interface{}, path string) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for key, child := range val {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			if r.matchField(key, childPath) {
+				val[key] = r.Mask
+				continue
+			}
+			val[key] = r.redactValue(child, childPath)
+		}
+	case []interface{}:
+		// 数组元素沿用数组所在的路径
+		for i, child := range val {
+			val[i] = r.redactValue(child, path)
+		}
+	}
+	return v
+}
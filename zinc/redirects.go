@@ -0,0 +1,75 @@
+package zinc
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Redirects 方法按重定向表注册重定向路由，键为旧地址的路由模式，值为新地址，可以带有状态码前缀（默认为 301）：
+//
+//	engine.Redirects(map[string]string{
+//		"/old/:id":        "/new/:id",
+//		"/blog/*path":     "302 https://blog.example.com/*path",
+//		"/promo":          "307 /campaigns/spring",
+//	})
+//
+// 旧地址中的参数按名称替换到新地址中，请求的查询字符串保留到新地址上。
+// 重定向作为普通的 GET 和 HEAD 路由编译进路由树，状态码不是 3xx 或新地址引用了旧地址中不存在的参数时 panic。
+func (engine *Engine) Redirects(table map[string]string) {
+	for pattern, target := range table {
+		code, target := parseRedirect(pattern, target)
+		names := patternParams(pattern)
+		for _, name := range patternParams(target) {
+			if !contains(names, name) {
+				panic(fmt.Sprintf("zinc: redirect target %s uses parameter %q not defined in %s", target, name, pattern))
+			}
+		}
+		handler := redirectHandler(code, target)
+		engine.GET(pattern, handler)
+		engine.Handle(http.MethodHead, pattern, handler)
+	}
+}
+
+// parseRedirect 解析重定向表中 pattern 对应的值，返回状态码（默认为 301）和新地址，状态码不是 3xx 时 panic
+func parseRedirect(pattern string, target string) (int, string) {
+	code := http.StatusMovedPermanently
+	if i := strings.IndexByte(target, ' '); i >= 0 {
+		n, err := strconv.Atoi(target[:i])
+		if err != nil || n < 300 || n > 399 {
+			panic(fmt.Sprintf("zinc: invalid redirect status %q for %s", target[:i], pattern))
+		}
+		code, target = n, strings.TrimSpace(target[i+1:])
+	}
+	return code, target
+}
+
+// redirectHandler 返回重定向到 target 的处理函数，target 中的参数替换为请求的路由参数
+func redirectHandler(code int, target string) HandlerFunc {
+	// 绝对地址只替换路径部分的参数
+	origin, targetPath := "", target
+	if i := strings.Index(target, "://"); i >= 0 {
+		if j := strings.IndexByte(target[i+3:], '/'); j >= 0 {
+			origin, targetPath = target[:i+3+j], target[i+3+j:]
+		} else {
+			origin, targetPath = target, ""
+		}
+	}
+	dynamic := strings.ContainsAny(targetPath, ":*")
+	return func(c *Context) {
+		location := origin + targetPath
+		if dynamic {
+			params := make(map[string]string, len(c.Params))
+			for _, p := range c.Params {
+				params[p.Key] = p.Value
+			}
+			location = origin + expandPattern(targetPath, params)
+		}
+		if c.Req.URL.RawQuery != "" {
+			location += "?" + c.Req.URL.RawQuery
+		}
+		c.SetHeader("Location", location)
+		c.Status(code)
+	}
+}
@@ -0,0 +1,131 @@
+package zinc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"unicode/utf16"
+
+	"github.com/BurntSushi/toml"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v3"
+)
+
+// YAML 方法快速构造YAML响应报文，用于配置导出、诊断快照等供人阅读的接口。
+// 先完整编码再写出，编码失败时返回 500 而不是半截的响应；不使用分组的响应信封。
+func (c *Context) YAML(code int, obj interface{}) {
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		http.Error(c.Writer, err.Error(), 500)
+		return
+	}
+	c.SetHeader("Content-Type", c.contentType("application/yaml"))
+	c.Status(code)
+	c.Writer.Write(data)
+}
+
+// TOML 方法快速构造TOML响应报文，obj 通常为结构体或映射（TOML 文档的顶层是表），见 YAML
+func (c *Context) TOML(code int, obj interface{}) {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(obj); err != nil {
+		http.Error(c.Writer, err.Error(), 500)
+		return
+	}
+	c.SetHeader("Content-Type", c.contentType("application/toml"))
+	c.Status(code)
+	c.Writer.Write(buf.Bytes())
+}
+
+// ProtoBuf 方法快速构造 Protocol Buffers 响应报文（application/x-protobuf），用于内部服务之间交换紧凑的数据
+func (c *Context) ProtoBuf(code int, msg proto.Message) {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		http.Error(c.Writer, err.Error(), 500)
+		return
+	}
+	c.SetHeader("Content-Type", "application/x-protobuf")
+	c.Status(code)
+	c.Writer.Write(data)
+}
+
+// defaultSecureJSONPrefix Engine.SecureJSONPrefix 的默认值
+const defaultSecureJSONPrefix = "while(1);"
+
+// IndentedJSON 方法快速构造缩进格式的JSON响应报文，便于在调试接口中阅读；会比 JSON 更大，不应用于普通接口
+func (c *Context) IndentedJSON(code int, obj interface{}) {
+	c.renderJSON(code, obj, func(obj interface{}) ([]byte, error) {
+		return json.MarshalIndent(obj, "", "    ")
+	})
+}
+
+// PureJSON 方法快速构造不转义 HTML 字符的JSON响应报文，<、>、& 按原样输出而不是 \u003c 等
+func (c *Context) PureJSON(code int, obj interface{}) {
+	c.renderJSON(code, obj, func(obj interface{}) ([]byte, error) {
+		buf := c.scratchBuffer()
+		if buf == nil {
+			buf = new(bytes.Buffer)
+		}
+		encoder := json.NewEncoder(buf)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(obj); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	})
+}
+
+// AsciiJSON 方法快速构造只包含 ASCII 字符的JSON响应报文，非 ASCII 字符转义为 \uXXXX，用于无法正确处理 UTF-8 的客户端
+func (c *Context) AsciiJSON(code int, obj interface{}) {
+	c.renderJSON(code, obj, func(obj interface{}) ([]byte, error) {
+		data, err := json.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		for _, r := range string(data) {
+			switch {
+			case r < 0x80:
+				buf.WriteRune(r)
+			case r > 0xffff:
+				// 基本多文种平面之外的字符按 UTF-16 代理对转义
+				r1, r2 := utf16.EncodeRune(r)
+				fmt.Fprintf(&buf, "\\u%04x\\u%04x", r1, r2)
+			default:
+				fmt.Fprintf(&buf, "\\u%04x", r)
+			}
+		}
+		return buf.Bytes(), nil
+	})
+}
+
+// SecureJSON 方法快速构造防止 JSON 劫持的JSON响应报文：顶层为数组时在前面加上 Engine.SecureJSONPrefix（默认为 while(1);），
+// 使旧浏览器无法通过 <script> 标签读取数据；客户端需要去掉前缀后再解析
+func (c *Context) SecureJSON(code int, obj interface{}) {
+	c.renderJSON(code, obj, func(obj interface{}) ([]byte, error) {
+		data, err := json.Marshal(obj)
+		if err != nil || !bytes.HasPrefix(data, []byte("[")) {
+			return data, err
+		}
+		prefix := defaultSecureJSONPrefix
+		if c.engine != nil && c.engine.SecureJSONPrefix != "" {
+			prefix = c.engine.SecureJSONPrefix
+		}
+		return append([]byte(prefix), data...), nil
+	})
+}
+
+// renderJSON 方法用 marshal 编码 obj（分组设置了响应信封时先包装），编码成功后写出JSON响应报文
+func (c *Context) renderJSON(code int, obj interface{}, marshal func(obj interface{}) ([]byte, error)) {
+	if c.envelope != nil {
+		obj = c.envelope(code, obj, nil)
+	}
+	data, err := marshal(obj)
+	if err != nil {
+		http.Error(c.Writer, err.Error(), 500)
+		return
+	}
+	c.SetHeader("Content-Type", c.contentType("application/json"))
+	c.Status(code)
+	c.Writer.Write(data)
+}
@@ -0,0 +1,255 @@
+package zinc
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// bufferedWriter 缓冲响应的 http.ResponseWriter。
+// 状态码和响应体先写入内存，由 flush 方法统一发送，发送前可以修改响应头部和响应体。
+type bufferedWriter struct {
+	http.ResponseWriter
+	status int          // 缓冲的状态码
+	body   bytes.Buffer // 缓冲的响应体
+	header http.Header  // 非空时使用独立的头部，flush 时再复制到底层的 http.ResponseWriter
+}
+
+// newBufferedWriter 是 zinc.bufferedWriter 的构造函数
+func newBufferedWriter(w http.ResponseWriter) *bufferedWriter {
+	return &bufferedWriter{ResponseWriter: w}
+}
+
+// Header 方法返回响应头部
+func (w *bufferedWriter) Header() http.Header {
+	if w.header != nil {
+		return w.header
+	}
+	return w.ResponseWriter.Header()
+}
+
+// WriteHeader 方法只记录状态码，不发送
+func (w *bufferedWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+// Write 方法将数据写入缓冲区
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(data)
+}
+
+// flush 方法将 body 作为最终的响应体，连同状态码一起发送给底层的 http.ResponseWriter
+func (w *bufferedWriter) flush(body []byte) {
+	// 没有任何写入时不发送，保持底层 http.ResponseWriter 的默认行为
+	if w.status == 0 {
+		return
+	}
+	for key, values := range w.header {
+		w.ResponseWriter.Header()[key] = values
+	}
+	if w.ResponseWriter.Header().Get("Content-Length") != "" {
+		w.ResponseWriter.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.Write(body)
+}
+
+// ResponseTransformer 响应改写函数，在响应发送之前修改响应体（头部通过 c.Writer.Header() 修改），返回新的响应体
+type ResponseTransformer func(c *Context, body []byte) []byte
+
+// TransformResponse 是响应改写中间件的构造函数。
+// 中间件缓冲后续处理函数的输出，Content-Type 匹配 contentTypes 中任一前缀时（为空则全部匹配）
+// 调用 fn 改写响应，如压缩 HTML、在调试模式下注入工具栏等。
+//
+// 如：g.Use(zinc.TransformResponse(minifyHTML, "text/html"))
+func TransformResponse(fn ResponseTransformer, contentTypes ...string) HandlerFunc {
+	return func(c *Context) {
+		origin := c.Writer
+		buffer := newBufferedWriter(origin)
+		c.Writer = buffer
+		// 发生 panic 时恢复原始 Writer，使 Recovery 能直接向客户端输出错误
+		defer func() {
+			c.Writer = origin
+		}()
+		c.Next()
+
+		body := buffer.body.Bytes()
+		if matchContentType(origin.Header().Get("Content-Type"), contentTypes) {
+			body = fn(c, body)
+		}
+		buffer.flush(body)
+	}
+}
+
+// matchContentType 判断 contentType 是否以 contentTypes 中的任一项为前缀，contentTypes 为空时返回 true
+func matchContentType(contentType string, contentTypes []string) bool {
+	if len(contentTypes) == 0 {
+		return true
+	}
+	for _, t := range contentTypes {
+		if strings.HasPrefix(contentType, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// headWriter 丢弃响应体的 http.ResponseWriter，用于将 HEAD 请求交给 GET 路由处理。
+// 状态码延迟到 finish 时发送，以便根据丢弃的字节数设置 Content-Length。
+type headWriter struct {
+	http.ResponseWriter
+	status int // 记录的状态码
+	size   int // 丢弃的响应体字节数
+}
+
+// WriteHeader 方法只记录状态码，不发送
+func (w *headWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+// Write 方法丢弃数据，只记录字节数
+func (w *headWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.size += len(data)
+	return len(data), nil
+}
+
+// finish 方法设置 Content-Length 并发送状态码
+func (w *headWriter) finish() {
+	if w.status == 0 {
+		return
+	}
+	if w.ResponseWriter.Header().Get("Content-Length") == "" {
+		w.ResponseWriter.Header().Set("Content-Length", strconv.Itoa(w.size))
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+// discardBody 中间件使后续处理函数写出的响应体被丢弃，只保留状态码、头部和 Content-Length
+func discardBody(c *Context) {
+	origin := c.Writer
+	writer := &headWriter{ResponseWriter: origin}
+	c.Writer = writer
+	defer func() {
+		c.Writer = origin
+	}()
+	c.Next()
+	writer.finish()
+}
+
+// responseRecorder 记录已发送的响应（状态码、头部和前 limit 字节的响应体）的 http.ResponseWriter，
+// 写入直接透传给底层的 http.ResponseWriter
+type responseRecorder struct {
+	http.ResponseWriter
+	limit     int
+	status    int
+	header    http.Header  // 发送状态码时头部的快照
+	body      bytes.Buffer // 已发送的响应体的前 limit 字节
+	truncated bool         // 响应体是否超过 limit
+}
+
+// WriteHeader 方法记录状态码和头部的快照并发送
+func (w *responseRecorder) WriteHeader(code int) {
+	if w.status == 0 && code >= http.StatusOK {
+		w.status = code
+		w.header = w.ResponseWriter.Header().Clone()
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write 方法记录并发送数据
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.Write(data)
+	room := w.limit - w.body.Len()
+	if room < 0 {
+		room = 0
+	}
+	if room < n {
+		w.body.Write(data[:room])
+		w.truncated = true
+	} else {
+		w.body.Write(data[:n])
+	}
+	return n, err
+}
+
+// Flush 方法发送已写出的数据
+func (w *responseRecorder) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack 方法接管底层连接（如 WebSocket 升级），底层的 http.ResponseWriter 不支持时返回 http.ErrNotSupported；
+// 接管之后的数据不会被记录
+func (w *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	return hijacker.Hijack()
+}
+
+// Unwrap 方法返回底层的 http.ResponseWriter，供 http.ResponseController 访问其他扩展接口
+func (w *responseRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// RecordResponse 是响应记录中间件的构造函数，记录后续处理函数实际发送的状态码、头部和前 limit 字节的响应体。
+// 注册在它之后的中间件可以在 c.Next() 返回后通过 c.ResponseBody、c.ResponseHeader 读取，
+// 缓存、签名、审计等中间件不需要各自包装 c.Writer。记录的是经过内层中间件（如压缩）处理后的内容。
+//
+// limit 为 0 时只记录状态码和头部，为负数时 panic。
+//
+// 如：e.Use(zinc.RecordResponse(64<<10), audit)
+func RecordResponse(limit int) HandlerFunc {
+	if limit < 0 {
+		panic(fmt.Sprintf("zinc: RecordResponse limit must not be negative, got %d", limit))
+	}
+	return func(c *Context) {
+		if c.recorder != nil {
+			c.Next()
+			return
+		}
+		origin := c.Writer
+		c.recorder = &responseRecorder{ResponseWriter: origin, limit: limit}
+		c.Writer = c.recorder
+		defer func() {
+			c.Writer = origin
+		}()
+		c.Next()
+	}
+}
+
+// ResponseBody 方法返回已发送的响应体（最多 RecordResponse 的 limit 字节），complete 为 false 表示响应体被截断；
+// 没有注册 RecordResponse 时返回 nil 和 false。返回的切片在请求结束后不能再使用。
+func (c *Context) ResponseBody() (body []byte, complete bool) {
+	if c.recorder == nil {
+		return nil, false
+	}
+	return c.recorder.body.Bytes(), !c.recorder.truncated
+}
+
+// ResponseHeader 方法返回发送状态码时的响应头部；还没有发送或没有注册 RecordResponse 时返回当前的响应头部
+func (c *Context) ResponseHeader() http.Header {
+	if c.recorder != nil && c.recorder.header != nil {
+		return c.recorder.header
+	}
+	return c.Writer.Header()
+}
@@ -0,0 +1,142 @@
+package zinc
+
+import (
+	"net/http"
+	"net/textproto"
+	"regexp"
+	"strings"
+)
+
+// RewriteRule 请求改写规则，在路由匹配之前修改请求
+type RewriteRule func(req *http.Request)
+
+// Rewrite 是请求改写中间件的构造函数，按顺序应用 rules。
+// 需要通过 (*Engine).Pre 注册才能在路由匹配之前生效，
+// 如：e.Pre(zinc.Rewrite(zinc.StripPrefix("/v1"), zinc.MapPath("/old/:id", "/new/:id")))
+func Rewrite(rules ...RewriteRule) HandlerFunc {
+	return func(c *Context) {
+		for _, rule := range rules {
+			rule(c.Req)
+		}
+		// 路由匹配使用 c.Path，改写后需同步
+		c.Path = c.Req.URL.Path
+	}
+}
+
+// setPath 修改请求路径，同时清空已失效的 RawPath
+func setPath(req *http.Request, path string) {
+	if path == "" || path[0] != '/' {
+		path = "/" + path
+	}
+	req.URL.Path = path
+	req.URL.RawPath = ""
+}
+
+// StripPrefix 返回去掉路径前缀 prefix 的改写规则，如 /v1/users 改写为 /users；
+// 前缀按完整的段匹配，/v10/users 不会被改写
+func StripPrefix(prefix string) RewriteRule {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return func(req *http.Request) {
+		if hasPathPrefix(req.URL.Path, prefix) {
+			setPath(req, req.URL.Path[len(prefix):])
+		}
+	}
+}
+
+// AddPrefix 返回为路径加上前缀 prefix 的改写规则，如 /users 改写为 /v1/users；
+// 已经以完整的段 prefix 开头的路径保持不变，/v10/users 改写为 /v1/v10/users
+func AddPrefix(prefix string) RewriteRule {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return func(req *http.Request) {
+		if !hasPathPrefix(req.URL.Path, prefix) {
+			setPath(req, prefix+req.URL.Path)
+		}
+	}
+}
+
+// hasPathPrefix 判断 path 是否以完整的段 prefix（不含末尾的`/`）开头，如 /v1 匹配 /v1 和 /v1/users，不匹配 /v10/users
+func hasPathPrefix(path string, prefix string) bool {
+	return prefix == "" || path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// MapPath 返回将匹配 from 的路径改写为 to 的规则。
+// from 和 to 支持`:`和`*`两种参数，如 MapPath("/old/:id", "/new/:id") 将 /old/1 改写为 /new/1。
+// from 中的参数可以带有与路由相同的约束，如 MapPath("/old/:id<int>", "/new/:id") 不改写 /old/abc。
+func MapPath(from string, to string) RewriteRule {
+	fromParts := parsePattern(from)
+	// 在创建规则时编译参数约束，如 /old/:id<int> 只改写 id 为整数的路径
+	matchers := make([]*regexp.Regexp, len(fromParts))
+	for index, part := range fromParts {
+		if expr := constraintOf(part); expr != "" {
+			matchers[index] = compileConstraint(expr)
+		}
+	}
+	return func(req *http.Request) {
+		params, ok := matchParts(fromParts, matchers, parsePattern(req.URL.Path))
+		if ok {
+			setPath(req, expandPattern(to, params))
+		}
+	}
+}
+
+// NormalizeHeaders 返回将头部键名规范化（如 x-request-id 改为 X-Request-Id）并合并重复键的改写规则
+func NormalizeHeaders() RewriteRule {
+	return func(req *http.Request) {
+		for key, values := range req.Header {
+			canonical := textproto.CanonicalMIMEHeaderKey(key)
+			if canonical != key {
+				delete(req.Header, key)
+				req.Header[canonical] = append(req.Header[canonical], values...)
+			}
+		}
+	}
+}
+
+// DefaultQueryParam 返回在查询字符串缺少 key 时注入默认值 value 的改写规则
+func DefaultQueryParam(key string, value string) RewriteRule {
+	return func(req *http.Request) {
+		query := req.URL.Query()
+		if _, ok := query[key]; !ok {
+			query.Set(key, value)
+			req.URL.RawQuery = query.Encode()
+		}
+	}
+}
+
+// matchParts 将路径 searchParts 与模式 parts 逐段匹配，返回以参数名为键的参数；
+// matchers 与 parts 一一对应，非空时对应的参数必须满足该约束
+func matchParts(parts []string, matchers []*regexp.Regexp, searchParts []string) (map[string]string, bool) {
+	params := make(map[string]string)
+	for index, part := range parts {
+		if part[0] == '*' {
+			if index > len(searchParts) {
+				return nil, false
+			}
+			params[paramName(part)] = strings.Join(searchParts[index:], "/")
+			return params, true
+		}
+		if index >= len(searchParts) {
+			return nil, false
+		}
+		if part[0] == ':' {
+			if matchers[index] != nil && !matchers[index].MatchString(searchParts[index]) {
+				return nil, false
+			}
+			params[paramName(part)] = searchParts[index]
+		} else if part != searchParts[index] {
+			return nil, false
+		}
+	}
+	return params, len(parts) == len(searchParts)
+}
+
+// expandPattern 用 params 替换 pattern 中的`:`和`*`参数，生成具体路径
+func expandPattern(pattern string, params map[string]string) string {
+	parts := parsePattern(pattern)
+	for index, part := range parts {
+		if part[0] == ':' || part[0] == '*' {
+			parts[index] = params[paramName(part)]
+		}
+	}
+	return "/" + strings.Join(parts, "/")
+}
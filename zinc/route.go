@@ -0,0 +1,195 @@
+package zinc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+)
+
+// Route 已注册的路由。GET、POST 等注册方法返回 *Route，可以链式声明路由的附加信息，
+// 如：g.POST("/users", create).Consumes(CreateUserReq{}).Produces(UserResp{})
+type Route struct {
+	Method       string                 // 请求方法
+	Host         string                 // 路由只匹配的 Host，为空时不区分 Host
+	Pattern      string                 // 完整的路由地址（包含分组前缀）
+	Name         string                 // 通过 Named 设置的路由名称
+	Metadata     map[string]interface{} // 通过 Meta 附加的元数据，如 auth、rate-limit 等标记
+	RequestType  reflect.Type           // 通过 Consumes 声明的请求体类型，可用于生成 OpenAPI 文档
+	ResponseType reflect.Type           // 通过 Produces 声明的响应体类型，可用于生成 OpenAPI 文档
+	node         *node                  // 路由在前缀树中对应的节点
+	group        *RouterGroup           // 注册路由的分组
+	handler      HandlerFunc            // 注册时传入的 Handler
+	middlewares  []HandlerFunc          // 通过 Use 添加的只对该路由生效的中间件
+	noCompress   bool                   // 通过 NoCompress 声明不压缩响应
+	validate     bool                   // 通过 Validate 开启请求体校验
+	critical     bool                   // 通过 Critical 声明为关键路由
+	budget       *Budget                // 通过 Budget 设置的响应预算
+	canary       *canary                // 通过 Canary 设置的灰度 Handler
+}
+
+// Consumes 方法声明路由的JSON请求体类型，配合 Validate 在 Handler 之前校验请求体
+func (route *Route) Consumes(v interface{}) *Route {
+	return route.update("Route.Consumes", func() {
+		route.RequestType = reflect.TypeOf(v)
+	})
+}
+
+// Validate 方法在 Handler 之前插入按 Consumes 声明的类型校验请求体的步骤：
+// 请求体无法严格解析为该类型（包括出现未声明的字段）时以 400 状态码中止请求，
+// 超过 Engine.MaxBodyBytes 时以 413 状态码中止请求。
+// 如：g.POST("/users", create).Consumes(CreateUserReq{}).Validate()
+func (route *Route) Validate() *Route {
+	return route.update("Route.Validate", func() {
+		route.validate = true
+	})
+}
+
+// NoCompress 方法声明路由的响应不经过压缩中间件压缩，如已经压缩过的文件下载、包含密钥的响应
+func (route *Route) NoCompress() *Route {
+	return route.update("Route.NoCompress", func() {
+		route.noCompress = true
+	})
+}
+
+// Use 方法添加只对该路由生效的中间件，在所有分组的中间件之后、Handler 之前执行
+func (route *Route) Use(middlewares ...HandlerFunc) *Route {
+	return route.update("Route.Use", func() {
+		route.middlewares = append(route.middlewares, middlewares...)
+	})
+}
+
+// update 方法在路由表冻结之前修改路由并重新计算处理函数链，op 为调用的操作。
+// 修改和重新计算时持有 engine.mu，不会与 AddRoute、RemoveRoute 等并发修改冲突
+func (route *Route) update(op string, fn func()) *Route {
+	engine := route.group.engine
+	engine.checkMutable(op)
+	engine.mu.Lock()
+	defer engine.mu.Unlock()
+	fn()
+	route.rebuild()
+	return route
+}
+
+// rebuild 方法重新计算路由的处理函数链，调用时需要持有 engine.mu 或路由表还没有开始处理请求：所有上层分组的中间件、响应预算、路由的中间件、
+// NoCompress 和 Validate 声明的附加步骤、Handler（设置了灰度时为分流处理函数）
+func (route *Route) rebuild() {
+	handler := route.handler
+	if route.canary != nil {
+		handler = route.canary.split(handler)
+	}
+	handlers := route.group.combineHandlers(route.Method, handler)
+	var steps []HandlerFunc
+	if route.budget != nil {
+		steps = append(steps, ResponseBudget(*route.budget))
+	}
+	steps = append(steps, route.middlewares...)
+	if route.noCompress {
+		steps = append(steps, disableCompression)
+	}
+	if route.validate && route.RequestType != nil {
+		steps = append(steps, validateBody(route.RequestType))
+	}
+	if len(steps) > 0 {
+		// 附加步骤插入到 Handler 之前
+		last := len(handlers) - 1
+		handlers = append(append(handlers[:last:last], steps...), handlers[last])
+	}
+	route.node.handlers = handlers
+}
+
+// Named 方法设置路由名称
+func (route *Route) Named(name string) *Route {
+	return route.update("Route.Named", func() {
+		route.Name = name
+	})
+}
+
+// Meta 方法为路由附加元数据，中间件可以通过 c.RouteInfo 读取并按路由做出不同的处理，
+// 如：g.GET("/admin", h).Meta("auth", true)
+func (route *Route) Meta(key string, value interface{}) *Route {
+	return route.update("Route.Meta", func() {
+		if route.Metadata == nil {
+			route.Metadata = make(map[string]interface{})
+		}
+		route.Metadata[key] = value
+	})
+}
+
+// RouteInfo 匹配到的路由信息
+type RouteInfo struct {
+	Method   string
+	Pattern  string
+	Name     string
+	Metadata map[string]interface{}
+}
+
+// RouteInfo 方法返回当前请求匹配到的路由信息，没有匹配的路由时返回零值
+func (c *Context) RouteInfo() RouteInfo {
+	if c.route == nil {
+		return RouteInfo{}
+	}
+	return RouteInfo{
+		Method:   c.route.Method,
+		Pattern:  c.route.Pattern,
+		Name:     c.route.Name,
+		Metadata: c.route.Metadata,
+	}
+}
+
+// RouteMeta 方法返回当前请求匹配到的路由上键为 key 的元数据，不存在时 ok 为 false
+func (c *Context) RouteMeta(key string) (value interface{}, ok bool) {
+	if c.route == nil {
+		return nil, false
+	}
+	value, ok = c.route.Metadata[key]
+	return value, ok
+}
+
+// Critical 方法声明路由为关键路由，Handler 发生 panic 时以 SeverityCritical 上报
+func (route *Route) Critical() *Route {
+	return route.update("Route.Critical", func() {
+		route.critical = true
+	})
+}
+
+// Produces 方法声明路由的响应体类型
+func (route *Route) Produces(v interface{}) *Route {
+	return route.update("Route.Produces", func() {
+		route.ResponseType = reflect.TypeOf(v)
+	})
+}
+
+// validateBody 返回按类型 t 严格解析JSON请求体的校验处理函数，请求体通过 c.bufferBody 读取，Handler 仍可以再次读取
+func validateBody(t reflect.Type) HandlerFunc {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return func(c *Context) {
+		if c.Req.Body == nil {
+			c.Fail(http.StatusBadRequest, "request body is required")
+			return
+		}
+		data, err := c.bufferBody()
+		if errors.Is(err, ErrRequestBodyTooLarge) {
+			c.Fail(http.StatusRequestEntityTooLarge, "Request Entity Too Large")
+			return
+		}
+		if err != nil {
+			c.Fail(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(reflect.New(t).Interface()); err != nil {
+			c.Fail(http.StatusBadRequest, err.Error())
+		}
+	}
+}
+
+// Routes 方法返回所有已注册的路由的副本，按注册顺序排列；克隆的 Engine 返回原 Engine 的路由
+func (engine *Engine) Routes() []*Route {
+	return engine.routeList()
+}
@@ -2,24 +2,30 @@ package zinc
 
 import (
 	"net/http"
+	"net/url"
 	"strings"
 )
 
 // router 路由结构
 type router struct {
 	// 使用 roots 来存储每种请求方式的Trie 树根节点。
-	roots    map[string]*node
-	handlers map[string]HandlerFunc
+	// 每个已注册route的处理函数链（中间件+Handler）存储在对应的node上。
+	roots map[string]*node
+	// 不含`:`和`*`的静态路由，按请求方式和规范化的路径直接索引到node，查找时先于前缀树
+	statics map[string]map[string]*node
+	// 匹配失败时继续查找的路由结构，如 Host 分组的路由结构回退到普通路由结构
+	fallback *router
+	// `:`子域名分组的路由结构中，捕获的子域名对应的路由参数名
+	subdomainParam string
 }
 
 // roots key 例子： roots['GET']、roots['POST']
-// handlers key 例子： handlers['GET-/p/:lang/doc']、handlers['POST-/p/book']
 
 // newRouter 是 zinc.router 的构造函数
 func newRouter() *router {
 	return &router{
-		roots:    make(map[string]*node),
-		handlers: make(map[string]HandlerFunc),
+		roots:   make(map[string]*node),
+		statics: make(map[string]map[string]*node),
 	}
 }
 
@@ -40,55 +46,87 @@ func parsePattern(pattern string) []string {
 	return parts
 }
 
-// addRoute 方法将pattern和对应处理函数注册到路由表中，并将路由插入到method对应的前缀树中
-func (r *router) addRoute(method string, pattern string, handler HandlerFunc) {
-	// 拆分pattern（url）
-	parts := parsePattern(pattern)
-
-	key := method + "-" + pattern
-	// 注册到路由表
-	r.handlers[key] = handler
-
+// addRoute 方法将路由插入到method对应的前缀树中，并把处理函数链handlers存储在route对应的node上；
+// 返回route对应的node
+func (r *router) addRoute(method string, pattern string, handlers []HandlerFunc) *node {
 	_, ok := r.roots[method]
 	// 该method对应的前缀树不存在，创建根节点
 	if !ok {
 		r.roots[method] = &node{}
 	}
-	// 将pattern拆分的part逐个插入method对应的前缀树中
-	r.roots[method].insert(pattern, parts, 0)
+	// 将pattern插入method对应的前缀树中
+	n := r.roots[method].insert(pattern)
+	n.handlers = handlers
+	// 静态路由同时加入静态路由表
+	if path := normalizePattern(pattern); wildcardIndex(path) < 0 {
+		if r.statics[method] == nil {
+			r.statics[method] = make(map[string]*node)
+		}
+		r.statics[method][path] = n
+	}
+	return n
 }
 
 // getRoute 方法取得路由。
 // 解析了`:`和`*`两种匹配符的参数；
-// 返回path对应的node（已注册的route）和储存解析结果的params（map类型） 。
-func (r *router) getRoute(method string, path string) (*node, map[string]string) {
-	searchParts := parsePattern(path)
-	params := make(map[string]string)
-	root, ok := r.roots[method]
-	// 该method对应的前缀树不存在
-	if !ok {
-		return nil, nil
-	}
+// 返回path对应的node（已注册的route）和储存解析结果的params 。
+func (r *router) getRoute(method string, path string) (*node, Params) {
+	var params Params
+	n := r.lookup(method, path, 0, &params)
+	return n, params
+}
 
-	n := root.search(searchParts, 0)
+// getRouteFold 方法以忽略大小写的方式取得路由，静态part比较时忽略大小写。
+func (r *router) getRouteFold(method string, path string) (*node, Params) {
+	var params Params
+	n := r.lookup(method, path, searchFold, &params)
+	return n, params
+}
 
-	if n != nil {
-		parts := parsePattern(n.pattern)
-		for index, part := range parts {
+// searchMode 方法返回 engine 的选项对应的路由查找方式
+func (engine *Engine) searchMode() searchMode {
+	if engine.DisableBacktracking {
+		return searchStrict
+	}
+	return 0
+}
+
+// lookup 方法在method对应的前缀树中按查找方式mode查找path；
+// 解析出的参数追加到params中，只有匹配到的路由含有参数时才会写入，params可以复用以避免内存分配。
+func (r *router) lookup(method string, path string, mode searchMode, params *Params) *node {
+	start := len(*params)
+	path = cleanSearchPath(path)
+	// 静态路由优先于参数路由，命中静态路由表时不需要查找前缀树
+	if mode&searchFold == 0 {
+		if n, ok := r.statics[method][path]; ok && n.pattern != "" {
+			return n
+		}
+	}
+	if root, ok := r.roots[method]; ok {
+		if n := root.search(path, mode, params); n != nil {
 			// 如：`/p/go/doc`匹配到`/p/:lang/doc`，解析结果为：`{lang: "go"}`；
-			if part[0] == ':' {
-				params[part[1:]] = searchParts[index]
-			}
+			// 带约束的参数如`:id<int>`，解析结果的键为`id`；
 			// 如：`/static/css/zincRe.css`匹配到`/static/*filepath`，解析结果为`{filepath: "css/zincRe.css"}`。
-			if part[0] == '*' && len(part) > 1 {
-				params[part[1:]] = strings.Join(searchParts[index:], "/")
-				break
+			values := (*params)[start:]
+			kept := start
+			for index, name := range n.paramNames {
+				// 匿名的通配符`*`不解析参数
+				if name != "" {
+					(*params)[kept] = Param{Key: name, Value: values[index].Value}
+					kept++
+				}
 			}
+			*params = (*params)[:kept]
+			return n
 		}
-		return n, params
 	}
+	*params = (*params)[:start]
 
-	return nil, nil
+	// 该method对应的前缀树不存在或没有匹配的路由
+	if r.fallback != nil {
+		return r.fallback.lookup(method, path, mode, params)
+	}
+	return nil
 }
 
 // getRoutes 方法返回method作为root下的所有route（每一个node即已注册的route)
@@ -102,26 +140,140 @@ func (r *router) getRoutes(method string) []*node {
 	return nodes
 }
 
-// handle 方法匹配路由对应的处理函数Handler ，添加到(*Context).handlers列表中；
-// 通过传入的 Context对象 的 Next 方法，依次调用 Context对象handlers列表中的Handler和中间件。
+// canonicalPath 返回path按pattern规范大小写后的路径：静态part取pattern中的写法，参数part保持原样
+func canonicalPath(pattern string, path string) string {
+	parts := parsePattern(pattern)
+	searchParts := parsePattern(path)
+	for index, part := range parts {
+		if part[0] == '*' {
+			parts = append(parts[:index], searchParts[index:]...)
+			break
+		}
+		if part[0] == ':' {
+			parts[index] = searchParts[index]
+		}
+	}
+	return "/" + strings.Join(parts, "/")
+}
+
+// unescapeParams 对路由参数进行百分号解码，如 John%2FDoe 解码为 John/Doe；无法解码的值保持原样
+func unescapeParams(params Params) {
+	for i := range params {
+		if unescaped, err := url.PathUnescape(params[i].Value); err == nil {
+			params[i].Value = unescaped
+		}
+	}
+}
+
+// emptyCatchAll 判断 path 是否以空的通配参数匹配到通配路由 n，如 /assets 和 /assets/ 匹配 /assets/*filepath
+func emptyCatchAll(n *node, path string) bool {
+	if n.kind != catchAll {
+		return false
+	}
+	segments := func(p string) int {
+		return len(strings.FieldsFunc(p, func(r rune) bool { return r == '/' }))
+	}
+	prefix := n.pattern[:strings.LastIndex(n.pattern, "*")]
+	return segments(path) == segments(prefix)
+}
+
+// notFound 是默认的 404 处理函数
+func notFound(c *Context) {
+	// 设置了响应信封或开启 ProblemJSON 时以 JSON 格式输出
+	if c.envelope != nil || c.engine.ProblemJSON {
+		c.Fail(http.StatusNotFound, "404 NOT FOUND: "+c.Path)
+		return
+	}
+	c.String(http.StatusNotFound, "404 NOT FOUND: %s\n", c.Path)
+}
+
+// match 方法匹配路由对应的处理函数链（注册时计算好的中间件和Handler），赋值给(*Context).handlers；
+// 之后通过 Context对象 的 Next 方法，依次调用 Context对象handlers列表中的Handler和中间件。
 //
-// handle 方法将解析出来的路由参数赋值给了 Context对象 的 Params
+// match 方法将解析出来的路由参数赋值给了 Context对象 的 Params
 //（如：GET /a/asd/c || GET a/s/c 匹配到路由(GET-/a/:param/c)对应的HandlerFunc，并把asd || s 存在Context的Params里）。
-func (r *router) handle(c *Context) {
-	n, params := r.getRoute(c.Method, c.Path)
+//
+// 需要重定向到规范路径时返回重定向的目标地址，否则返回空字符串。
+func (r *router) match(c *Context) (redirect string) {
+	path := c.Path
+	// 开启 UseRawPath 时使用未解码的路径匹配，使 %2F 等转义字符不会被当作路径分隔符
+	if c.engine.UseRawPath && c.Req.URL.RawPath != "" {
+		path = c.Req.URL.EscapedPath()
+		// 匹配成功后再解码路由参数
+		if c.engine.UnescapePathValues {
+			defer func() {
+				unescapeParams(c.Params)
+			}()
+		}
+	}
+
+	// 解析出的参数直接写入c.Params，复用其空间
+	n := c.engine.owner().cachedLookup(r, c.Method, path, &c.Params)
+	// 开启大小写不敏感匹配时，精确匹配失败后再忽略大小写匹配一次
+	if n == nil && c.engine.CaseInsensitive {
+		n = r.lookup(c.Method, path, c.engine.searchMode()|searchFold, &c.Params)
+		if n != nil && c.engine.RedirectCanonicalCase && (c.Method == http.MethodGet || c.Method == http.MethodHead) {
+			// 重定向到与注册路由大小写一致的规范路径
+			target := canonicalPath(n.pattern, path)
+			if c.Req.URL.RawQuery != "" {
+				target += "?" + c.Req.URL.RawQuery
+			}
+			return target
+		}
+	}
+
+	// 要求通配参数非空时，以空的通配参数匹配的路由视为匹配失败
+	if n != nil && c.engine.NonEmptyCatchAll && emptyCatchAll(n, path) {
+		n = nil
+		c.Params = c.Params[:0]
+	}
+
+	// HEAD 请求没有匹配的路由时交给 GET 路由处理，丢弃响应体
+	if n == nil && c.Method == http.MethodHead && c.engine.HeadFallbackToGet {
+		if n = r.lookup(http.MethodGet, path, c.engine.searchMode(), &c.Params); n != nil && !(c.engine.NonEmptyCatchAll && emptyCatchAll(n, path)) {
+			c.route = n.route
+			c.handlers = make([]HandlerFunc, 0, len(n.handlers)+1)
+			c.handlers = append(c.handlers, discardBody)
+			c.handlers = append(c.handlers, n.handlers...)
+			return ""
+		}
+	}
 
 	if n != nil {
-		// 将解析出来的路由参数赋值给了c.Params
-		c.Params = params
-		key := c.Method + "-" + n.pattern
-		// 将从路由匹配得到的 Handler 添加到 `c.handlers`列表中
-		c.handlers = append(c.handlers, r.handlers[key])
+		c.route = n.route
+		// 注册时已计算好的处理函数链（中间件+Handler）
+		c.handlers = n.handlers
 	} else {
-		// 匹配失败，将显示匹配失败的函数添加到 `c.handlers`列表中
-		c.handlers = append(c.handlers, func(c *Context) {
-			c.String(http.StatusNotFound, "404 NOT FOUND: %s\n", c.Path)
-		})
+		// 匹配失败时不属于任何分组，只执行全局中间件
+		global := c.engine.owner().RouterGroup.middlewaresFor(c.Method)
+		c.handlers = make([]HandlerFunc, 0, len(global)+len(c.engine.noRoute)+2)
+		c.handlers = append(c.handlers, global...)
+		if len(c.engine.fallbacks) > 0 {
+			c.handlers = append(c.handlers, c.engine.runFallbacks)
+		}
+		if len(c.engine.noRoute) > 0 {
+			// 将用户通过 NoRoute 设置的处理函数链添加到 `c.handlers`列表中
+			c.handlers = append(c.handlers, c.engine.noRoute...)
+		} else {
+			// 将显示匹配失败的函数添加到 `c.handlers`列表中
+			c.handlers = append(c.handlers, notFound)
+		}
 	}
+	return ""
+}
 
-	c.Next()
+// removeRoute 方法从method对应的前缀树中删除pattern，返回是否删除成功
+func (r *router) removeRoute(method string, pattern string) bool {
+	root, ok := r.roots[method]
+	if !ok {
+		return false
+	}
+	if !root.remove(pattern) {
+		return false
+	}
+	path := normalizePattern(pattern)
+	if n, ok := r.statics[method][path]; ok && n.pattern == "" {
+		delete(r.statics[method], path)
+	}
+	return true
 }
\ No newline at end of file
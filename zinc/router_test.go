@@ -2,7 +2,9 @@ package zinc
 
 import (
 	"fmt"
+	"net/http"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -37,24 +39,24 @@ func TestGetRoute(t *testing.T) {
 		t.Fatal("should match /hello/:name")
 	}
 
-	if ps["name"] != "geektutu" {
+	if ps.ByName("name") != "geektutu" {
 		t.Fatal("name should be equal to 'geektutu'")
 	}
 
-	fmt.Printf("matched path: %s, params['name']: %s\n", n.pattern, ps["name"])
+	fmt.Printf("matched path: %s, params['name']: %s\n", n.pattern, ps.ByName("name"))
 
 }
 
 func TestGetRoute2(t *testing.T) {
 	r := newTestRouter()
 	n1, ps1 := r.getRoute("GET", "/assets/file1.txt")
-	ok1 := n1.pattern == "/assets/*filepath" && ps1["filepath"] == "file1.txt"
+	ok1 := n1.pattern == "/assets/*filepath" && ps1.ByName("filepath") == "file1.txt"
 	if !ok1 {
 		t.Fatal("pattern shoule be /assets/*filepath & filepath shoule be file1.txt")
 	}
 
 	n2, ps2 := r.getRoute("GET", "/assets/css/test.css")
-	ok2 := n2.pattern == "/assets/*filepath" && ps2["filepath"] == "css/test.css"
+	ok2 := n2.pattern == "/assets/*filepath" && ps2.ByName("filepath") == "css/test.css"
 	if !ok2 {
 		t.Fatal("pattern shoule be /assets/*filepath & filepath shoule be css/test.css")
 	}
@@ -71,4 +73,274 @@ func TestGetRoutes(t *testing.T) {
 	if len(nodes) != 5 {
 		t.Fatal("the number of routes shoule be 4")
 	}
-}
\ No newline at end of file
+}
+func TestGetRouteConstraint(t *testing.T) {
+	r := newRouter()
+	r.addRoute("GET", "/orders/:id<int>", nil)
+	r.addRoute("GET", "/orders/:name", nil)
+	r.addRoute("GET", "/files/:name<[a-z]+>", nil)
+
+	n, ps := r.getRoute("GET", "/orders/42")
+	if n == nil || n.pattern != "/orders/:id<int>" || ps.ByName("id") != "42" {
+		t.Fatal("/orders/42 should match /orders/:id<int> with id 42")
+	}
+
+	n, ps = r.getRoute("GET", "/orders/latest")
+	if n == nil || n.pattern != "/orders/:name" || ps.ByName("name") != "latest" {
+		t.Fatal("/orders/latest should fall back to /orders/:name")
+	}
+
+	n, _ = r.getRoute("GET", "/files/ABC")
+	if n != nil {
+		t.Fatal("/files/ABC shouldn't match /files/:name<[a-z]+>")
+	}
+}
+
+func TestRadixTree(t *testing.T) {
+	r := newRouter()
+	r.addRoute("GET", "/help", nil)
+	hello := r.addRoute("GET", "/hello/:name", nil)
+	r.addRoute("GET", "/hel", nil)
+
+	n, ps := r.getRoute("GET", "/hello/zinc")
+	if n != hello || ps.ByName("name") != "zinc" {
+		t.Fatal("/hello/zinc should match /hello/:name after the prefix split")
+	}
+	for _, path := range []string{"/help", "/hel"} {
+		if n, _ := r.getRoute("GET", path); n == nil || n.pattern != path {
+			t.Fatalf("%s should match itself", path)
+		}
+	}
+	if n, _ := r.getRoute("GET", "/he"); n != nil {
+		t.Fatal("/he shouldn't match any route")
+	}
+
+	if !r.removeRoute("GET", "/hel") {
+		t.Fatal("/hel should be removed")
+	}
+	if n, _ := r.getRoute("GET", "/hel"); n != nil {
+		t.Fatal("/hel shouldn't match after removal")
+	}
+	if n, _ := r.getRoute("GET", "/help"); n == nil {
+		t.Fatal("/help should still match after removing /hel")
+	}
+}
+
+func BenchmarkGetRoute(b *testing.B) {
+	r := newTestRouter()
+	params := make(Params, 0, 4)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		params = params[:0]
+		r.lookup("GET", "/hello/zinc", 0, &params)
+	}
+}
+
+func TestLookupReusesParams(t *testing.T) {
+	r := newTestRouter()
+	params := make(Params, 0, 4)
+	allocs := testing.AllocsPerRun(100, func() {
+		params = params[:0]
+		if n := r.lookup("GET", "/hello/zinc", 0, &params); n == nil || params.ByName("name") != "zinc" {
+			t.Fatal("/hello/zinc should match /hello/:name")
+		}
+		params = params[:0]
+		if n := r.lookup("GET", "/hello/b/c", 0, &params); n == nil || len(params) != 0 {
+			t.Fatal("/hello/b/c should match without params")
+		}
+	})
+	if allocs != 0 {
+		t.Fatalf("lookup should not allocate with reused params, got %v allocs", allocs)
+	}
+}
+
+func TestStaticRoutes(t *testing.T) {
+	r := newTestRouter()
+	if _, ok := r.statics["GET"]["/hello/b/c"]; !ok {
+		t.Fatal("/hello/b/c should be in the static route table")
+	}
+	if _, ok := r.statics["GET"]["/hello/:name"]; ok {
+		t.Fatal("/hello/:name shouldn't be in the static route table")
+	}
+	if n, _ := r.getRoute("GET", "/hello/b/c/"); n == nil || n.pattern != "/hello/b/c" {
+		t.Fatal("/hello/b/c/ should match /hello/b/c through the static route table")
+	}
+
+	if !r.removeRoute("GET", "/hello/b/c") {
+		t.Fatal("/hello/b/c should be removed")
+	}
+	if _, ok := r.statics["GET"]["/hello/b/c"]; ok {
+		t.Fatal("/hello/b/c should be removed from the static route table")
+	}
+	if n, ps := r.getRoute("GET", "/hello/b"); n == nil || ps.ByName("name") != "b" {
+		t.Fatal("/hello/b should still match /hello/:name")
+	}
+}
+
+func BenchmarkGetStaticRoute(b *testing.B) {
+	r := newTestRouter()
+	params := make(Params, 0, 4)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		params = params[:0]
+		r.lookup("GET", "/hello/b/c", 0, &params)
+	}
+}
+
+func TestValidatePattern(t *testing.T) {
+	for _, pattern := range []string{"/", "/hello/:name", "/orders/:id<int>", "/assets/*filepath", "/assets/*", "/assets/*filepath/"} {
+		if err := validatePattern(pattern); err != nil {
+			t.Fatalf("%s should be valid, got %v", pattern, err)
+		}
+	}
+	for pattern, segment := range map[string]string{
+		"/a/*x/b":         "*x",
+		"/users/:":        ":",
+		"/users/:<int>":   ":<int>",
+		"/users/:id<int":  ":id<int",
+		"/users/:id<[a->": ":id<[a->",
+		"/users/:id/:id":  ":id",
+	} {
+		err := validatePattern(pattern)
+		if err == nil || !strings.Contains(err.Error(), segment) {
+			t.Fatalf("%s should be rejected naming %s, got %v", pattern, segment, err)
+		}
+	}
+}
+
+func TestEmptyCatchAll(t *testing.T) {
+	r := newRouter()
+	r.addRoute("GET", "/assets/*filepath", nil)
+	r.addRoute("GET", "/assetsx", nil)
+	r.addRoute("GET", "/files/:bucket/*key", nil)
+	r.addRoute("GET", "/docs", nil)
+	r.addRoute("GET", "/docs/*page", nil)
+	r.addRoute("GET", "/*spa", nil)
+
+	for _, tt := range []struct {
+		path, pattern, key, value string
+	}{
+		{"/assets/", "/assets/*filepath", "filepath", ""},
+		{"/assets", "/assets/*filepath", "filepath", ""},
+		{"/assets/a.css", "/assets/*filepath", "filepath", "a.css"},
+		{"/files/b", "/files/:bucket/*key", "key", ""},
+		{"/files/b/", "/files/:bucket/*key", "bucket", "b"},
+		// 静态路由优先于空的通配参数
+		{"/docs", "/docs", "", ""},
+		{"/docs/", "/docs", "", ""},
+		{"/docs/intro", "/docs/*page", "page", "intro"},
+		{"/", "/*spa", "spa", ""},
+	} {
+		n, ps := r.getRoute("GET", tt.path)
+		if n == nil || n.pattern != tt.pattern {
+			t.Errorf("%s should match %s, got %v", tt.path, tt.pattern, n)
+			continue
+		}
+		if tt.key != "" && ps.ByName(tt.key) != tt.value {
+			t.Errorf("%s: %s should be %q, got %q", tt.path, tt.key, tt.value, ps.ByName(tt.key))
+		}
+	}
+}
+
+func TestWildcardBacktracking(t *testing.T) {
+	patterns := []string{
+		"/static/*filepath",
+		"/static/admin/login",
+		"/static/admin/:page/edit",
+		"/users/:id",
+		"/users/:id/posts",
+		"/users/new",
+		"/users/new/drafts/:draft",
+		"/orders/:id<int>/items",
+		"/orders/:ref/summary",
+	}
+	tests := []struct {
+		path, pattern string
+		params        map[string]string
+	}{
+		// 更具体的静态路由优先，与注册顺序无关
+		{"/static/admin/login", "/static/admin/login", nil},
+		// 静态分支在更深处失败时回溯到通配符，参数从静态分支残留的值被清除
+		{"/static/admin", "/static/*filepath", map[string]string{"filepath": "admin"}},
+		{"/static/admin/logout", "/static/*filepath", map[string]string{"filepath": "admin/logout"}},
+		{"/static/admin/login/x", "/static/*filepath", map[string]string{"filepath": "admin/login/x"}},
+		{"/static/admin/users/edit", "/static/admin/:page/edit", map[string]string{"page": "users"}},
+		{"/static/admin/users/view", "/static/*filepath", map[string]string{"filepath": "admin/users/view"}},
+		{"/static/adm", "/static/*filepath", map[string]string{"filepath": "adm"}},
+		// 静态节点失败后回溯到参数节点
+		{"/users/new", "/users/new", nil},
+		{"/users/new/posts", "/users/:id/posts", map[string]string{"id": "new"}},
+		{"/users/new/drafts/3", "/users/new/drafts/:draft", map[string]string{"draft": "3"}},
+		// 带约束的参数节点在更深处失败时回溯到普通参数节点
+		{"/orders/42/items", "/orders/:id<int>/items", map[string]string{"id": "42"}},
+		{"/orders/42/summary", "/orders/:ref/summary", map[string]string{"ref": "42"}},
+	}
+
+	// 正序和逆序注册的结果必须一致
+	for _, reverse := range []bool{false, true} {
+		r := newRouter()
+		for i := range patterns {
+			if reverse {
+				i = len(patterns) - 1 - i
+			}
+			r.addRoute("GET", patterns[i], nil)
+		}
+		for _, tt := range tests {
+			n, ps := r.getRoute("GET", tt.path)
+			if n == nil || n.pattern != tt.pattern {
+				t.Errorf("reverse=%v: %s should match %s, got %v", reverse, tt.path, tt.pattern, n)
+				continue
+			}
+			if len(ps) != len(tt.params) {
+				t.Errorf("reverse=%v: %s should have params %v, got %v", reverse, tt.path, tt.params, ps)
+			}
+			for key, value := range tt.params {
+				if ps.ByName(key) != value {
+					t.Errorf("reverse=%v: %s: %s should be %q, got %q", reverse, tt.path, key, value, ps.ByName(key))
+				}
+			}
+		}
+		if n, _ := r.getRoute("GET", "/users/new/drafts"); n != nil {
+			t.Errorf("reverse=%v: /users/new/drafts shouldn't match, got %s", reverse, n.pattern)
+		}
+	}
+}
+
+func TestDisableBacktracking(t *testing.T) {
+	r := newRouter()
+	for _, pattern := range []string{"/static/*filepath", "/static/admin/login", "/users/:id/posts", "/users/new", "/users/newsletter/:id"} {
+		r.addRoute("GET", pattern, nil)
+	}
+	for _, tt := range []struct {
+		path, pattern string
+	}{
+		// 不以完整part匹配静态路由时仍然按参数和通配路由匹配
+		{"/static/adm", "/static/*filepath"},
+		{"/static/admin/login", "/static/admin/login"},
+		{"/users/news/posts", "/users/:id/posts"},
+		// 当前part是静态路由的part时不回溯
+		{"/static/admin/logout", ""},
+		{"/users/new/posts", ""},
+	} {
+		var params Params
+		n := r.lookup("GET", tt.path, searchStrict, &params)
+		if (tt.pattern == "" && n != nil) || (tt.pattern != "" && (n == nil || n.pattern != tt.pattern)) {
+			t.Errorf("%s should match %q without backtracking, got %v", tt.path, tt.pattern, n)
+		}
+		if n == nil && len(params) != 0 {
+			t.Errorf("%s: params should be empty after a failed lookup, got %v", tt.path, params)
+		}
+	}
+
+	e := New()
+	e.DisableBacktracking = true
+	e.GET("/static/*filepath", func(c *Context) {})
+	e.GET("/static/admin/login", func(c *Context) {})
+	if w := performRequest(e, "GET", "/static/admin/logout"); w.Code != http.StatusNotFound {
+		t.Fatalf("/static/admin/logout shouldn't fall back to the catch-all, got %d", w.Code)
+	}
+	e.DisableBacktracking = false
+	if w := performRequest(e, "GET", "/static/admin/logout"); w.Code != http.StatusOK {
+		t.Fatalf("/static/admin/logout should fall back to the catch-all by default, got %d", w.Code)
+	}
+}
@@ -0,0 +1,77 @@
+package zinc
+
+import (
+	"fmt"
+)
+
+// RouteSpec 以数据描述的路由，可以从配置文件（JSON、YAML 等）中读取，交给 Engine.LoadRoutes 注册。
+// Handler 和 Middlewares 为通过 RegisterHandler、RegisterMiddleware 注册的名称。
+type RouteSpec struct {
+	Method      string                 `json:"method" yaml:"method"`
+	Pattern     string                 `json:"pattern" yaml:"pattern"`
+	Handler     string                 `json:"handler" yaml:"handler"`
+	Middlewares []string               `json:"middlewares,omitempty" yaml:"middlewares,omitempty"`
+	Name        string                 `json:"name,omitempty" yaml:"name,omitempty"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+}
+
+// RegisterHandler 方法以名称 name 注册处理函数，供 RouteSpec.Handler 引用
+func (engine *Engine) RegisterHandler(name string, handler HandlerFunc) {
+	if engine.namedHandlers == nil {
+		engine.namedHandlers = make(map[string]HandlerFunc)
+	}
+	engine.namedHandlers[name] = handler
+}
+
+// RegisterMiddleware 方法以名称 name 注册中间件，供 RouteSpec.Middlewares 引用
+func (engine *Engine) RegisterMiddleware(name string, middleware HandlerFunc) {
+	if engine.namedMiddlewares == nil {
+		engine.namedMiddlewares = make(map[string]HandlerFunc)
+	}
+	engine.namedMiddlewares[name] = middleware
+}
+
+// LoadRoutes 方法按 specs 注册路由，路由的中间件在全局中间件之后、Handler 之前按顺序执行。
+// 注册之前先检查所有 spec，引用了未注册的名称或路由地址不合法时返回错误，不注册任何路由。
+//
+// 如：
+//
+//	engine.RegisterHandler("users.list", listUsers)
+//	engine.RegisterMiddleware("auth", auth())
+//	err := engine.LoadRoutes([]zinc.RouteSpec{{Method: "GET", Pattern: "/users", Handler: "users.list", Middlewares: []string{"auth"}}})
+func (engine *Engine) LoadRoutes(specs []RouteSpec) error {
+	for i, spec := range specs {
+		if spec.Method == "" {
+			return fmt.Errorf("zinc: route spec %d: method is empty", i)
+		}
+		if err := validatePattern(spec.Pattern); err != nil {
+			return fmt.Errorf("zinc: route spec %d %s %s: %v", i, spec.Method, spec.Pattern, err)
+		}
+		if _, ok := engine.namedHandlers[spec.Handler]; !ok {
+			return fmt.Errorf("zinc: route spec %d %s %s: handler %q is not registered", i, spec.Method, spec.Pattern, spec.Handler)
+		}
+		for _, name := range spec.Middlewares {
+			if _, ok := engine.namedMiddlewares[name]; !ok {
+				return fmt.Errorf("zinc: route spec %d %s %s: middleware %q is not registered", i, spec.Method, spec.Pattern, name)
+			}
+		}
+	}
+
+	for _, spec := range specs {
+		route := engine.Handle(spec.Method, spec.Pattern, engine.namedHandlers[spec.Handler])
+		if len(spec.Middlewares) > 0 {
+			middlewares := make([]HandlerFunc, 0, len(spec.Middlewares))
+			for _, name := range spec.Middlewares {
+				middlewares = append(middlewares, engine.namedMiddlewares[name])
+			}
+			route.Use(middlewares...)
+		}
+		if spec.Name != "" {
+			route.Named(spec.Name)
+		}
+		for key, value := range spec.Metadata {
+			route.Meta(key, value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package zinc
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Freeze 方法冻结路由表，Run 在启动服务之前自动调用。
+// 冻结之后直接通过 GET、Use、NoRoute、Pre 等方法修改路由会 panic，
+// 因为它们与请求处理并发执行时存在数据竞争；服务运行期间只能通过 AddRoute、RemoveRoute 安全地修改路由。
+func (engine *Engine) Freeze() {
+	atomic.StoreInt32(&engine.frozen, 1)
+}
+
+// Frozen 方法判断路由表是否已冻结
+func (engine *Engine) Frozen() bool {
+	return atomic.LoadInt32(&engine.frozen) == 1
+}
+
+// checkMutable 方法在路由表已冻结时 panic，op 为调用的操作
+func (engine *Engine) checkMutable(op string) {
+	if engine.Frozen() {
+		panic(fmt.Sprintf("zinc: %s after the router is frozen by Run; use Engine.AddRoute or Engine.RemoveRoute while serving", op))
+	}
+}
+
+// AddRoute 方法在服务运行期间注册路由，可与请求处理并发调用，用于插件等动态注册的场景。
+// 路由注册到全局分组，处理函数链包含全局中间件。路由表冻结之后返回的 *Route 不能再通过 Use、Meta 等方法修改。
+func (engine *Engine) AddRoute(method string, pattern string, handler HandlerFunc) *Route {
+	if method == "" {
+		panic("zinc: HTTP method can not be empty")
+	}
+	engine.checkOwner("AddRoute")
+	engine.mu.Lock()
+	defer engine.mu.Unlock()
+	return engine.register(method, pattern, handler)
+}
+
+// RemoveRoute 方法在服务运行期间删除通过全局分组注册的路由，可与请求处理并发调用；返回是否删除成功。
+// pattern 必须与注册时完全一致（如 /hello/:name）。
+// 如果有先注册的路由被该路由覆盖（如先后注册 /hello/:id 和 /hello/:name），删除后恢复先注册的路由。
+func (engine *Engine) RemoveRoute(method string, pattern string) bool {
+	engine.checkOwner("RemoveRoute")
+	engine.mu.Lock()
+	defer engine.mu.Unlock()
+
+	for i := len(engine.routes) - 1; i >= 0; i-- {
+		route := engine.routes[i]
+		if route.Host != "" || route.Method != method || route.Pattern != pattern {
+			continue
+		}
+		engine.routes = append(engine.routes[:i], engine.routes[i+1:]...)
+		engine.invalidateRouteCache()
+		// 恢复同一节点上被覆盖的路由
+		for j := len(engine.routes) - 1; j >= 0; j-- {
+			shadowed := engine.routes[j]
+			if shadowed.node == route.node {
+				shadowed.node.pattern = shadowed.Pattern
+				shadowed.node.paramNames = patternParams(shadowed.Pattern)
+				shadowed.node.route = shadowed
+				shadowed.rebuild()
+				return true
+			}
+		}
+		return engine.router.removeRoute(method, pattern)
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package zinc
+
+import "bytes"
+
+// maxScratchRetain 是 Context 放回对象池时保留的临时缓冲区的最大字节数，更大的缓冲区被丢弃，避免长期占用内存
+const maxScratchRetain = 1 << 20
+
+// Scratch 方法从请求级的临时缓冲区中借出 n 个字节（已清零），请求结束后随 Context 一起回收复用，
+// 适合渲染、绑定等过程中的临时数据，以减少高 QPS 服务的内存分配和 GC 压力。
+// 返回的切片不能在请求结束后继续使用（包括在启动的 goroutine 中），也不能保存在全局数据中。
+// 没有开启 Engine.ScratchSize 时每次调用都分配新的切片。
+func (c *Context) Scratch(n int) []byte {
+	if c.engine == nil || c.engine.ScratchSize <= 0 {
+		return make([]byte, n)
+	}
+	if len(c.scratch)-c.scratchOff < n {
+		// 已借出的切片仍然有效，新的缓冲区按需翻倍
+		size := c.engine.ScratchSize
+		if double := 2 * len(c.scratch); double > size {
+			size = double
+		}
+		if n > size {
+			size = n
+		}
+		c.scratch = make([]byte, size)
+		c.scratchOff = 0
+	}
+	b := c.scratch[c.scratchOff : c.scratchOff+n : c.scratchOff+n]
+	for i := range b {
+		b[i] = 0
+	}
+	c.scratchOff += n
+	return b
+}
+
+// resetScratch 方法在复用 Context 时回收临时缓冲区
+func (c *Context) resetScratch() {
+	c.scratchOff = 0
+	if len(c.scratch) > maxScratchRetain {
+		c.scratch = nil
+	}
+}
+
+// scratchBuffer 方法返回以临时缓冲区剩余空间为底层存储的 bytes.Buffer，供 JSON 等渲染过程先编码再写出；
+// 没有开启 Engine.ScratchSize 时返回 nil，调用方直接写入响应
+func (c *Context) scratchBuffer() *bytes.Buffer {
+	if c.engine == nil || c.engine.ScratchSize <= 0 {
+		return nil
+	}
+	n := len(c.scratch) - c.scratchOff
+	if n <= 0 {
+		n = c.engine.ScratchSize
+	}
+	return bytes.NewBuffer(c.Scratch(n)[:0])
+}
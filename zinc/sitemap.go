@@ -0,0 +1,140 @@
+package zinc
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SitemapOptions sitemap.xml 的生成选项
+type SitemapOptions struct {
+	// BaseURL 站点地址，如 https://example.com，与路由地址拼接为完整的URL
+	BaseURL string
+	// Path sitemap 的路由地址，为空时为 /sitemap.xml
+	Path string
+	// Exclude 返回 true 的路由不出现在 sitemap 中
+	Exclude func(route *Route) bool
+	// LastMod 返回路由对应页面的最后修改时间，返回零值时不输出 lastmod
+	LastMod func(route *Route) time.Time
+	// Paths 返回带参数的路由展开后的路径，如 /posts/:id 展开为 /posts/1、/posts/2；为空时忽略带参数的路由
+	Paths func(route *Route) []string
+}
+
+// sitemapURL sitemap 中的一个页面
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// sitemapURLSet sitemap 的根元素
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+// Sitemap 方法注册返回 sitemap.xml 的路由，sitemap 在请求时根据所有通过 Named 命名的 GET 路由生成，
+// 运行期间通过 AddRoute 注册的路由同样会出现在 sitemap 中。
+//
+// 如：engine.Sitemap(zinc.SitemapOptions{BaseURL: "https://example.com"})
+func (engine *Engine) Sitemap(opts SitemapOptions) *Route {
+	if opts.Path == "" {
+		opts.Path = "/sitemap.xml"
+	}
+	baseURL := strings.TrimSuffix(opts.BaseURL, "/")
+	return engine.GET(opts.Path, func(c *Context) {
+		set := sitemapURLSet{XMLNS: "http://www.sitemaps.org/schemas/sitemap/0.9"}
+		for _, route := range engine.sitemapRoutes(opts) {
+			var lastMod string
+			if opts.LastMod != nil {
+				if t := opts.LastMod(route); !t.IsZero() {
+					lastMod = t.UTC().Format("2006-01-02")
+				}
+			}
+			paths := []string{route.Pattern}
+			if len(patternParams(route.Pattern)) > 0 {
+				paths = opts.Paths(route)
+			}
+			for _, p := range paths {
+				set.URLs = append(set.URLs, sitemapURL{Loc: baseURL + p, LastMod: lastMod})
+			}
+		}
+		data, err := xml.MarshalIndent(set, "", "  ")
+		if err != nil {
+			c.Fail(http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.SetHeader("Content-Type", "application/xml; charset=utf-8")
+		c.Data(http.StatusOK, append([]byte(xml.Header), data...))
+	})
+}
+
+// sitemapRoutes 方法返回出现在 sitemap 中的路由：命名的、没有被排除的 GET 路由，带参数的路由需要设置 opts.Paths
+func (engine *Engine) sitemapRoutes(opts SitemapOptions) []*Route {
+	var routes []*Route
+	for _, route := range engine.routeList() {
+		if route.Method != http.MethodGet || route.Name == "" || route.Pattern == opts.Path {
+			continue
+		}
+		if opts.Exclude != nil && opts.Exclude(route) {
+			continue
+		}
+		if len(patternParams(route.Pattern)) > 0 && opts.Paths == nil {
+			continue
+		}
+		routes = append(routes, route)
+	}
+	return routes
+}
+
+// RobotsRule robots.txt 中针对一类爬虫的规则
+type RobotsRule struct {
+	UserAgent string   // 爬虫名称，为空时为 *
+	Allow     []string // 允许抓取的路径
+	Disallow  []string // 禁止抓取的路径
+}
+
+// RobotsOptions robots.txt 的生成选项
+type RobotsOptions struct {
+	// Rules 抓取规则，为空时允许所有爬虫抓取所有路径
+	Rules []RobotsRule
+	// Sitemap sitemap 的完整地址，非空时输出 Sitemap 行
+	Sitemap string
+}
+
+// Robots 方法注册返回 /robots.txt 的路由
+func (engine *Engine) Robots(opts RobotsOptions) *Route {
+	rules := opts.Rules
+	if len(rules) == 0 {
+		rules = []RobotsRule{{}}
+	}
+	var b strings.Builder
+	for i, rule := range rules {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		userAgent := rule.UserAgent
+		if userAgent == "" {
+			userAgent = "*"
+		}
+		b.WriteString("User-agent: " + userAgent + "\n")
+		for _, p := range rule.Allow {
+			b.WriteString("Allow: " + p + "\n")
+		}
+		for _, p := range rule.Disallow {
+			b.WriteString("Disallow: " + p + "\n")
+		}
+		if len(rule.Allow) == 0 && len(rule.Disallow) == 0 {
+			b.WriteString("Disallow:\n")
+		}
+	}
+	if opts.Sitemap != "" {
+		b.WriteString("\nSitemap: " + opts.Sitemap + "\n")
+	}
+	content := []byte(b.String())
+	return engine.GET("/robots.txt", func(c *Context) {
+		c.SetHeader("Content-Type", "text/plain; charset=utf-8")
+		c.Data(http.StatusOK, content)
+	})
+}
@@ -0,0 +1,156 @@
+package zinc
+
+import (
+	"sync"
+	"time"
+)
+
+// SLOConfig 服务等级目标（SLO）的配置
+type SLOConfig struct {
+	// Name SLO 的名称，如分组的前缀，用于区分回调和指标
+	Name string
+	// Objective 达标请求的目标比例，如 0.999；错误预算为 1-Objective
+	Objective float64
+	// Latency 大于 0 时，耗时超过 Latency 的请求也计为不达标
+	Latency time.Duration
+	// IsError 判断请求是否失败，为空时状态码为 5xx 的请求为失败
+	IsError func(c *Context) bool
+	// Window 计算消耗速率的滑动窗口，默认为 5 分钟
+	Window time.Duration
+	// BurnRate 消耗速率的告警阈值，即窗口内的不达标比例是错误预算的多少倍，默认为 14.4
+	// （按该速率 30 天的错误预算在 2 天内耗尽）
+	BurnRate float64
+	// MinRequests 窗口内的请求数达到 MinRequests 后才判断消耗速率，避免少量请求造成误判，默认为 100
+	MinRequests int64
+	// OnBurn 消耗速率超过阈值时调用一次，可以在这里开启负载保护、降级等
+	OnBurn func(status SLOStatus)
+	// OnRecover 消耗速率回到阈值以下时调用一次
+	OnRecover func(status SLOStatus)
+}
+
+// SLOStatus 滑动窗口内的 SLO 指标
+type SLOStatus struct {
+	Name        string  `json:"name"`
+	Objective   float64 `json:"objective"`
+	Requests    int64   `json:"requests"`     // 窗口内的请求数
+	Bad         int64   `json:"bad"`          // 窗口内不达标（失败或过慢）的请求数
+	SuccessRate float64 `json:"success_rate"` // 达标比例，没有请求时为 1
+	BurnRate    float64 `json:"burn_rate"`    // 不达标比例与错误预算之比，1 表示恰好在窗口内用完预算
+	Burning     bool    `json:"burning"`      // 消耗速率是否超过阈值
+}
+
+// sloBuckets 滑动窗口划分的桶数
+const sloBuckets = 10
+
+// sloBucket 滑动窗口中的一个桶
+type sloBucket struct {
+	start    int64 // 桶的开始时间（纳秒），不在窗口内的桶视为空
+	requests int64
+	bad      int64
+}
+
+// SLOTracker 按 SLOConfig 统计请求的达标比例和错误预算的消耗速率
+type SLOTracker struct {
+	config  SLOConfig
+	mu      sync.Mutex
+	buckets [sloBuckets]sloBucket
+	burning bool
+}
+
+// NewSLOTracker 是 zinc.SLOTracker 的构造函数，通过 Middleware 方法挂载到需要统计的分组上：
+//
+//	api := engine.Group("/api")
+//	slo := zinc.NewSLOTracker(zinc.SLOConfig{Name: "api", Objective: 0.999, Latency: 300 * time.Millisecond,
+//		OnBurn: func(zinc.SLOStatus) { atomic.StoreInt32(&shedLowPriority, 1) }})
+//	api.Use(slo.Middleware())
+//	engine.GET("/metrics/slo", func(c *zinc.Context) { c.JSON(http.StatusOK, slo.Status()) })
+func NewSLOTracker(config SLOConfig) *SLOTracker {
+	if config.Window <= 0 {
+		config.Window = 5 * time.Minute
+	}
+	if config.BurnRate <= 0 {
+		config.BurnRate = 14.4
+	}
+	if config.MinRequests <= 0 {
+		config.MinRequests = 100
+	}
+	if config.IsError == nil {
+		config.IsError = func(c *Context) bool {
+			return c.StatusCode >= 500
+		}
+	}
+	return &SLOTracker{config: config}
+}
+
+// Middleware 方法返回统计请求的中间件，在后面的处理函数返回后记录结果，消耗速率越过阈值时调用 OnBurn 或 OnRecover
+func (s *SLOTracker) Middleware() HandlerFunc {
+	return func(c *Context) {
+		start := time.Now()
+		c.Next()
+		elapsed := time.Since(start)
+		bad := s.config.IsError(c) || (s.config.Latency > 0 && elapsed > s.config.Latency)
+		s.record(start.Add(elapsed), bad)
+	}
+}
+
+// record 方法记录一个请求，并在消耗速率越过阈值时调用回调
+func (s *SLOTracker) record(now time.Time, bad bool) {
+	s.mu.Lock()
+	width := int64(s.config.Window) / sloBuckets
+	slot := now.UnixNano() / width
+	bucket := &s.buckets[slot%sloBuckets]
+	if bucket.start != slot*width {
+		*bucket = sloBucket{start: slot * width}
+	}
+	bucket.requests++
+	if bad {
+		bucket.bad++
+	}
+	status := s.statusLocked(now)
+	changed := status.Burning != s.burning
+	s.burning = status.Burning
+	s.mu.Unlock()
+
+	// 回调在锁外执行，回调中可以调用 Status
+	if changed && status.Burning && s.config.OnBurn != nil {
+		s.config.OnBurn(status)
+	}
+	if changed && !status.Burning && s.config.OnRecover != nil {
+		s.config.OnRecover(status)
+	}
+}
+
+// Status 方法返回当前滑动窗口内的指标
+func (s *SLOTracker) Status() SLOStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.statusLocked(time.Now())
+}
+
+// Burning 方法返回错误预算的消耗速率是否超过阈值，可用于 LoadShedConfig.Classify 等按 SLO 调整的逻辑
+func (s *SLOTracker) Burning() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.burning
+}
+
+// statusLocked 方法汇总窗口内的桶，调用时需要持有 mu
+func (s *SLOTracker) statusLocked(now time.Time) SLOStatus {
+	status := SLOStatus{Name: s.config.Name, Objective: s.config.Objective, SuccessRate: 1}
+	oldest := now.UnixNano() - int64(s.config.Window)
+	for _, bucket := range s.buckets {
+		if bucket.start > oldest {
+			status.Requests += bucket.requests
+			status.Bad += bucket.bad
+		}
+	}
+	if status.Requests > 0 {
+		badRate := float64(status.Bad) / float64(status.Requests)
+		status.SuccessRate = 1 - badRate
+		if budget := 1 - s.config.Objective; budget > 0 {
+			status.BurnRate = badRate / budget
+		}
+	}
+	status.Burning = status.Requests >= s.config.MinRequests && status.BurnRate >= s.config.BurnRate
+	return status
+}
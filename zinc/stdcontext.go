@@ -0,0 +1,52 @@
+package zinc
+
+import (
+	"context"
+	"time"
+)
+
+// Context 实现了 context.Context 接口，Deadline、Done、Err、Value 方法都由请求的 context（c.Req.Context()）提供，
+// 所以可以把 c 直接传给数据库、RPC 等调用，客户端断开连接或超时时这些调用会被取消：
+//
+//	rows, err := db.QueryContext(c, query)
+//
+// Context 在请求结束后会被复用，需要在请求结束后继续使用时应当传入 c.Req.Context() 而不是 c。
+var _ context.Context = (*Context)(nil)
+
+// Deadline 方法返回请求的截止时间
+func (c *Context) Deadline() (deadline time.Time, ok bool) {
+	if c.Req == nil {
+		return time.Time{}, false
+	}
+	return c.Req.Context().Deadline()
+}
+
+// Done 方法返回请求被取消或超时时关闭的 channel
+func (c *Context) Done() <-chan struct{} {
+	if c.Req == nil {
+		return nil
+	}
+	return c.Req.Context().Done()
+}
+
+// Err 方法返回请求被取消或超时的原因，请求仍在进行时返回 nil
+func (c *Context) Err() error {
+	if c.Req == nil {
+		return nil
+	}
+	return c.Req.Context().Err()
+}
+
+// Value 方法返回请求的 context 中键为 key 的值；
+// 开启 Engine.ContextWithKeys 时，字符串键先查找 c.Keys 中的数据
+func (c *Context) Value(key interface{}) interface{} {
+	if name, ok := key.(string); ok && c.engine != nil && c.engine.ContextWithKeys {
+		if value, exists := c.Get(name); exists {
+			return value
+		}
+	}
+	if c.Req == nil {
+		return nil
+	}
+	return c.Req.Context().Value(key)
+}
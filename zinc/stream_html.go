@@ -0,0 +1,108 @@
+package zinc
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// 流式渲染的刷新策略：缓冲区满或距上次刷新超过间隔时将已渲染的内容发送给客户端
+const (
+	streamBufferSize    = 32 << 10
+	streamFlushInterval = 200 * time.Millisecond
+)
+
+// HTMLStream 方法流式渲染模板 name，用于数 MB 的报表等超大页面：
+// 先发送状态码和关键头部（Content-Type，去掉 Content-Length），之后模板的输出每 32KB 或每 200ms 发送一次，
+// 内存占用不随页面大小增长。
+//
+// 状态码已经发送，模板执行出错（包括模板函数 panic）时无法再改为错误响应：
+// 错误记录到 c.Errors 并中止处理函数链，已发送的内容保留，之后以 http.ErrAbortHandler panic，
+// 由 net/http 直接断开连接，客户端不会把截断的页面当作完整的响应。
+// 发送状态码之前的错误由 HTMLStream 返回。
+func (c *Context) HTMLStream(code int, name string, data interface{}) (err error) {
+	if err := c.Req.Context().Err(); err != nil {
+		c.Fail(http.StatusServiceUnavailable, err.Error())
+		return err
+	}
+	templates, release, err := c.templates()
+	if err != nil {
+		c.Fail(http.StatusInternalServerError, err.Error())
+		return err
+	}
+	defer release()
+
+	c.template = name
+	// 先提交状态码和头部，之后的错误不会产生状态码与内容不一致的响应
+	header := c.Writer.Header()
+	// 流式输出无法事后让浏览器重新判断编码，没有设置字符集时固定为 utf-8
+	if c.responseCharset() != "" {
+		header.Set("Content-Type", c.contentType("text/html"))
+	} else {
+		header.Set("Content-Type", "text/html; charset=utf-8")
+	}
+	header.Del("Content-Length")
+	c.Status(code)
+	flusher, _ := c.Writer.(http.Flusher)
+	if flusher != nil {
+		flusher.Flush()
+	}
+
+	w := &streamWriter{flusher: flusher, last: time.Now()}
+	w.buf = bufio.NewWriterSize(flushingWriter{w: c.Writer, flusher: flusher}, streamBufferSize)
+	// 模板执行是否失败，失败时在发送已渲染的内容后断开连接
+	failed := true
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("zinc: template %s panicked: %v", name, p)
+		}
+		if flushErr := w.buf.Flush(); err == nil {
+			err = flushErr
+		}
+		if err != nil {
+			log.Printf("stream %s: %v", name, err)
+			c.Error(err)
+			c.Abort()
+		}
+		if failed {
+			panic(http.ErrAbortHandler)
+		}
+	}()
+	if err := templates.ExecuteTemplate(w, name, data); err != nil {
+		return err
+	}
+	failed = false
+	return nil
+}
+
+// streamWriter 流式渲染的输出，写入缓冲区，距上次刷新超过 streamFlushInterval 时刷新
+type streamWriter struct {
+	buf     *bufio.Writer
+	flusher http.Flusher
+	last    time.Time
+}
+
+func (w *streamWriter) Write(data []byte) (int, error) {
+	n, err := w.buf.Write(data)
+	if err == nil && time.Since(w.last) >= streamFlushInterval {
+		err = w.buf.Flush()
+		w.last = time.Now()
+	}
+	return n, err
+}
+
+// flushingWriter 每次写入后都刷新到客户端的 Writer，由 streamWriter 的缓冲区批量写入
+type flushingWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+func (w flushingWriter) Write(data []byte) (int, error) {
+	n, err := w.w.Write(data)
+	if err == nil && w.flusher != nil {
+		w.flusher.Flush()
+	}
+	return n, err
+}
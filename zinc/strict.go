@@ -0,0 +1,117 @@
+package zinc
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// StrictConfig 请求走私防护（严格模式）的配置，用于没有经过加固的反向代理、直接暴露在公网上的部署。
+// 头部大小和数量的上限由 Engine 的 MaxHeaderBytes 和 MaxHeaderCount 设置，在 Pre 处理函数之前检查。
+type StrictConfig struct {
+	// Counters 非空时记录各类被拒绝请求的数量，可用于上报指标
+	Counters *StrictCounters
+}
+
+// StrictCounters 严格模式拒绝请求的计数器，字段需要通过 Load 方法读取
+type StrictCounters struct {
+	ConflictingLength     int64 // 同时带有 Transfer-Encoding 和 Content-Length，或多个不一致的 Content-Length
+	UnknownTransferCoding int64 // 带有 chunked 以外的传输编码
+	MalformedHeader       int64 // 头部名称或值中含有非法字符
+}
+
+// Load 方法返回计数器当前值的副本
+func (s *StrictCounters) Load() StrictCounters {
+	return StrictCounters{
+		ConflictingLength:     atomic.LoadInt64(&s.ConflictingLength),
+		UnknownTransferCoding: atomic.LoadInt64(&s.UnknownTransferCoding),
+		MalformedHeader:       atomic.LoadInt64(&s.MalformedHeader),
+	}
+}
+
+// StrictRequests 是请求走私防护的构造函数，应当通过 engine.Pre 注册，在路由匹配之前检查请求：
+// 拒绝同时带有 Transfer-Encoding 和 Content-Length（或多个不一致的 Content-Length）的请求、
+// 使用未知传输编码的请求，以及头部含有非法字符的请求。被拒绝的请求会关闭连接。
+//
+// 如：
+//
+//	engine.MaxHeaderBytes = 8 << 10
+//	engine.Pre(zinc.StrictRequests(zinc.StrictConfig{Counters: counters}))
+func StrictRequests(config StrictConfig) HandlerFunc {
+	counters := config.Counters
+	if counters == nil {
+		counters = &StrictCounters{}
+	}
+	reject := func(c *Context, counter *int64, code int, message string) {
+		atomic.AddInt64(counter, 1)
+		// 请求体的边界不可信，不能复用连接
+		c.SetHeader("Connection", "close")
+		c.Fail(code, message)
+	}
+
+	return func(c *Context) {
+		header := c.Req.Header
+		for key, values := range header {
+			if !validHeaderName(key) {
+				reject(c, &counters.MalformedHeader, http.StatusBadRequest, "Bad Request: malformed header")
+				return
+			}
+			for _, value := range values {
+				if strings.ContainsAny(value, "\r\n\x00") {
+					reject(c, &counters.MalformedHeader, http.StatusBadRequest, "Bad Request: malformed header")
+					return
+				}
+			}
+		}
+
+		codings := transferCodings(c.Req)
+		for _, coding := range codings {
+			if !strings.EqualFold(coding, "chunked") {
+				reject(c, &counters.UnknownTransferCoding, http.StatusNotImplemented, "Not Implemented: unknown transfer coding")
+				return
+			}
+		}
+		lengths := header["Content-Length"]
+		if len(lengths) > 0 && len(codings) > 0 {
+			reject(c, &counters.ConflictingLength, http.StatusBadRequest, "Bad Request: conflicting Transfer-Encoding and Content-Length")
+			return
+		}
+		for _, length := range lengths {
+			if strings.TrimSpace(length) != strings.TrimSpace(lengths[0]) {
+				reject(c, &counters.ConflictingLength, http.StatusBadRequest, "Bad Request: conflicting Content-Length")
+				return
+			}
+		}
+	}
+}
+
+// transferCodings 返回请求声明的全部传输编码。
+// net/http 解析请求时会把 Transfer-Encoding 从头部移到 Request.TransferEncoding，两处都需要检查。
+func transferCodings(req *http.Request) []string {
+	codings := append([]string(nil), req.TransferEncoding...)
+	for _, value := range req.Header["Transfer-Encoding"] {
+		for _, coding := range strings.Split(value, ",") {
+			if coding = strings.TrimSpace(coding); coding != "" {
+				codings = append(codings, coding)
+			}
+		}
+	}
+	return codings
+}
+
+// validHeaderName 判断头部名称是否是合法的 token（RFC 7230 3.2.6）
+func validHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		ch := name[i]
+		switch {
+		case ch >= 'a' && ch <= 'z', ch >= 'A' && ch <= 'Z', ch >= '0' && ch <= '9':
+		case strings.IndexByte("!#$%&'*+-.^_`|~", ch) >= 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,113 @@
+package zinc
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"reflect"
+	"sync"
+)
+
+// contextType 是 *Context 的反射类型
+var contextType = reflect.TypeOf((*Context)(nil))
+
+// SetContextFuncMap 方法设置需要访问当前请求的模板函数，函数的第一个参数必须是 *Context，
+// 渲染时自动传入当前的 Context，模板中调用时省略该参数：
+//
+//	engine.SetContextFuncMap(template.FuncMap{
+//		"currentUser": func(c *zinc.Context) *User { return c.MustGet("user").(*User) },
+//		"hasPerm":     func(c *zinc.Context, perm string) bool { ... },
+//	})
+//	engine.LoadHTMLGlob("templates/*")
+//
+//	{{ if hasPerm "admin" }}{{ currentUser.Name }}{{ end }}
+//
+// 与 SetFuncMap 一样需要在 LoadHTMLGlob 之前调用。绑定了模板函数的模板集副本由对象池复用，渲染时只替换 Context。
+func (engine *Engine) SetContextFuncMap(funcMap template.FuncMap) {
+	for name, fn := range funcMap {
+		t := reflect.TypeOf(fn)
+		if t == nil || t.Kind() != reflect.Func || t.NumIn() == 0 || t.In(0) != contextType {
+			panic(fmt.Sprintf("zinc: context template func %q must take *zinc.Context as its first parameter", name))
+		}
+	}
+	engine.contextFuncs = funcMap
+}
+
+// contextFuncPlaceholders 方法返回解析模板时使用的占位函数，签名为去掉 *Context 参数后的函数，渲染时被替换
+func (engine *Engine) contextFuncPlaceholders() template.FuncMap {
+	funcs := make(template.FuncMap, len(engine.contextFuncs))
+	for name, fn := range engine.contextFuncs {
+		name := name
+		funcs[name] = reflect.MakeFunc(boundFuncType(reflect.TypeOf(fn)), func([]reflect.Value) []reflect.Value {
+			panic(fmt.Sprintf("zinc: context template func %q called outside of c.HTML", name))
+		}).Interface()
+	}
+	return funcs
+}
+
+// boundFuncType 返回函数类型 t 去掉第一个参数后的类型
+func boundFuncType(t reflect.Type) reflect.Type {
+	in := make([]reflect.Type, 0, t.NumIn()-1)
+	for i := 1; i < t.NumIn(); i++ {
+		in = append(in, t.In(i))
+	}
+	out := make([]reflect.Type, 0, t.NumOut())
+	for i := 0; i < t.NumOut(); i++ {
+		out = append(out, t.Out(i))
+	}
+	return reflect.FuncOf(in, out, t.IsVariadic())
+}
+
+// boundTemplates 绑定了模板函数的模板集副本，模板函数调用时传入 c
+type boundTemplates struct {
+	t *template.Template
+	c *Context // 当前使用副本渲染的 Context
+}
+
+// newBoundTemplatePool 返回复用 master 副本的对象池：副本在创建时复制一次并绑定模板函数，
+// 之后每次渲染只替换当前的 Context。master 本身从不执行（html/template 执行过的模板不能再复制）
+func newBoundTemplatePool(master *template.Template, funcMap template.FuncMap) *sync.Pool {
+	return &sync.Pool{New: func() interface{} {
+		b := &boundTemplates{}
+		b.t = template.Must(master.Clone()).Funcs(bindContextFuncs(b, funcMap))
+		return b
+	}}
+}
+
+// bindContextFuncs 返回将 b 当前的 Context 作为第一个参数的模板函数
+func bindContextFuncs(b *boundTemplates, funcMap template.FuncMap) template.FuncMap {
+	funcs := make(template.FuncMap, len(funcMap))
+	for name, fn := range funcMap {
+		fv := reflect.ValueOf(fn)
+		t := fv.Type()
+		funcs[name] = reflect.MakeFunc(boundFuncType(t), func(args []reflect.Value) []reflect.Value {
+			args = append([]reflect.Value{reflect.ValueOf(b.c)}, args...)
+			if t.IsVariadic() {
+				return fv.CallSlice(args)
+			}
+			return fv.Call(args)
+		}).Interface()
+	}
+	return funcs
+}
+
+// errNoTemplates 没有加载模板时渲染返回的错误
+var errNoTemplates = errors.New("zinc: no HTML templates loaded, call LoadHTMLGlob first")
+
+// templates 方法返回渲染当前请求使用的模板集，渲染结束后需要调用 release：
+// 设置了 SetContextFuncMap 时从对象池取出绑定了模板函数的副本，模板函数使用 c
+func (c *Context) templates() (t *template.Template, release func(), err error) {
+	if c.engine.htmlTemplates == nil {
+		return nil, nil, errNoTemplates
+	}
+	pool := c.engine.boundTemplates
+	if pool == nil {
+		return c.engine.htmlTemplates, func() {}, nil
+	}
+	b := pool.Get().(*boundTemplates)
+	b.c = c
+	return b.t, func() {
+		b.c = nil
+		pool.Put(b)
+	}, nil
+}
@@ -0,0 +1,241 @@
+package zinc
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// WithTimeout 方法为当前请求设置时间预算 d，返回派生的 context 和取消函数。
+// 派生的 context 会替换 c.Req 的 context，之后的 c.HTTPClient 调用、渲染等都会遵守该截止时间；
+// 已有更早的截止时间时以更早的为准。
+func (c *Context) WithTimeout(d time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(c.Req.Context(), d)
+	c.Req = c.Req.WithContext(ctx)
+	return ctx, cancel
+}
+
+// Remaining 方法返回当前请求剩余的时间预算，没有设置截止时间时 ok 为 false
+func (c *Context) Remaining() (remaining time.Duration, ok bool) {
+	deadline, ok := c.Req.Context().Deadline()
+	if !ok {
+		return 0, false
+	}
+	return time.Until(deadline), true
+}
+
+// HasBudget 方法判断剩余的时间预算是否还够 d，没有设置截止时间时总是返回 true。
+// Handler 可以据此跳过耗时的步骤，返回部分结果而不是超时。
+func (c *Context) HasBudget(d time.Duration) bool {
+	remaining, ok := c.Remaining()
+	return !ok || remaining >= d
+}
+
+// fork 方法复制一个使用 w 作为 Writer 的 Context，用于在另一个 goroutine 中执行后面的处理函数。
+// Params 会被复制，当前 Context 放回对象池后副本仍然可用。
+func (c *Context) fork(w http.ResponseWriter) *Context {
+	c.mu.Lock()
+	keys := make(map[string]interface{}, len(c.Keys))
+	for key, value := range c.Keys {
+		keys[key] = value
+	}
+	errs := append([]error(nil), c.Errors...)
+	c.mu.Unlock()
+	return &Context{
+		Keys:          keys,
+		Errors:        errs,
+		Writer:        w,
+		Req:           c.Req,
+		Method:        c.Method,
+		Path:          c.Path,
+		Params:        append(Params(nil), c.Params...),
+		StatusCode:    c.StatusCode,
+		handlers:      c.handlers,
+		index:         c.index,
+		engine:        c.engine,
+		envelope:      c.envelope,
+		noCompress:    c.noCompress,
+		route:         c.route,
+		body:          c.body,
+		bodyBuffered:  c.bodyBuffered,
+		charset:       c.charset,
+		sameSite:      c.sameSite,
+		errorHandlers: c.errorHandlers,
+	}
+}
+
+// defaultMaxBackground TimeoutConfig.MaxBackground 的默认值
+const defaultMaxBackground = 100
+
+// HeaderRetrySafe GET、HEAD 请求超时返回 504 时附加的头部，提示客户端重试是安全的（请求是幂等的）
+const HeaderRetrySafe = "X-Retry-Safe"
+
+// TimeoutConfig 超时中间件的配置
+type TimeoutConfig struct {
+	// Timeout 处理函数的时间预算
+	Timeout time.Duration
+	// GatewayTimeout 为 true 时，GET、HEAD 请求超时以 504 状态码响应，并附加 Retry-After 和 X-Retry-Safe 头部；
+	// 其他请求仍以 503 状态码响应
+	GatewayTimeout bool
+	// RetryAfter 504 响应的 Retry-After，默认为 1 秒
+	RetryAfter time.Duration
+	// Store 非空时，GET、HEAD 请求超时后处理函数继续在后台执行（最长 Grace），
+	// 成功（2xx）的结果按请求方法和地址缓存 CacheTTL，客户端重试时直接返回，改善下游不稳定时的长尾延迟。
+	// 带有 Authorization 或 Cookie 的请求结果因人而异，不读写缓存，超时后处理函数也不在后台继续执行
+	Store ResponseStore
+	// Grace 超时后处理函数继续执行的时间上限，默认等于 Timeout
+	Grace time.Duration
+	// CacheTTL 后台完成的结果的缓存时长，默认为 30 秒
+	CacheTTL time.Duration
+	// MaxBackground 同时在后台继续执行的处理函数的上限，默认为 100；达到上限后超时的处理函数立即取消，结果不缓存
+	MaxBackground int
+}
+
+// Timeout 是超时中间件的构造函数。
+// 后面的处理函数在时间预算 d 内没有完成时，中间件立即以 503 状态码响应，
+// 处理函数的输出会被丢弃；处理函数可以通过 c.Req.Context() 感知超时并提前返回。
+// 超时之后处理函数中发生的 panic 会打印在日志中并交给 Engine.PanicReporter。
+func Timeout(d time.Duration) HandlerFunc {
+	return TimeoutWith(TimeoutConfig{Timeout: d})
+}
+
+// TimeoutWith 是可配置的超时中间件的构造函数，见 TimeoutConfig
+func TimeoutWith(config TimeoutConfig) HandlerFunc {
+	if config.RetryAfter <= 0 {
+		config.RetryAfter = time.Second
+	}
+	if config.Grace <= 0 {
+		config.Grace = config.Timeout
+	}
+	if config.CacheTTL <= 0 {
+		config.CacheTTL = 30 * time.Second
+	}
+	if config.MaxBackground <= 0 {
+		config.MaxBackground = defaultMaxBackground
+	}
+	// 正在后台继续执行的处理函数个数
+	var background int32
+	return func(c *Context) {
+		idempotent := c.Method == http.MethodGet || c.Method == http.MethodHead
+		// HEAD 的结果没有响应体，与 GET 分开缓存
+		key := c.Method + " " + c.Req.URL.RequestURI()
+		cacheable := idempotent && config.Store != nil && !hasCredentials(c.Req)
+		if cacheable {
+			if resp, ok := config.Store.Get(key); ok && resp.age() < resp.MaxAge {
+				c.Abort()
+				writeCachedResponse(c, resp)
+				return
+			}
+		}
+
+		// 结果需要缓存时，处理函数的 context 在超时后继续有效 Grace
+		keepRunning := cacheable
+		limit := config.Timeout
+		if keepRunning {
+			limit += config.Grace
+		}
+		ctx, cancel := c.WithTimeout(limit)
+		if !keepRunning {
+			defer cancel()
+		}
+		timer := time.NewTimer(config.Timeout)
+		defer timer.Stop()
+
+		// 后面的处理函数在副本上执行，输出（包括头部）先写入缓冲区，超时后不再与当前 goroutine 共享
+		buffer := &bufferedWriter{ResponseWriter: c.Writer, header: make(http.Header)}
+		forked := c.fork(buffer)
+		// 超时返回时关闭，通知后台完成的处理函数缓存结果
+		abandoned := make(chan struct{})
+		// 超时后是否占用了后台执行的名额，在关闭 abandoned 之前设置
+		detached := false
+		// 处理函数结束时传回 panic 的值，没有 panic 时为 nil
+		finished := make(chan interface{}, 1)
+		go func() {
+			defer func() {
+				// 副本上调用过 c.Draining 的长连接在这里结束
+				c.engine.releaseStream(forked)
+				p := recover()
+				if p != nil && p != http.ErrAbortHandler {
+					select {
+					case <-abandoned:
+						// 超时返回后没有人再接收 panic，在这里记录并上报
+						reportPanic(forked, p, trace(panicMessage(p)), SeverityError)
+					default:
+					}
+				}
+				if keepRunning {
+					select {
+					case <-abandoned:
+						if detached {
+							atomic.AddInt32(&background, -1)
+						}
+						if detached && p == nil && buffer.status >= 200 && buffer.status < 300 {
+							config.Store.Set(key, &CachedResponse{
+								Status: buffer.status,
+								Header: buffer.header,
+								Body:   buffer.body.Bytes(),
+								Stored: time.Now(),
+								MaxAge: config.CacheTTL,
+							})
+						}
+					default:
+					}
+				}
+				finished <- p
+				if keepRunning {
+					// 先传回结果再取消，避免等待方先看到 ctx.Done 而误判为超时
+					cancel()
+				}
+			}()
+			forked.Next()
+		}()
+
+		select {
+		case p := <-finished:
+			if p != nil {
+				// 在当前 goroutine 中重新 panic，交由 Recovery 处理
+				panic(p)
+			}
+			c.index = forked.index
+			c.mu.Lock()
+			c.Errors = forked.Errors
+			c.mu.Unlock()
+			c.StatusCode = forked.StatusCode
+			buffer.flush(buffer.body.Bytes())
+			return
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+		if keepRunning {
+			if atomic.AddInt32(&background, 1) <= int32(config.MaxBackground) {
+				detached = true
+			} else {
+				// 后台名额已满，立即取消处理函数
+				atomic.AddInt32(&background, -1)
+				cancel()
+			}
+		}
+		close(abandoned)
+		if idempotent && config.GatewayTimeout {
+			c.SetHeader("Retry-After", strconv.Itoa(int(math.Ceil(config.RetryAfter.Seconds()))))
+			c.SetHeader(HeaderRetrySafe, "true")
+			c.Fail(http.StatusGatewayTimeout, "Gateway Timeout: request timeout")
+			return
+		}
+		c.Fail(http.StatusServiceUnavailable, "Service Unavailable: request timeout")
+	}
+}
+
+// writeCachedResponse 将缓存的响应写给客户端，X-Cache 头部为 HIT
+func writeCachedResponse(c *Context, resp *CachedResponse) {
+	header := c.Writer.Header()
+	for key, values := range resp.Header {
+		header[key] = append([]string(nil), values...)
+	}
+	header.Set("X-Cache", "HIT")
+	c.Status(resp.Status)
+	c.Writer.Write(resp.Body)
+}
@@ -2,95 +2,501 @@ package zinc
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 )
 
-// node 前缀树节点
+// nodeKind 节点类型
+type nodeKind uint8
+
+const (
+	static   nodeKind = iota // 静态节点，path为压缩后的路径片段，如"/hello/"
+	param                    // 参数节点，path为":name"或带约束的":id<int>"
+	catchAll                 // 通配节点，path为"*filepath"
+)
+
+// node 压缩前缀树（radix tree）节点。
+// 静态路径按字节压缩存储，公共前缀只存一份；参数和通配符各自占用一个节点，
+// 如 /hello/:name 和 /help 组成的树为：
+//
+//	/hel
+//	├── lo/
+//	│   └── :name
+//	└── p
 type node struct {
-	pattern 	string	// 要么是一个完整的url，要么是一个空字符串
-	part    	string	// URL块值，用/分割的部分，比如/abc/123中，abc和123就是2个part
-	children 	[]*node	// 当前节点下的子节点
-	isWild		bool	// 是否模糊匹配，比如:filename或*filename这样的node就为true
+	pattern      string         // 要么是一个完整的url（注册时的pattern），要么是一个空字符串
+	path         string         // 静态节点为压缩后的路径片段；参数节点和通配节点为":name"、"*filepath"这样的part
+	kind         nodeKind       // 节点类型
+	indices      string         // 静态子节点path的首字节，与children一一对应，用于快速定位子节点
+	children     []*node        // 静态子节点
+	wildChildren []*node        // 参数子节点，带约束的节点排在普通参数节点之前
+	catchAll     *node          // 通配子节点
+	matcher      *regexp.Regexp // 参数约束，比如:id<int>这样的node只匹配满足约束的part
+	handlers     []HandlerFunc  // 完整url对应的处理函数链（中间件+Handler），在注册路由时计算
+	paramNames   []string       // 完整url中依次出现的参数名，与查找时收集的参数值一一对应
+	route        *Route         // 完整url对应的已注册路由
+}
+
+// constraints 是内置的具名参数约束
+var constraints = map[string]string{
+	"int":   `-?[0-9]+`,
+	"uint":  `[0-9]+`,
+	"alpha": `[a-zA-Z]+`,
+	"alnum": `[a-zA-Z0-9]+`,
+	"uuid":  `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+}
+
+// splitConstraint 将形如`:id<int>`的part拆分为参数名`id`和约束`int`，没有约束时 expr 为空字符串
+func splitConstraint(part string) (name string, expr string) {
+	name = part[1:]
+	if i := strings.IndexByte(name, '<'); i >= 0 && strings.HasSuffix(name, ">") {
+		return name[:i], name[i+1 : len(name)-1]
+	}
+	return name, ""
+}
+
+// paramName 返回动态路由part中的参数名，如`:id<int>`返回`id`，`*filepath`返回`filepath`
+func paramName(part string) string {
+	name, _ := splitConstraint(part)
+	return name
+}
+
+// compileConstraint 将约束编译为整段匹配的正则，约束可以是内置的具名约束或任意正则表达式
+func compileConstraint(expr string) *regexp.Regexp {
+	if named, ok := constraints[expr]; ok {
+		expr = named
+	}
+	re, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		panic(fmt.Sprintf("zinc: invalid constraint <%s>: %v", expr, err))
+	}
+	return re
+}
+
+// newNode 根据part创建新的参数节点或通配节点，解析动态路由的参数约束
+func newNode(part string) *node {
+	n := &node{path: part, kind: param}
+	if part[0] == '*' {
+		n.kind = catchAll
+	}
+	if part[0] == ':' {
+		if _, expr := splitConstraint(part); expr != "" {
+			n.matcher = compileConstraint(expr)
+		}
+	}
+	return n
+}
+
+// constraintOf 返回part的参数约束，静态part和没有约束的动态part返回空字符串
+func constraintOf(part string) string {
+	if part[0] != ':' {
+		return ""
+	}
+	_, expr := splitConstraint(part)
+	return expr
 }
 
 func (n *node) String() string {
-	return fmt.Sprintf("node{pattern=%s, part=%s, isWild=%t}", n.pattern, n.part, n.isWild)
+	return fmt.Sprintf("node{pattern=%s, path=%s, kind=%d}", n.pattern, n.path, n.kind)
 }
 
-// matchChild 方法返回第一个匹配成功的节点，用于insert插入方法中
-func (n *node) matchChild(part string) *node {
-	for _, child := range n.children {
-		// 修改点：动态匹配做强校验,防止路由注册时被覆盖
-		if child.part == part || ((part[0] == ':' || part[0] == '*') && child.isWild) {
-			return child
+// normalizePattern 返回规范化的路径：以`/`开头，去掉重复和末尾的`/`，`*`之后的部分被忽略
+func normalizePattern(pattern string) string {
+	return "/" + strings.Join(parsePattern(pattern), "/")
+}
+
+// cleanSearchPath 返回用于查找的规范化路径。
+// 只有路径中含有重复或末尾的`/`时才需要重新拼接，常见的路径直接返回，不产生内存分配。
+func cleanSearchPath(path string) string {
+	if path == "" || path[0] != '/' || strings.Contains(path, "//") || (len(path) > 1 && path[len(path)-1] == '/') {
+		return "/" + strings.Join(strings.FieldsFunc(path, func(r rune) bool { return r == '/' }), "/")
+	}
+	return path
+}
+
+// wildcardIndex 返回path中第一个参数或通配符（位于part开头的`:`或`*`）的下标，没有时返回-1
+func wildcardIndex(path string) int {
+	for i := 0; i < len(path); i++ {
+		if (path[i] == ':' || path[i] == '*') && (i == 0 || path[i-1] == '/') {
+			return i
 		}
 	}
+	return -1
+}
+
+// segmentEnd 返回path中第一个part的结束位置
+func segmentEnd(path string) int {
+	if end := strings.IndexByte(path, '/'); end >= 0 {
+		return end
+	}
+	return len(path)
+}
+
+// commonPrefix 返回a和b最长公共前缀的长度
+func commonPrefix(a string, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+// validatePattern 检查路由地址，返回的错误指明不合法的part：
+// 参数名为空（如`/:`、`/:<int>`）、约束缺少`>`或不是合法的正则、同一路由中参数名重复、通配符之后还有路径（如`/a/*x/b`）
+func validatePattern(pattern string) error {
+	seen := make(map[string]bool)
+	segments := strings.Split(pattern, "/")
+	for index, segment := range segments {
+		if segment == "" || (segment[0] != ':' && segment[0] != '*') {
+			continue
+		}
+		if segment[0] == '*' {
+			if rest := strings.Join(segments[index+1:], ""); rest != "" {
+				return fmt.Errorf("catch-all %q must be the last segment", segment)
+			}
+		}
+		name, expr := splitConstraint(segment)
+		if segment[0] == ':' {
+			if strings.IndexByte(segment, '<') >= 0 && !strings.HasSuffix(segment, ">") {
+				return fmt.Errorf("unterminated constraint in %q", segment)
+			}
+			if name == "" {
+				return fmt.Errorf("empty parameter name in %q", segment)
+			}
+			if expr != "" {
+				if named, ok := constraints[expr]; ok {
+					expr = named
+				}
+				if _, err := regexp.Compile(expr); err != nil {
+					return fmt.Errorf("invalid constraint in %q: %v", segment, err)
+				}
+			}
+		}
+		if name != "" && seen[name] {
+			return fmt.Errorf("duplicate parameter name %q in %q", name, segment)
+		}
+		seen[name] = true
+	}
 	return nil
 }
 
-// matchChildren 方法返回所有匹配成功的节点，用于search查找方法中
-func (n *node) matchChildren(part string) []*node {
-	nodes := make([]*node, 0)
-	wildNodes := make([]*node, 0)
-	for _, child := range n.children {
-		// 修改点：静态路由节点优先,动态路由节点延后
-		if child.part == part {
-			nodes = append(nodes, child)
-		} else if child.isWild {
-			wildNodes = append(wildNodes, child)
+// wildcardConflict 比较 pattern 和已注册的 existing，两者会注册到同一节点时 ok 为true，
+// part 和 other 为第一个名称不同的参数或通配符，如 /hello/:id 和 /hello/:name 返回 :id 和 :name
+func wildcardConflict(pattern string, existing string) (part string, other string, ok bool) {
+	parts, others := parsePattern(pattern), parsePattern(existing)
+	if len(parts) != len(others) {
+		return "", "", false
+	}
+	for i := range parts {
+		a, b := parts[i], others[i]
+		switch {
+		case a[0] == ':' && b[0] == ':':
+			if constraintOf(a) != constraintOf(b) {
+				return "", "", false
+			}
+		case a[0] == '*' && b[0] == '*':
+		case a != b:
+			return "", "", false
+		}
+		if part == "" && a != b {
+			part, other = a, b
+		}
+	}
+	return part, other, true
+}
+
+// patternParams 返回pattern中依次出现的参数名，如/p/:lang/*filepath返回[lang filepath]
+func patternParams(pattern string) []string {
+	names := make([]string, 0)
+	for _, part := range parsePattern(pattern) {
+		if part[0] == ':' || part[0] == '*' {
+			names = append(names, paramName(part))
+		}
+	}
+	return names
+}
+
+// insert 方法插入pattern，返回pattern对应的node。
+// 静态部分按字节与已有节点合并公共前缀，必要时拆分节点；参数和通配符插入到对应的子节点。
+func (n *node) insert(pattern string) *node {
+	path := normalizePattern(pattern)
+	current := n
+	for len(path) > 0 {
+		i := wildcardIndex(path)
+		if i != 0 {
+			// 插入静态部分
+			staticPath := path
+			if i > 0 {
+				staticPath = path[:i]
+			}
+			current = current.insertStatic(staticPath)
+			path = path[len(staticPath):]
+			continue
+		}
+		// 插入参数或通配符，通配符总是位于末尾
+		end := segmentEnd(path)
+		if path[0] == '*' {
+			end = len(path)
 		}
+		current = current.insertWild(path[:end])
+		path = path[end:]
 	}
-	nodes = append(nodes, wildNodes...)
-	return nodes
+	// 如果已经匹配完了，那么将pattern赋值给该node，表示它是一个完整的url
+	current.pattern = pattern
+	current.paramNames = patternParams(pattern)
+	return current
 }
 
-// insert 方法一边匹配一边插入，pattern为完整url，parts为url各部分，height是当前层高（初始为0）
-func (n *node) insert(pattern string, parts []string, height int) {
+// insertStatic 方法插入静态路径片段path，返回path末尾对应的节点
+func (n *node) insertStatic(path string) *node {
+	for {
+		index := strings.IndexByte(n.indices, path[0])
+		if index < 0 {
+			// 没有公共前缀的子节点，生成新节点
+			child := &node{path: path, kind: static}
+			n.indices += string(path[0])
+			n.children = append(n.children, child)
+			return child
+		}
+
+		child := n.children[index]
+		common := commonPrefix(path, child.path)
+		if common < len(child.path) {
+			// 拆分子节点：公共前缀作为新的中间节点，原节点（及其上的路由）保留为其子节点
+			prefix := &node{
+				path:     child.path[:common],
+				kind:     static,
+				indices:  string(child.path[common]),
+				children: []*node{child},
+			}
+			child.path = child.path[common:]
+			n.children[index] = prefix
+			child = prefix
+		}
+		path = path[common:]
+		if len(path) == 0 {
+			return child
+		}
+		n = child
+	}
+}
+
+// insertWild 方法插入参数或通配符part，返回对应的节点。
+// 约束相同的参数节点（如:name和:id）共用同一节点，约束不同的（如:id<int>和:name）互不合并。
+func (n *node) insertWild(part string) *node {
+	if part[0] == '*' {
+		if n.catchAll == nil {
+			n.catchAll = newNode(part)
+		}
+		n.catchAll.path = part
+		return n.catchAll
+	}
+	for _, child := range n.wildChildren {
+		if constraintOf(child.path) == constraintOf(part) {
+			return child
+		}
+	}
+	child := newNode(part)
+	if child.matcher != nil {
+		// 带约束的参数节点优先匹配
+		n.wildChildren = append([]*node{child}, n.wildChildren...)
+	} else {
+		n.wildChildren = append(n.wildChildren, child)
+	}
+	return child
+}
+
+// remove 方法删除pattern对应的route，并剪掉不再包含任何route的子节点；返回是否删除成功
+func (n *node) remove(pattern string) bool {
+	return n.removePath(normalizePattern(pattern), pattern)
+}
+
+// removePath 方法沿着剩余路径path查找并删除pattern
+func (n *node) removePath(path string, pattern string) bool {
 	// 递归的终止条件
-	if len(parts) == height {
-		// 如果已经匹配完了，那么将pattern赋值给该node，表示它是一个完整的url
-		n.pattern = pattern
-		return
+	if path == "" {
+		if n.pattern != pattern {
+			return false
+		}
+		n.pattern = ""
+		n.handlers = nil
+		n.route = nil
+		n.paramNames = nil
+		return true
 	}
 
-	part := parts[height]
-	child := n.matchChild(part)
-	if child == nil {
-		// 没有匹配上，那么生成新节点，并放到n节点的子列表中
-		child = &node{part: part, isWild: part[0] == ':' || part[0] == '*'}
-		n.children = append(n.children, child)
+	// 参数和通配符只出现在以`/`结尾的静态节点之后
+	if n.kind == static && strings.HasSuffix(n.path, "/") && (path[0] == ':' || path[0] == '*') {
+		if path[0] == '*' {
+			if n.catchAll == nil || !n.catchAll.removePath("", pattern) {
+				return false
+			}
+			if n.catchAll.isEmpty() {
+				n.catchAll = nil
+			}
+			return true
+		}
+		end := segmentEnd(path)
+		for i, child := range n.wildChildren {
+			if constraintOf(child.path) != constraintOf(path[:end]) {
+				continue
+			}
+			if !child.removePath(path[end:], pattern) {
+				return false
+			}
+			if child.isEmpty() {
+				n.wildChildren = append(n.wildChildren[:i], n.wildChildren[i+1:]...)
+			}
+			return true
+		}
+		return false
+	}
+
+	index := strings.IndexByte(n.indices, path[0])
+	if index < 0 {
+		return false
 	}
-	// 接着插入下一个part节点
-	child.insert(pattern, parts, height+1)
+	child := n.children[index]
+	if !strings.HasPrefix(path, child.path) || !child.removePath(path[len(child.path):], pattern) {
+		return false
+	}
+	// 子节点既不是完整的url也没有下层节点时将其剪掉
+	if child.isEmpty() {
+		n.indices = n.indices[:index] + n.indices[index+1:]
+		n.children = append(n.children[:index], n.children[index+1:]...)
+	}
+	return true
 }
 
-// search 方法查找匹配的route（返回的node中pattern为完整url)
-func (n *node) search(parts []string, height int) *node {
-	// 递归终止条件，找到末尾了或者通配符
-	if len(parts) == height || strings.HasPrefix(n.part, "*") {
-		// pattern为空字符串表示它不是一个完整的url，匹配失败
+// isEmpty 方法判断节点是否既不是完整的url也没有任何子节点
+func (n *node) isEmpty() bool {
+	return n.pattern == "" && len(n.children) == 0 && len(n.wildChildren) == 0 && n.catchAll == nil
+}
+
+// searchMode 查找路由的方式，可以组合使用
+type searchMode uint8
+
+const (
+	searchFold   searchMode = 1 << iota // 静态部分忽略大小写比较
+	searchStrict                        // 不回溯，见 Engine.DisableBacktracking
+)
+
+// search 方法查找匹配剩余路径path的route（返回的node中pattern为完整url)，
+// 匹配过程中依次把参数值（键在匹配成功后按node.paramNames填写）追加到values中。
+// 优先级为：静态节点 > 带约束的参数节点 > 普通参数节点 > 通配节点，某个分支匹配失败时回溯尝试下一个分支，
+// 因此更具体的路由优先，结果与注册顺序无关。
+// mode 含 searchStrict 时不回溯：当前part是某个静态路由的part时只在静态分支中查找，匹配到参数节点后也不再尝试其他分支；
+// 含 searchFold 时静态部分忽略大小写比较。
+func (n *node) search(path string, mode searchMode, values *Params) *node {
+	fold, strict := mode&searchFold != 0, mode&searchStrict != 0
+	// 递归终止条件，找到末尾了
+	if path == "" {
+		// pattern为空字符串表示它不是一个完整的url，此时只能以空的通配参数匹配
 		if n.pattern == "" {
+			if result := n.emptyCatchAll(values); result != nil {
+				return result
+			}
+			// 如 /assets 匹配 /assets/*filepath，末尾的`/`在查找前已被去掉
+			if index := strings.IndexByte(n.indices, '/'); index >= 0 && n.children[index].path == "/" {
+				return n.children[index].emptyCatchAll(values)
+			}
 			return nil
 		}
 		return n
 	}
 
-	part := parts[height]
-	// 获取所有可能的子路径
-	children := n.matchChildren(part)
+	// 静态子节点
+	if fold {
+		for _, child := range n.children {
+			if len(path) >= len(child.path) && strings.EqualFold(path[:len(child.path)], child.path) {
+				if result := child.search(path[len(child.path):], mode, values); result != nil {
+					return result
+				}
+			}
+		}
+	} else if index := strings.IndexByte(n.indices, path[0]); index >= 0 {
+		child := n.children[index]
+		if strings.HasPrefix(path, child.path) {
+			if result := child.search(path[len(child.path):], mode, values); result != nil {
+				return result
+			}
+		} else if len(child.path) == len(path)+1 && child.path[len(path)] == '/' && strings.HasPrefix(child.path, path) {
+			// 剩余路径只缺少末尾的`/`，如 /assets 匹配 /assets/*filepath
+			if result := child.emptyCatchAll(values); result != nil {
+				return result
+			}
+		}
+	}
 
-	for _, child := range children {
-		// 对于每条路径接着用下一part去查找
-		result := child.search(parts, height+1)
-		if result != nil {
-			// 找到了即返回
-			return result
+	// 不回溯时，当前part是某个静态路由的part则不再尝试参数和通配子节点
+	if strict && (len(n.wildChildren) > 0 || n.catchAll != nil) && n.hasStaticPart(path[:segmentEnd(path)], fold) {
+		return nil
+	}
+
+	// 参数子节点，匹配一个完整的part
+	if len(n.wildChildren) > 0 {
+		end := segmentEnd(path)
+		part := path[:end]
+		for _, child := range n.wildChildren {
+			// 不满足约束的节点不参与匹配
+			if part == "" || (child.matcher != nil && !child.matcher.MatchString(part)) {
+				continue
+			}
+			*values = append(*values, Param{Value: part})
+			if result := child.search(path[end:], mode, values); result != nil {
+				return result
+			}
+			*values = (*values)[:len(*values)-1]
+			// 不回溯时只尝试第一个满足约束的参数节点
+			if strict {
+				return nil
+			}
 		}
 	}
 
+	// 通配子节点，匹配剩余的全部路径
+	if n.catchAll != nil && n.catchAll.pattern != "" {
+		*values = append(*values, Param{Value: path})
+		return n.catchAll
+	}
 	return nil
 }
 
+// emptyCatchAll 方法以空的通配参数匹配 n 的通配子节点，没有通配子节点时返回 nil
+func (n *node) emptyCatchAll(values *Params) *node {
+	if n.catchAll == nil || n.catchAll.pattern == "" {
+		return nil
+	}
+	*values = append(*values, Param{})
+	return n.catchAll
+}
+
+// hasStaticPart 方法判断 n 的静态子节点中是否有与 part 完全相同的part（之后是`/`或路由在此结束），fold 为true时忽略大小写
+func (n *node) hasStaticPart(part string, fold bool) bool {
+	if part == "" {
+		return false
+	}
+	for _, child := range n.children {
+		common := len(child.path)
+		if common > len(part) {
+			common = len(part)
+		}
+		if part[:common] != child.path[:common] && !(fold && strings.EqualFold(part[:common], child.path[:common])) {
+			continue
+		}
+		if common == len(part) {
+			if common < len(child.path) {
+				return child.path[common] == '/'
+			}
+			return child.pattern != "" || strings.IndexByte(child.indices, '/') >= 0
+		}
+		if child.hasStaticPart(part[common:], fold) {
+			return true
+		}
+	}
+	return false
+}
+
 // travel 方法查找所有完整的url，保存到列表中
 func (n *node) travel(list *([]*node)) {
 	// 递归终止条件
@@ -101,4 +507,10 @@ func (n *node) travel(list *([]*node)) {
 		// 一层一层的递归找pattern是非空的节点
 		child.travel(list)
 	}
-}
\ No newline at end of file
+	for _, child := range n.wildChildren {
+		child.travel(list)
+	}
+	if n.catchAll != nil {
+		n.catchAll.travel(list)
+	}
+}
@@ -0,0 +1,448 @@
+package zinc
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 可续传上传协议（tus 1.0.0）的头部
+const (
+	tusVersion     = "1.0.0"
+	tusExtensions  = "creation,expiration,termination"
+	tusContentType = "application/offset+octet-stream"
+)
+
+// ErrUploadNotFound 上传不存在（或已过期被删除）时 UploadStore 返回的错误
+var ErrUploadNotFound = errors.New("zinc: upload not found")
+
+// UploadInfo 一次可续传上传的状态
+type UploadInfo struct {
+	ID       string
+	Size     int64             // 文件的总字节数，即创建时的 Upload-Length
+	Offset   int64             // 已接收的字节数
+	Metadata map[string]string // 创建时 Upload-Metadata 中的键值（值已解码）
+	Expires  time.Time         // 过期时间，之后的 PATCH 返回 410
+}
+
+// Complete 方法返回上传是否已完成
+func (info UploadInfo) Complete() bool {
+	return info.Offset == info.Size
+}
+
+// UploadStore 可续传上传的存储后端，可以用磁盘、对象存储等实现该接口，实现需要支持并发调用
+type UploadStore interface {
+	// Create 方法保存新建的上传
+	Create(info UploadInfo) error
+	// Info 方法返回上传的状态，不存在时返回 ErrUploadNotFound
+	Info(id string) (UploadInfo, error)
+	// Append 方法将 r 中的数据追加到上传的 offset 处，返回写入的字节数；出错时已写入的部分仍计入 Offset
+	Append(id string, offset int64, r io.Reader) (int64, error)
+	// Delete 方法删除上传及其数据
+	Delete(id string) error
+}
+
+// UploadConfig 可续传上传的配置
+type UploadConfig struct {
+	// Store 存储后端，默认为 NewMemoryUploadStore()
+	Store UploadStore
+	// MaxSize 单个文件的最大字节数，必须大于 0
+	MaxSize int64
+	// Expiration 上传创建后的有效期，默认为 24 小时
+	Expiration time.Duration
+	// OnComplete 非空时在最后一个 PATCH 请求写完数据后调用，可以在这里将文件移到最终位置；
+	// 此时还没有响应，可以通过 c.Fail 等拒绝上传
+	OnComplete func(c *Context, info UploadInfo)
+}
+
+// uploader 实现可续传上传协议的处理函数
+type uploader struct {
+	config UploadConfig
+	mu     sync.Mutex
+	locks  map[string]*uploadLock // 正在处理 PATCH 的上传 ID 到锁，同一上传的 PATCH 串行执行
+}
+
+// uploadLock 同一上传的 PATCH 请求共用的锁，refs 为持有或等待该锁的请求数，为 0 时从 uploader.locks 中删除
+type uploadLock struct {
+	sync.Mutex
+	refs int
+}
+
+// lock 方法锁定上传 id，返回解锁函数
+func (u *uploader) lock(id string) (unlock func()) {
+	u.mu.Lock()
+	l, ok := u.locks[id]
+	if !ok {
+		l = &uploadLock{}
+		u.locks[id] = l
+	}
+	l.refs++
+	u.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		u.mu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(u.locks, id)
+		}
+		u.mu.Unlock()
+	}
+}
+
+// Uploads 方法在分组上挂载可续传上传（tus 1.0.0 协议，支持 creation、expiration、termination 扩展）：
+//
+//	files := engine.Group("/files")
+//	files.Use(auth)
+//	files.Uploads(zinc.UploadConfig{MaxSize: 1 << 30, OnComplete: saveFile})
+//
+// 注册的路由为 OPTIONS、POST 分组前缀（创建上传），HEAD、PATCH、DELETE 前缀/:id（查询偏移、追加数据、终止上传）。
+// 客户端中断后用 HEAD 查询已接收的偏移，再从该偏移继续 PATCH。返回 group 本身以便链式调用。
+// config.MaxSize 必须大于 0，否则 panic。
+func (group *RouterGroup) Uploads(config UploadConfig) *RouterGroup {
+	if config.MaxSize <= 0 {
+		panic("zinc: UploadConfig.MaxSize must be positive")
+	}
+	if config.Store == nil {
+		config.Store = NewMemoryUploadStore()
+	}
+	if config.Expiration <= 0 {
+		config.Expiration = 24 * time.Hour
+	}
+	u := &uploader{config: config, locks: make(map[string]*uploadLock)}
+	group.Handle(http.MethodOptions, "/", u.options)
+	group.POST("/", u.tus(u.create))
+	group.Handle(http.MethodHead, "/:id", u.tus(u.head))
+	group.Handle(http.MethodPatch, "/:id", u.tus(u.patch))
+	group.Handle(http.MethodDelete, "/:id", u.tus(u.delete))
+	return group
+}
+
+// options 方法返回服务端支持的协议版本和扩展
+func (u *uploader) options(c *Context) {
+	c.SetHeader("Tus-Resumable", tusVersion)
+	c.SetHeader("Tus-Version", tusVersion)
+	c.SetHeader("Tus-Extension", tusExtensions)
+	c.SetHeader("Tus-Max-Size", strconv.FormatInt(u.config.MaxSize, 10))
+	c.Status(http.StatusNoContent)
+}
+
+// tus 方法检查请求的协议版本，并为响应加上 Tus-Resumable 头部
+func (u *uploader) tus(handler HandlerFunc) HandlerFunc {
+	return func(c *Context) {
+		c.SetHeader("Tus-Resumable", tusVersion)
+		if c.requestHeader("Tus-Resumable") != tusVersion {
+			c.SetHeader("Tus-Version", tusVersion)
+			c.Fail(http.StatusPreconditionFailed, "unsupported Tus-Resumable version")
+			return
+		}
+		handler(c)
+	}
+}
+
+// create 方法按 Upload-Length 新建上传，Location 头部为上传的地址
+func (u *uploader) create(c *Context) {
+	size, err := strconv.ParseInt(c.requestHeader("Upload-Length"), 10, 64)
+	if err != nil || size < 0 {
+		c.Fail(http.StatusBadRequest, "invalid Upload-Length")
+		return
+	}
+	if size > u.config.MaxSize {
+		c.Fail(http.StatusRequestEntityTooLarge, "upload exceeds Tus-Max-Size")
+		return
+	}
+	metadata, err := parseUploadMetadata(c.requestHeader("Upload-Metadata"))
+	if err != nil {
+		c.Fail(http.StatusBadRequest, err.Error())
+		return
+	}
+	id, err := newUploadID()
+	if err != nil {
+		c.Error(err)
+		c.Fail(http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+	info := UploadInfo{ID: id, Size: size, Metadata: metadata, Expires: time.Now().Add(u.config.Expiration)}
+	if err := u.config.Store.Create(info); err != nil {
+		c.Error(err)
+		c.Fail(http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+	c.SetHeader("Location", strings.TrimSuffix(c.Req.URL.Path, "/")+"/"+info.ID)
+	c.SetHeader("Upload-Expires", info.Expires.UTC().Format(http.TimeFormat))
+	c.Status(http.StatusCreated)
+}
+
+// head 方法返回上传已接收的偏移
+func (u *uploader) head(c *Context) {
+	info, ok := u.lookup(c)
+	if !ok {
+		return
+	}
+	c.SetHeader("Cache-Control", "no-store")
+	c.SetHeader("Upload-Offset", strconv.FormatInt(info.Offset, 10))
+	c.SetHeader("Upload-Length", strconv.FormatInt(info.Size, 10))
+	c.SetHeader("Upload-Expires", info.Expires.UTC().Format(http.TimeFormat))
+	c.Status(http.StatusOK)
+}
+
+// patch 方法从 Upload-Offset 处追加请求体，偏移与已接收的字节数不一致时返回 409
+func (u *uploader) patch(c *Context) {
+	if !strings.HasPrefix(c.requestHeader("Content-Type"), tusContentType) {
+		c.Fail(http.StatusUnsupportedMediaType, "Content-Type must be "+tusContentType)
+		return
+	}
+	offset, err := strconv.ParseInt(c.requestHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.Fail(http.StatusBadRequest, "invalid Upload-Offset")
+		return
+	}
+
+	// 不存在的上传不创建锁
+	if _, ok := u.lookup(c); !ok {
+		return
+	}
+	defer u.lock(c.Param("id"))()
+	// 加锁后重新读取，得到之前的 PATCH 写入后的偏移
+	info, ok := u.lookup(c)
+	if !ok {
+		return
+	}
+	if offset != info.Offset {
+		c.SetHeader("Upload-Offset", strconv.FormatInt(info.Offset, 10))
+		c.Fail(http.StatusConflict, "Upload-Offset does not match the received length")
+		return
+	}
+	if c.Req.ContentLength > info.Size-info.Offset {
+		c.Fail(http.StatusRequestEntityTooLarge, "request body exceeds Upload-Length")
+		return
+	}
+	body := &uploadLimitReader{r: c.Req.Body, remaining: info.Size - info.Offset}
+	n, err := u.config.Store.Append(info.ID, info.Offset, body)
+	info.Offset += n
+	c.SetHeader("Upload-Offset", strconv.FormatInt(info.Offset, 10))
+	c.SetHeader("Upload-Expires", info.Expires.UTC().Format(http.TimeFormat))
+	if errors.Is(err, errUploadOverflow) {
+		c.Fail(http.StatusRequestEntityTooLarge, "request body exceeds Upload-Length")
+		return
+	}
+	if err != nil {
+		// 客户端中断时已写入的部分保留，客户端可以通过 HEAD 查询偏移后继续
+		c.Error(err)
+		c.Fail(http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+	if info.Complete() {
+		if u.config.OnComplete != nil {
+			u.config.OnComplete(c, info)
+			if c.IsAborted() {
+				return
+			}
+		}
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// delete 方法终止上传并删除已接收的数据
+func (u *uploader) delete(c *Context) {
+	if _, ok := u.lookup(c); !ok {
+		return
+	}
+	if err := u.config.Store.Delete(c.Param("id")); err != nil {
+		c.Error(err)
+		c.Fail(http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// lookup 方法返回路由参数 id 对应的上传，不存在时以 404、已过期时删除上传并以 410 状态码中止请求
+func (u *uploader) lookup(c *Context) (UploadInfo, bool) {
+	id := c.Param("id")
+	info, err := u.config.Store.Info(id)
+	if errors.Is(err, ErrUploadNotFound) {
+		c.Fail(http.StatusNotFound, "upload not found")
+		return info, false
+	}
+	if err != nil {
+		c.Error(err)
+		c.Fail(http.StatusInternalServerError, "Internal Server Error")
+		return info, false
+	}
+	if !info.Expires.IsZero() && time.Now().After(info.Expires) {
+		u.config.Store.Delete(id)
+		c.Fail(http.StatusGone, "upload expired")
+		return info, false
+	}
+	return info, true
+}
+
+// errUploadOverflow 请求体超出上传剩余的字节数
+var errUploadOverflow = errors.New("zinc: request body exceeds Upload-Length")
+
+// uploadLimitReader 读取至多 remaining 字节，之后多读一个字节判断请求体是否超出，超出时返回 errUploadOverflow
+type uploadLimitReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *uploadLimitReader) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		var b [1]byte
+		if n, _ := l.r.Read(b[:]); n > 0 {
+			return 0, errUploadOverflow
+		}
+		return 0, io.EOF
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
+
+// parseUploadMetadata 解析 Upload-Metadata 头部，格式为逗号分隔的“键 base64值”，值可以省略
+func parseUploadMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		fields := strings.Fields(pair)
+		switch len(fields) {
+		case 0:
+			continue
+		case 1:
+			metadata[fields[0]] = ""
+		case 2:
+			value, err := base64.StdEncoding.DecodeString(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid Upload-Metadata value for %s", fields[0])
+			}
+			metadata[fields[0]] = string(value)
+		default:
+			return nil, fmt.Errorf("invalid Upload-Metadata pair %q", strings.TrimSpace(pair))
+		}
+	}
+	return metadata, nil
+}
+
+// newUploadID 返回随机生成的上传 ID
+func newUploadID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// MemoryUploadStore 保存在内存中的 UploadStore，用于测试和单实例的小文件上传；
+// 新建上传时清理已过期的上传
+type MemoryUploadStore struct {
+	mu      sync.Mutex
+	uploads map[string]*memoryUpload
+}
+
+type memoryUpload struct {
+	info UploadInfo
+	data []byte
+}
+
+// NewMemoryUploadStore 是 zinc.MemoryUploadStore 的构造函数
+func NewMemoryUploadStore() *MemoryUploadStore {
+	return &MemoryUploadStore{uploads: make(map[string]*memoryUpload)}
+}
+
+// Create 方法保存新建的上传
+func (s *MemoryUploadStore) Create(info UploadInfo) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for id, upload := range s.uploads {
+		if !upload.info.Expires.IsZero() && now.After(upload.info.Expires) {
+			delete(s.uploads, id)
+		}
+	}
+	s.uploads[info.ID] = &memoryUpload{info: info}
+	return nil
+}
+
+// Info 方法返回上传的状态
+func (s *MemoryUploadStore) Info(id string) (UploadInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	upload, ok := s.uploads[id]
+	if !ok {
+		return UploadInfo{}, ErrUploadNotFound
+	}
+	return upload.info, nil
+}
+
+// memoryUploadChunk MemoryUploadStore.Append 每次读取并追加的最大字节数
+const memoryUploadChunk = 32 << 10
+
+// Append 方法将 r 中的数据分块追加到上传中，每读到一块就计入 Offset，上传过程中 HEAD 可以看到进度
+func (s *MemoryUploadStore) Append(id string, offset int64, r io.Reader) (int64, error) {
+	if _, err := s.Info(id); err != nil {
+		return 0, err
+	}
+	// 在锁外读取请求体，避免慢客户端阻塞其他上传
+	buf := make([]byte, memoryUploadChunk)
+	var written int64
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if appendErr := s.appendChunk(id, offset+written, buf[:n]); appendErr != nil {
+				return written, appendErr
+			}
+			written += int64(n)
+		}
+		if err == io.EOF {
+			return written, nil
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+}
+
+// appendChunk 方法将 data 追加到上传的 offset 处
+func (s *MemoryUploadStore) appendChunk(id string, offset int64, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	upload, ok := s.uploads[id]
+	if !ok {
+		return ErrUploadNotFound
+	}
+	if offset != upload.info.Offset {
+		return fmt.Errorf("zinc: upload %s is at offset %d, not %d", id, upload.info.Offset, offset)
+	}
+	upload.data = append(upload.data, data...)
+	upload.info.Offset += int64(len(data))
+	return nil
+}
+
+// Delete 方法删除上传
+func (s *MemoryUploadStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.uploads, id)
+	return nil
+}
+
+// Data 方法返回上传已接收的数据，上传不存在时 ok 为 false
+func (s *MemoryUploadStore) Data(id string) (data []byte, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	upload, ok := s.uploads[id]
+	if !ok {
+		return nil, false
+	}
+	return append([]byte(nil), upload.data...), true
+}
@@ -0,0 +1,183 @@
+package zinc
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// UploadError 的错误类型
+const (
+	ReasonTooLarge       = "too_large"
+	ReasonTypeMismatch   = "type_mismatch"
+	ReasonTypeNotAllowed = "type_not_allowed"
+)
+
+// UploadError 上传的文件未通过 MaxFileSize、SniffContentType 检查的原因，可以直接作为响应内容返回给客户端
+type UploadError struct {
+	Field    string `json:"field"`              // 表单字段名
+	FileName string `json:"filename"`           // 客户端提供的文件名
+	Reason   string `json:"reason"`             // 错误类型：too_large、type_mismatch 或 type_not_allowed
+	Message  string `json:"message"`            // 错误描述
+	Declared string `json:"declared,omitempty"` // 客户端声明的 Content-Type
+	Detected string `json:"detected,omitempty"` // 按文件内容识别的类型
+	Limit    int64  `json:"limit,omitempty"`    // 超出的字节数上限
+	Err      error  `json:"-"`                  // 原始错误，超出大小时为 ErrPartTooLarge
+}
+
+// Error 方法返回错误描述
+func (e *UploadError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// Unwrap 方法返回原始错误
+func (e *UploadError) Unwrap() error {
+	return e.Err
+}
+
+// MaxFileSize 返回限制单个上传文件大小的检查器，文件超过 limit 字节时返回 *UploadError（Err 为 ErrPartTooLarge），
+// InspectUploads 以 413 状态码拒绝请求。请求体的总大小由 BodyLimit 限制。
+func MaxFileSize(limit int64) PartInspector {
+	return maxFileSize(limit)
+}
+
+type maxFileSize int64
+
+func (limit maxFileSize) Inspect(info PartInfo) (io.WriteCloser, error) {
+	return &sizeCheck{info: info, limit: int64(limit)}, nil
+}
+
+// sizeCheck 累计文件的字节数
+type sizeCheck struct {
+	info  PartInfo
+	limit int64
+	size  int64
+}
+
+func (s *sizeCheck) Write(p []byte) (int, error) {
+	s.size += int64(len(p))
+	if s.size > s.limit {
+		return 0, &UploadError{
+			Field:    s.info.FormName,
+			FileName: s.info.FileName,
+			Reason:   ReasonTooLarge,
+			Message:  "file exceeds " + strconv.FormatInt(s.limit, 10) + " bytes",
+			Limit:    s.limit,
+			Err:      ErrPartTooLarge,
+		}
+	}
+	return len(p), nil
+}
+
+func (s *sizeCheck) Close() error {
+	return nil
+}
+
+// sniffLen http.DetectContentType 最多使用的字节数
+const sniffLen = 512
+
+// SniffContentType 返回按文件头识别文件类型（http.DetectContentType）的检查器：
+// 识别的类型必须匹配 allowed 中的某一项（前缀匹配，如 image/ 或 application/pdf，为空时不限制），
+// 且与客户端声明的 Content-Type 一致，否则返回 *UploadError，InspectUploads 以 422 状态码拒绝请求。
+//
+// 声明为 application/octet-stream 或未声明时只检查 allowed；文本类型（如 text/csv、application/json）
+// 识别为 text/plain，基于 zip 的格式（如 .docx）识别为 application/zip，这些情况视为一致。
+func SniffContentType(allowed ...string) PartInspector {
+	return sniffContentType(allowed)
+}
+
+type sniffContentType []string
+
+func (allowed sniffContentType) Inspect(info PartInfo) (io.WriteCloser, error) {
+	return &sniffCheck{info: info, allowed: allowed}, nil
+}
+
+// sniffCheck 保存文件的前 512 字节，在文件结束时识别类型
+type sniffCheck struct {
+	info    PartInfo
+	allowed []string
+	head    []byte
+}
+
+func (s *sniffCheck) Write(p []byte) (int, error) {
+	if room := sniffLen - len(s.head); room > 0 {
+		if len(p) < room {
+			room = len(p)
+		}
+		s.head = append(s.head, p[:room]...)
+	}
+	return len(p), nil
+}
+
+func (s *sniffCheck) Close() error {
+	detected, _, _ := mime.ParseMediaType(http.DetectContentType(s.head))
+	declared, _, _ := mime.ParseMediaType(s.info.Header.Get("Content-Type"))
+	if !matchContentType(detected, s.allowed) {
+		return &UploadError{
+			Field:    s.info.FormName,
+			FileName: s.info.FileName,
+			Reason:   ReasonTypeNotAllowed,
+			Message:  "file type " + detected + " is not allowed",
+			Declared: declared,
+			Detected: detected,
+		}
+	}
+	if declared != "" && declared != "application/octet-stream" && !sameContentType(declared, detected) {
+		return &UploadError{
+			Field:    s.info.FormName,
+			FileName: s.info.FileName,
+			Reason:   ReasonTypeMismatch,
+			Message:  "declared type " + declared + " does not match the content (" + detected + ")",
+			Declared: declared,
+			Detected: detected,
+		}
+	}
+	return nil
+}
+
+// sameContentType 判断声明的类型与识别的类型是否一致
+func sameContentType(declared string, detected string) bool {
+	switch {
+	case declared == detected:
+		return true
+	case detected == "text/xml":
+		return declared == "application/xml" || strings.HasSuffix(declared, "+xml")
+	case detected == "text/plain":
+		return strings.HasPrefix(declared, "text/") || declared == "application/json" || declared == "application/xml" ||
+			strings.HasSuffix(declared, "+json") || strings.HasSuffix(declared, "+xml")
+	case detected == "application/zip":
+		return strings.HasSuffix(declared, "+zip") || strings.Contains(declared, "openxmlformats") || strings.Contains(declared, "opendocument")
+	case detected == "application/octet-stream":
+		// 无法识别的二进制格式无法验证声明的类型
+		return true
+	}
+	return false
+}
+
+// abortUpload 方法记录上传检查的错误并中止请求：超出大小限制时为 413，其他为 422；
+// 错误为 *UploadError 时响应中包含它的 field、filename、reason 等字段
+func (c *Context) abortUpload(err error) {
+	c.Error(err)
+	status := http.StatusUnprocessableEntity
+	if errors.Is(err, ErrPartTooLarge) {
+		status = http.StatusRequestEntityTooLarge
+	}
+	var uploadErr *UploadError
+	if !errors.As(err, &uploadErr) || c.envelope != nil {
+		c.Fail(status, err.Error())
+		return
+	}
+	c.Abort()
+	if c.engine != nil && c.engine.ProblemJSON {
+		c.Problem(status, ProblemDetails{
+			Detail:     uploadErr.Error(),
+			Instance:   c.Path,
+			Extensions: map[string]interface{}{"field": uploadErr.Field, "filename": uploadErr.FileName, "reason": uploadErr.Reason},
+		})
+		return
+	}
+	c.JSON(status, uploadErr)
+}
@@ -0,0 +1,217 @@
+package zinc
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ValidatorFunc 校验规则，value 为字段的值（指针字段为指向的值），param 为规则的参数（如 min=3 中的 3），
+// 校验通过时返回 true
+type ValidatorFunc func(value interface{}, param string) bool
+
+// DecoderFunc 将查询参数、表单字段、路由参数或头部的字符串值解码为字段类型的值
+type DecoderFunc func(value string) (interface{}, error)
+
+// 通过 RegisterValidator 和 RegisterDecoder 注册的扩展
+var (
+	extMu      sync.RWMutex
+	validators = map[string]ValidatorFunc{}
+	decoders   = map[reflect.Type]DecoderFunc{}
+)
+
+// RegisterValidator 注册名为 name 的校验规则，可以在 validate 标签中使用，同名的规则（包括内置规则）会被覆盖。
+// 应在程序初始化时调用，如：
+//
+//	zinc.RegisterValidator("iban", func(value interface{}, param string) bool {
+//		s, _ := value.(string)
+//		return iban.Valid(s)
+//	})
+//
+//	type Transfer struct {
+//		To string `json:"to" validate:"required,iban"`
+//	}
+func RegisterValidator(name string, fn ValidatorFunc) {
+	extMu.Lock()
+	defer extMu.Unlock()
+	validators[name] = fn
+}
+
+// RegisterDecoder 注册 sample 所属类型的字段解码函数，用于 BindQuery、BindForm、BindUri、BindHeader，
+// 优先于内置的转换（如按项目统一的格式解析 time.Time，或解析 Money 等自定义类型）：
+//
+//	zinc.RegisterDecoder(Money{}, func(s string) (interface{}, error) {
+//		return ParseMoney(s)
+//	})
+//
+// 实现了 encoding.TextUnmarshaler 的类型不需要注册。fn 返回的值必须是 sample 的类型。
+func RegisterDecoder(sample interface{}, fn DecoderFunc) {
+	extMu.Lock()
+	defer extMu.Unlock()
+	decoders[reflect.TypeOf(sample)] = fn
+}
+
+// decodeValue 使用注册的解码函数将 val 赋值给 v，没有注册时 ok 为 false
+func decodeValue(v reflect.Value, val string) (ok bool, err error) {
+	extMu.RLock()
+	fn, registered := decoders[v.Type()]
+	extMu.RUnlock()
+	if registered {
+		decoded, err := fn(val)
+		if err != nil {
+			return true, err
+		}
+		value := reflect.ValueOf(decoded)
+		if !value.IsValid() || value.Type() != v.Type() {
+			return true, fmt.Errorf("decoder for %s returned %T", v.Type(), decoded)
+		}
+		v.Set(value)
+		return true, nil
+	}
+	return false, nil
+}
+
+// unmarshalText 在 v 实现了 encoding.TextUnmarshaler 时用它解析 val，没有实现时 ok 为 false
+func unmarshalText(v reflect.Value, val string) (ok bool, err error) {
+	if !v.CanAddr() {
+		return false, nil
+	}
+	u, ok := v.Addr().Interface().(encoding.TextUnmarshaler)
+	if !ok {
+		return false, nil
+	}
+	return true, u.UnmarshalText([]byte(val))
+}
+
+// builtinValidators 内置的校验规则
+var builtinValidators = map[string]ValidatorFunc{
+	"required": func(value interface{}, param string) bool {
+		return value != nil && !reflect.ValueOf(value).IsZero()
+	},
+	"min": func(value interface{}, param string) bool {
+		n, ok := measure(value)
+		limit, err := strconv.ParseFloat(param, 64)
+		return ok && err == nil && n >= limit
+	},
+	"max": func(value interface{}, param string) bool {
+		n, ok := measure(value)
+		limit, err := strconv.ParseFloat(param, 64)
+		return ok && err == nil && n <= limit
+	},
+	"oneof": func(value interface{}, param string) bool {
+		s := fmt.Sprint(value)
+		for _, option := range strings.Fields(param) {
+			if s == option {
+				return true
+			}
+		}
+		return false
+	},
+}
+
+// measure 返回 min、max 规则比较的量：数字为其值，字符串为字符数，切片和映射为长度
+func measure(value interface{}) (float64, bool) {
+	if n, ok := toFloat(value); ok {
+		return n, true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.String:
+		return float64(len([]rune(v.String()))), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), true
+	}
+	return 0, false
+}
+
+// lookupValidator 返回名为 name 的校验规则，注册的规则优先于内置规则
+func lookupValidator(name string) (ValidatorFunc, bool) {
+	extMu.RLock()
+	fn, ok := validators[name]
+	extMu.RUnlock()
+	if ok {
+		return fn, true
+	}
+	fn, ok = builtinValidators[name]
+	return fn, ok
+}
+
+// validateStruct 按 validate 标签校验 obj 指向的结构体（包括嵌套的结构体），
+// 校验失败时返回 *BindError，Field 为按 tag 标签命名的字段路径（如 address.city）。
+// obj 不是结构体指针时不校验。规则未注册时 panic。
+//
+// 内置规则：required（非零值）、min=n、max=n（数字比较值，字符串、切片比较长度）、oneof=a b c。
+func validateStruct(obj interface{}, tag string) error {
+	v, err := structValue(obj)
+	if err != nil {
+		return nil
+	}
+	return validateValue(v, tag, "")
+}
+
+// validateValue 校验结构体 v 的字段，prefix 为 v 的字段路径
+func validateValue(v reflect.Value, tag string, prefix string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key, ok := fieldKey(field, tag)
+		if !ok {
+			continue
+		}
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		value := v.Field(i)
+		if rules := field.Tag.Get("validate"); rules != "" {
+			if err := checkRules(value, rules, key); err != nil {
+				return err
+			}
+		}
+		if value.Kind() == reflect.Ptr && !value.IsNil() {
+			value = value.Elem()
+		}
+		if value.Kind() == reflect.Struct && value.Type() != reflect.TypeOf(time.Time{}) {
+			if err := validateValue(value, tag, key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkRules 按逗号分隔的规则校验字段的值，key 为字段路径。空指针只校验 required。
+func checkRules(value reflect.Value, rules string, key string) error {
+	for _, rule := range strings.Split(rules, ",") {
+		name, param := rule, ""
+		if i := strings.IndexByte(rule, '='); i >= 0 {
+			name, param = rule[:i], rule[i+1:]
+		}
+		fn, ok := lookupValidator(name)
+		if !ok {
+			panic(fmt.Sprintf("zinc: unknown validation rule %q on %s", name, key))
+		}
+		var arg interface{}
+		if value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				if name == "required" {
+					return &BindError{Field: key, Reason: ReasonInvalid, Message: "is required"}
+				}
+				continue
+			}
+			arg = value.Elem().Interface()
+		} else {
+			arg = value.Interface()
+		}
+		if !fn(arg, param) {
+			if name == "required" {
+				return &BindError{Field: key, Reason: ReasonInvalid, Message: "is required"}
+			}
+			return &BindError{Field: key, Reason: ReasonInvalid, Message: "failed the " + rule + " rule"}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package zinc
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// WellKnownGroup 前缀为 /.well-known 的分组（RFC 8615），提供常用的 well-known 端点
+type WellKnownGroup struct {
+	*RouterGroup
+}
+
+// WellKnown 方法返回 /.well-known 分组，如：engine.WellKnown().SecurityTxt(zinc.SecurityTxt{...})
+func (engine *Engine) WellKnown() *WellKnownGroup {
+	return &WellKnownGroup{RouterGroup: engine.Group("/.well-known")}
+}
+
+// SecurityTxt security.txt（RFC 9116）的内容，Contact 和 Expires 是必需的字段
+type SecurityTxt struct {
+	Contact            []string  // 联系方式，如 mailto:security@example.com
+	Expires            time.Time // 过期时间
+	Encryption         []string  // 加密密钥的地址
+	Acknowledgments    string    // 致谢页面的地址
+	PreferredLanguages []string  // 首选语言，如 en、zh
+	Canonical          []string  // security.txt 的规范地址
+	Policy             string    // 漏洞披露政策的地址
+	Hiring             string    // 安全岗位的招聘地址
+}
+
+// String 方法按 RFC 9116 的格式输出 security.txt
+func (s SecurityTxt) String() string {
+	var b strings.Builder
+	write := func(field string, values ...string) {
+		for _, value := range values {
+			if value != "" {
+				b.WriteString(field + ": " + value + "\n")
+			}
+		}
+	}
+	write("Contact", s.Contact...)
+	if !s.Expires.IsZero() {
+		write("Expires", s.Expires.UTC().Format(time.RFC3339))
+	}
+	write("Encryption", s.Encryption...)
+	write("Acknowledgments", s.Acknowledgments)
+	write("Preferred-Languages", strings.Join(s.PreferredLanguages, ", "))
+	write("Canonical", s.Canonical...)
+	write("Policy", s.Policy)
+	write("Hiring", s.Hiring)
+	return b.String()
+}
+
+// SecurityTxt 方法注册 /.well-known/security.txt，缺少 Contact 或 Expires 时 panic
+func (g *WellKnownGroup) SecurityTxt(s SecurityTxt) *Route {
+	if len(s.Contact) == 0 || s.Expires.IsZero() {
+		panic("zinc: security.txt requires Contact and Expires")
+	}
+	content := []byte(s.String())
+	return g.GET("/security.txt", func(c *Context) {
+		c.SetHeader("Content-Type", "text/plain; charset=utf-8")
+		c.Data(http.StatusOK, content)
+	})
+}
+
+// ChangePassword 方法注册 /.well-known/change-password，将密码管理器重定向到修改密码的页面 target
+func (g *WellKnownGroup) ChangePassword(target string) *Route {
+	return g.GET("/change-password", func(c *Context) {
+		http.Redirect(c.Writer, c.Req, target, http.StatusFound)
+		c.StatusCode = http.StatusFound
+	})
+}
+
+// AssetLinks 方法注册 Android 应用链接使用的 /.well-known/assetlinks.json，v 在注册时编码，编码失败时 panic
+func (g *WellKnownGroup) AssetLinks(v interface{}) *Route {
+	return g.wellKnownJSON("/assetlinks.json", v)
+}
+
+// AppleAppSiteAssociation 方法注册 iOS 通用链接使用的 /.well-known/apple-app-site-association，
+// 该地址没有扩展名，但必须以 application/json 返回且不能重定向
+func (g *WellKnownGroup) AppleAppSiteAssociation(v interface{}) *Route {
+	return g.wellKnownJSON("/apple-app-site-association", v)
+}
+
+// wellKnownJSON 方法注册返回固定JSON内容的端点
+func (g *WellKnownGroup) wellKnownJSON(pattern string, v interface{}) *Route {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic("zinc: encode " + pattern + ": " + err.Error())
+	}
+	return g.GET(pattern, func(c *Context) {
+		c.SetHeader("Content-Type", "application/json")
+		c.Data(http.StatusOK, data)
+	})
+}
@@ -1,11 +1,14 @@
 package zinc
 
 import (
+	"fmt"
 	"html/template"
 	"log"
 	"net/http"
 	"path"
 	"strings"
+	"sync"
+	"time"
 )
 
 // HandlerFunc 定义了使用zinc框架时的请求处理函数（handler）
@@ -16,22 +19,130 @@ type HandlerFunc func(*Context)
 type Engine struct {
 	*RouterGroup           // 嵌套结构体，继承RouterGroup所有属性和方法
 	router *router         // 普通路由结构
+	hosts  map[string]*router // 按 Host 区分的路由结构，由 Host、Subdomain 方法创建
 	groups []*RouterGroup  // 存储所有分组
 	htmlTemplates *template.Template // 将所有的模板加载进内存，用于html渲染
 	funcMap       template.FuncMap   // 是所有的自定义模板渲染函数，用于html渲染
+	contextFuncs  template.FuncMap   // 通过 SetContextFuncMap 设置的需要访问当前请求的模板函数
+	boundTemplates *sync.Pool        // 设置了 contextFuncs 时复用绑定了模板函数的模板集副本，由 LoadHTMLGlob 创建
+	noRoute       []HandlerFunc      // 路由匹配失败时的处理函数链（自定义404）
+	fallbacks     []FallbackResolver // 路由匹配失败时在 noRoute 之前依次尝试的处理函数
+	preHandlers   []HandlerFunc      // 路由匹配之前执行的处理函数，如请求改写
+	routes        []*Route           // 所有已注册的路由
+	pool          sync.Pool          // 复用 Context 对象，减少每个请求的内存分配
+	routeCache    *routeCache        // 动态路由查找结果的缓存，开启 RouteCacheSize 后在第一次查找时创建
+	cacheOnce     sync.Once          // 保证 routeCache 只创建一次
+	namedHandlers    map[string]HandlerFunc // 通过 RegisterHandler 注册的处理函数，供 LoadRoutes 按名称引用
+	namedMiddlewares map[string]HandlerFunc // 通过 RegisterMiddleware 注册的中间件，供 LoadRoutes 按名称引用
+	server        *http.Server       // Run 启动的 http 服务器，供 Shutdown 关闭
+	draining      chan struct{}      // Shutdown 开始时关闭，通知长连接
+	drainOnce     sync.Once          // 保证 draining 只创建一次
+	streams       int64              // 正在处理的长连接请求（调用过 c.Draining）的数量
+	origin        *Engine            // 通过 Clone 创建时为路由树的所有者，路由匹配使用它的路由结构、锁和缓存
+
+	EngineConfig // 配置选项，Clone 时整体复制
+
+	mu     sync.RWMutex // 保护运行时通过 AddRoute、RemoveRoute 对路由的修改
+	frozen int32        // 为 1 时路由表已冻结，只能通过 AddRoute、RemoveRoute 修改
+}
+
+// EngineConfig Engine 的配置选项，嵌入 Engine 中，可以直接通过 engine.CaseInsensitive 等访问。
+// 新增的配置选项应该放在这里，Clone 时才会被复制。
+type EngineConfig struct {
+	// CaseInsensitive 为true时，精确匹配失败后忽略大小写再匹配一次，如 /API/Users 匹配 /api/users
+	CaseInsensitive bool
+	// RedirectCanonicalCase 为true时，忽略大小写匹配成功的 GET/HEAD 请求以 301 重定向到规范大小写的路径
+	RedirectCanonicalCase bool
+	// HeadFallbackToGet 为true时，没有匹配路由的 HEAD 请求交给对应的 GET 路由处理，丢弃响应体但保留 Content-Length
+	HeadFallbackToGet bool
+	// UseRawPath 为true时，使用未解码的路径（URL.EscapedPath）匹配路由，如 /hello/John%2FDoe 匹配 /hello/:name
+	UseRawPath bool
+	// UnescapePathValues 为true时，UseRawPath 匹配得到的路由参数会被解码，如 name 为 John/Doe
+	UnescapePathValues bool
+	// RemoveExtraSlash 为true时，路由匹配之前合并路径中重复的斜杠，如 //g1//hello/x 规范为 /g1/hello/x
+	RemoveExtraSlash bool
+	// CleanPath 为true时，路由匹配之前解析路径中的 . 和 .. 段，如 /g1/./hello/x 规范为 /g1/hello/x
+	CleanPath bool
+	// RedirectCleanPath 为true时，GET/HEAD 请求的路径需要规范时以 301 重定向到规范路径，而不是直接按规范路径匹配
+	RedirectCleanPath bool
+	// NonEmptyCatchAll 为true时通配参数必须非空，如 /assets 和 /assets/ 不再匹配 /assets/*filepath（默认以空参数匹配）
+	NonEmptyCatchAll bool
+	// DisableBacktracking 为true时查找路由不回溯：请求路径的part是某个静态路由的part时只在静态分支中查找，
+	// 匹配到参数路由后也不再尝试通配路由。如同时注册 /static/*filepath 和 /static/admin/login 时，
+	// 默认 /static/admin/logout 回溯匹配到通配路由，开启后返回 404
+	DisableBacktracking bool
+	// BaseDomain Subdomain 分组匹配的基础域名，如 example.com；为空时 Host 的第一段视为子域名
+	BaseDomain string
+	// RouteCacheSize 大于 0 时，以 LRU 缓存最近的动态路由查找结果（请求方式和路径到node和参数），注册或删除路由时清空
+	RouteCacheSize int
+	// QueryDuplicates 同名查询参数和表单字段重复出现时的处理方式，对 Query、PostForm 和查询参数绑定统一生效
+	QueryDuplicates DuplicatePolicy
+	// HeaderDuplicates 同名请求头部重复出现时的处理方式，RejectDuplicates 不拒绝 Accept、Cookie 等列表型头部
+	HeaderDuplicates DuplicatePolicy
+	// MaxQueryLength 查询字符串的字节数上限，为 0 时不限制；超过时在路由之前以 414 状态码拒绝请求
+	MaxQueryLength int
+	// MaxQueryParams 查询参数的数量上限，为 0 时不限制；超过时在路由之前以 414 状态码拒绝请求
+	MaxQueryParams int
+	// MaxHeaderCount 请求头部的数量上限，为 0 时不限制；超过时在路由之前以 431 状态码拒绝请求
+	MaxHeaderCount int
+	// MaxHeaderBytes 请求头部（名称和值）的总字节数上限，为 0 时不限制；超过时在路由之前以 431 状态码拒绝请求
+	MaxHeaderBytes int
+	// ContextWithKeys 为true时，Context 作为 context.Context 使用时的 Value 方法先查找 c.Keys 中的数据（键为字符串时），
+	// 再查找请求的 context
+	ContextWithKeys bool
+	// MaxBodyBytes 框架读取整个请求体（如 GetRawData、ShouldBindBodyWith 和 Route.Validate 的校验步骤）时的字节数上限，为 0 时为 10MB；
+	// 超过上限时以 413 状态码拒绝请求
+	MaxBodyBytes int64
+	// DisallowUnknownFields 为true时，ShouldBindJSON 等绑定方法拒绝包含结构体未声明字段的请求体
+	DisallowUnknownFields bool
+	// TrustedPlatform 非空时，c.ClientIP 直接使用该请求头部中的客户端 IP，如 zinc.PlatformCloudflare（CF-Connecting-IP）；
+	// 只应在服务只能通过该平台访问时设置
+	TrustedPlatform string
+	// TrustedProxies 可信代理的 IP 或 CIDR，连接的对端属于其中时 c.ClientIP 才解析 X-Forwarded-For、X-Real-IP
+	TrustedProxies []string
+	// SecureJSONPrefix c.SecureJSON 在顶层为数组的响应前加上的前缀，为空时为 while(1);
+	SecureJSONPrefix string
+	// MsgPackCodec c.MsgPack、c.BindMsgPack 使用的 MessagePack 编解码器，为空时使用 github.com/vmihailenco/msgpack
+	MsgPackCodec Codec
+	// Charset 非空时，c.String、c.HTML、c.JSON 等渲染方法的 Content-Type 附加该字符集，如 utf-8 时为 application/json; charset=utf-8；
+	// 可以通过 c.SetCharset 为单个响应指定其他字符集
+	Charset string
+	// ProblemJSON 为true时，框架产生的错误响应（404、Recovery 的 500、Fail 等）以 RFC 7807 的 application/problem+json 格式输出；
+	// 设置了响应信封的分组仍使用信封格式
+	ProblemJSON bool
+	// FlowStore 多步骤表单（c.Flow）数据的存储
+	FlowStore FlowStore
+	// FlowCookieInsecure 为true时 c.Flow 设置的 Cookie 不带 Secure 属性，只应在本地通过 HTTP 开发时设置
+	FlowCookieInsecure bool
+	// ScratchSize 大于 0 时开启请求级临时缓冲区（c.Scratch），为缓冲区的初始字节数，随 Context 对象池复用；开启后 JSON 响应先编码到缓冲区再写出
+	ScratchSize int
+	// DrainGracePeriod Shutdown 通知长连接后等待它们返回的最长时间，为 0 时为 5 秒
+	DrainGracePeriod time.Duration
+	// Redactor Recovery 写日志和调用 PanicReporter 之前屏蔽请求中敏感数据的规则，LoggerConfig.Redactor 为空时访问日志也使用它；
+	// 为空时使用 DefaultRedactor
+	Redactor *Redactor
+	// PanicReporter 非空时 Recovery 捕获 panic 后调用，用于向错误上报服务报告，声明了 Critical 的路由以更高的严重程度报告
+	PanicReporter PanicReporter
 }
 
 // RouterGroup 分组路由结构
 type RouterGroup struct {
 	prefix      string         // 前缀
+	host        string         // 分组只匹配的 Host，为空时不区分 Host
 	middlewares []HandlerFunc  // 中间件
+	methods     []string       // 与 middlewares 一一对应，非空时中间件只应用于该请求方法的路由（由 UseFor 设置）
+	parent      *RouterGroup   // 父分组，用于在注册路由时收集所有上层分组的中间件
 	engine      *Engine        // 所有分组都指向同一个Engine
+	panicConfig *PanicConfig   // 通过 OnPanic 设置的 panic 处理方式，为空时使用上层分组的设置
 }
 
 // New 是 zinc.Engine 的构造函数
 func New() *Engine {
 	engine := &Engine{router: newRouter()}
 	engine.RouterGroup = &RouterGroup{engine: engine}
+	engine.pool.New = func() interface{} {
+		return &Context{engine: engine}
+	}
 	engine.groups = []*RouterGroup{engine.RouterGroup}
 	return engine
 }
@@ -42,33 +153,164 @@ func (group *RouterGroup) Group(prefix string) *RouterGroup {
 	engine := group.engine
 	newGroup := &RouterGroup{
 		prefix: group.prefix + prefix,
+		host:   group.host,
+		parent: group,
 		engine: engine,
 	}
 	engine.groups = append(engine.groups, newGroup)
 	return newGroup
 }
 
-// Use 方法将中间件应用到 group 分组中
-func (group *RouterGroup) Use(middlewares ...HandlerFunc) {
+// Use 方法将中间件应用到 group 分组中。
+// 路由在注册时就计算好了中间件链，如果分组中已经注册了路由，Use 会重新计算这些路由的中间件链，
+// 使中间件同样对它们生效。
+// 返回 group 本身，可以链式调用，如：e.Group("/api").Use(auth()).GET("/me", me)
+func (group *RouterGroup) Use(middlewares ...HandlerFunc) *RouterGroup {
+	group.engine.checkMutable("Use")
+	group.addMiddlewares("", middlewares)
+	return group
+}
+
+// UseFor 方法将只对请求方法 method 生效的中间件应用到 group 分组中，
+// 如审计、CSRF、请求体大小限制等只需要作用于写操作的中间件：g.UseFor("POST", csrf())。
+// 中间件在注册时就只加入该请求方法的路由的中间件链，与通过 Use 注册的中间件按注册顺序执行。
+func (group *RouterGroup) UseFor(method string, middlewares ...HandlerFunc) *RouterGroup {
+	group.engine.checkMutable("UseFor")
+	if method == "" {
+		panic("zinc: HTTP method can not be empty")
+	}
+	group.addMiddlewares(strings.ToUpper(method), middlewares)
+	return group
+}
+
+// addMiddlewares 方法添加只对请求方法 method 生效（为空时对所有请求方法生效）的中间件，
+// 并重新计算分组中已注册的路由的中间件链
+func (group *RouterGroup) addMiddlewares(method string, middlewares []HandlerFunc) {
 	group.middlewares = append(group.middlewares, middlewares...)
+	for range middlewares {
+		group.methods = append(group.methods, method)
+	}
+	if group.hasRoutes() {
+		log.Printf("[WARNING] Use called on group %q after routes were registered, rebuilding handler chains", group.host+group.prefix)
+		group.engine.mu.Lock()
+		defer group.engine.mu.Unlock()
+		for _, route := range group.engine.routes {
+			if route.group.inherits(group) {
+				route.rebuild()
+			}
+		}
+	}
+}
+
+// middlewaresFor 方法返回 group 中对请求方法 method 生效的中间件
+func (group *RouterGroup) middlewaresFor(method string) []HandlerFunc {
+	handlers := make([]HandlerFunc, 0, len(group.middlewares))
+	for i, middleware := range group.middlewares {
+		if group.methods[i] == "" || group.methods[i] == method {
+			handlers = append(handlers, middleware)
+		}
+	}
+	return handlers
 }
 
-//  addRoute 方法把路由（由请求方法和路由地址构成）和处理方法注册到路由映射表 router 中
-func (group *RouterGroup) addRoute(method string, comp string, handler HandlerFunc) {
+// combineHandlers 方法按从外到内的顺序收集 group 及其所有上层分组对请求方法 method 生效的中间件，
+// 并在末尾加上 handler，得到路由最终的处理函数链
+func (group *RouterGroup) combineHandlers(method string, handler HandlerFunc) []HandlerFunc {
+	var groups []*RouterGroup
+	for g := group; g != nil; g = g.parent {
+		groups = append(groups, g)
+	}
+	handlers := make([]HandlerFunc, 0)
+	for i := len(groups) - 1; i >= 0; i-- {
+		handlers = append(handlers, groups[i].middlewaresFor(method)...)
+	}
+	return append(handlers, handler)
+}
+
+//  addRoute 方法把路由（由请求方法和路由地址构成）和处理函数链注册到路由映射表 router 中
+func (group *RouterGroup) addRoute(method string, comp string, handler HandlerFunc) *Route {
+	group.engine.checkMutable("route registration")
+	group.engine.checkOwner("route registration")
+	group.checkConflicts(method, group.prefix+comp)
+	return group.register(method, comp, handler)
+}
+
+// checkConflicts 方法在 pattern 与已注册的路由注册到同一节点时 panic，如先后注册 /hello/:name 和 /hello/:id，
+// 后注册的路由会覆盖先注册的路由。只有 Engine.AddRoute 允许在运行期间有意覆盖路由
+func (group *RouterGroup) checkConflicts(method string, pattern string) {
+	if validatePattern(pattern) != nil {
+		// 不合法的路由地址由 register 报告
+		return
+	}
+	for _, existing := range group.engine.routes {
+		if existing.Method != method || existing.Host != group.host || existing.Pattern == pattern {
+			continue
+		}
+		part, other, ok := wildcardConflict(pattern, existing.Pattern)
+		if !ok {
+			continue
+		}
+		if part != "" {
+			panic(fmt.Sprintf("zinc: route %s %s%s conflicts with existing route %s%s: %q is named %q there", method, group.host, pattern, existing.Host, existing.Pattern, part, other))
+		}
+		panic(fmt.Sprintf("zinc: route %s %s%s conflicts with existing route %s%s", method, group.host, pattern, existing.Host, existing.Pattern))
+	}
+}
+
+// register 方法注册路由，不检查路由表是否已冻结，由 addRoute 和 Engine.AddRoute 调用
+func (group *RouterGroup) register(method string, comp string, handler HandlerFunc) *Route {
 	// 加上分组的前缀 group.prefix 组成 pattern
 	pattern := group.prefix + comp
-	log.Printf("Route %4s - %s", method, pattern)
-	group.engine.router.addRoute(method, pattern, handler)
+	// 不合法的路由地址在注册时 panic，而不是在请求时匹配错误
+	if err := validatePattern(pattern); err != nil {
+		panic(fmt.Sprintf("zinc: invalid route %s %s%s: %v", method, group.host, pattern, err))
+	}
+	for _, existing := range group.engine.routes {
+		if existing.Method == method && existing.Host == group.host && existing.Pattern == pattern {
+			panic(fmt.Sprintf("zinc: route %s %s%s is already registered", method, group.host, pattern))
+		}
+	}
+	log.Printf("Route %4s - %s%s", method, group.host, pattern)
+	// 注册时即计算好中间件链，请求时无需再遍历分组
+	n := group.engine.routerFor(group.host).addRoute(method, pattern, group.combineHandlers(method, handler))
+	// 新路由可能比缓存的查找结果优先级更高
+	group.engine.invalidateRouteCache()
+	route := &Route{Method: method, Host: group.host, Pattern: pattern, node: n, group: group, handler: handler}
+	n.route = route
+	group.engine.routes = append(group.engine.routes, route)
+	return route
+}
+
+// anyMethods 是 Any 方法注册的所有标准请求方法
+var anyMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace,
+}
+
+// Handle 方法以任意请求方法 method 注册路由，可用于 WebDAV 等自定义方法（如 PROPFIND）
+func (group *RouterGroup) Handle(method string, pattern string, handler HandlerFunc) *Route {
+	if method == "" {
+		panic("zinc: HTTP method can not be empty")
+	}
+	return group.addRoute(method, pattern, handler)
+}
+
+// Any 方法为 pattern 一次性注册所有标准请求方法，返回 group 本身以便链式调用
+func (group *RouterGroup) Any(pattern string, handler HandlerFunc) *RouterGroup {
+	for _, method := range anyMethods {
+		group.addRoute(method, pattern, handler)
+	}
+	return group
 }
 
 // GET 方法把请求方法为"GET"的请求和相应处理方法 addRoute
-func (group *RouterGroup) GET(pattern string, handler HandlerFunc) {
-	group.addRoute("GET", pattern, handler)
+func (group *RouterGroup) GET(pattern string, handler HandlerFunc) *Route {
+	return group.addRoute("GET", pattern, handler)
 }
 
 // POST 方法把请求方法为"POST"的请求和相应处理方法 addRoute
-func (group *RouterGroup) POST(pattern string, handler HandlerFunc) {
-	group.addRoute("POST", pattern, handler)
+func (group *RouterGroup) POST(pattern string, handler HandlerFunc) *Route {
+	return group.addRoute("POST", pattern, handler)
 }
 
 // createStaticHandler 方法创建静态文件处理器
@@ -87,10 +329,17 @@ func (group *RouterGroup) createStaticHandler(relativePath string, fs http.FileS
 	return func(c *Context) {
 		file := c.Param("filepath")
 		// 检查文件是否存在，是否有权访问它
-		if _, err := fs.Open(file); err != nil {
+		f, err := fs.Open(file)
+		if err != nil {
 			c.Status(http.StatusNotFound)
 			return
 		}
+		defer f.Close()
+		// 普通文件由 serveContent 处理 ETag 和 If-Range，目录（列表、index.html）仍交给 http.FileServer
+		if stat, err := f.Stat(); err == nil && !stat.IsDir() {
+			c.serveContent(stat.Name(), f, stat)
+			return
+		}
 		// 调用(http.Handler).ServeHTTP 方法响应HTTP请求。
 		fileServer.ServeHTTP(c.Writer, c.Req)
 	}
@@ -101,7 +350,8 @@ func (group *RouterGroup) createStaticHandler(relativePath string, fs http.FileS
 //
 // 如：(*RouterGroup).Static("/assets", "/usr/zincRe/blog/static")；
 // 用户访问`/assets/js/zincRe.js`，最终返回`/usr/zincRe/blog/static/js/zincRe.js`。
-func (group *RouterGroup) Static(relativePath string, root string) {
+// 返回 group 本身以便链式调用。
+func (group *RouterGroup) Static(relativePath string, root string) *RouterGroup {
 	// 创建静态文件处理器 handler
 	// http.Dir() 方法会返回 http.Dir 类型用于 将字符串路径转换为文件系统。
 	// Dir类型实现了 http.FileSystem 接口。
@@ -110,6 +360,22 @@ func (group *RouterGroup) Static(relativePath string, root string) {
 	urlPattern := path.Join(relativePath, "/*filepath")
 	// 注册 GET方法路由，将 relativePath/*filepath 与 handler 绑定。
 	group.GET(urlPattern, handler)
+	return group
+}
+
+// NoRoute 方法设置路由匹配失败时的处理函数链，用于渲染自定义的 404 页面。
+// 处理函数链在全局中间件之后执行，未设置时返回默认的 404 文本。
+func (engine *Engine) NoRoute(handlers ...HandlerFunc) {
+	engine.checkMutable("NoRoute")
+	engine.noRoute = handlers
+}
+
+// Pre 方法注册在路由匹配之前执行的处理函数（如 Rewrite），它们可以修改请求路径以影响路由结果。
+// 这些处理函数按注册顺序依次执行，其中任一函数已写出响应（如调用了 Fail）或调用了 Abort 时不再继续路由。
+// MaxQueryLength、MaxHeaderBytes 等请求大小限制和 RejectDuplicates 的检查在这些处理函数之前执行。
+func (engine *Engine) Pre(handlers ...HandlerFunc) {
+	engine.checkMutable("Pre")
+	engine.preHandlers = append(engine.preHandlers, handlers...)
 }
 
 // SetFuncMap 方法设置自定义渲染函数
@@ -119,30 +385,107 @@ func (engine *Engine) SetFuncMap(funcMap template.FuncMap) {
 
 // LoadHTMLGlob 方法加载模板
 func (engine *Engine) LoadHTMLGlob(pattern string) {
-	engine.htmlTemplates = template.Must(template.New("").Funcs(engine.funcMap).ParseGlob(pattern))
+	engine.htmlTemplates = template.Must(template.New("").Funcs(engine.funcMap).Funcs(engine.contextFuncPlaceholders()).ParseGlob(pattern))
+	engine.boundTemplates = nil
+	if len(engine.contextFuncs) > 0 {
+		engine.boundTemplates = newBoundTemplatePool(engine.htmlTemplates, engine.contextFuncs)
+	}
 }
 
-// Run 方法启动一个 http 服务器
+// Run 方法冻结路由表（见 Freeze）并启动一个 http 服务器
 func (engine *Engine) Run(addr string) (err error) {
-	return http.ListenAndServe(addr, engine)
+	engine.Freeze()
+	server := &http.Server{Addr: addr, Handler: engine}
+	engine.mu.Lock()
+	engine.server = server
+	engine.mu.Unlock()
+	return server.ListenAndServe()
+}
+
+// normalizePath 方法按 RemoveExtraSlash 和 CleanPath 选项规范路径 p，保留末尾的斜杠
+func (engine *Engine) normalizePath(p string) string {
+	if engine.CleanPath {
+		cleaned := path.Clean("/" + p)
+		if strings.HasSuffix(p, "/") && cleaned != "/" {
+			cleaned += "/"
+		}
+		return cleaned
+	}
+	if engine.RemoveExtraSlash && strings.Contains(p, "//") {
+		var b strings.Builder
+		for i := 0; i < len(p); i++ {
+			if p[i] == '/' && i > 0 && p[i-1] == '/' {
+				continue
+			}
+			b.WriteByte(p[i])
+		}
+		return b.String()
+	}
+	return p
 }
 
-// ServeHTTP 方法构造初始化一个Context对象；
-// Context对象保存所有适用于当前请求的中间件；
-// Context对象作为engine调用router.handle方法的参数。
+// ServeHTTP 方法从对象池取出并初始化一个Context对象，请求结束后放回；
+// Context对象作为engine调用router.match方法的参数，由router.match设置匹配到的处理函数链，再依次执行。
 func (engine *Engine) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	// 当前请求适用的中间件列表
-	var middlewares []HandlerFunc
-	// 遍历所有分组
-	for _, group := range engine.groups {
-		// 若此 group.prefix 为 URL.Path 的前缀
-		if strings.HasPrefix(req.URL.Path, group.prefix) {
-			// 当前请求适用于此 group 分组的所有中间件
-			middlewares = append(middlewares, group.middlewares...)
+	c := engine.pool.Get().(*Context)
+	c.reset(w, req)
+	defer func() {
+		engine.releaseStream(c)
+		engine.pool.Put(c)
+	}()
+	// 在解析查询参数和执行 Pre 处理函数之前检查请求的大小
+	if code := engine.checkLimits(req); code != 0 {
+		c.Fail(code, http.StatusText(code))
+		return
+	}
+
+	// 按 RejectDuplicates 拒绝带有重复参数的请求
+	if key := engine.duplicateKey(req); key != "" {
+		c.Fail(http.StatusBadRequest, "Bad Request: duplicate parameter "+key)
+		return
+	}
+
+	// 执行路由匹配之前的处理函数
+	for _, handler := range engine.preHandlers {
+		handler(c)
+		// 已经写出响应或调用了 Abort，不再继续路由
+		if c.StatusCode != 0 || c.IsAborted() {
+			return
 		}
 	}
-	c := newContext(w, req)
-	c.handlers = middlewares
-	c.engine = engine
-	engine.router.handle(c)
+	c.index = -1
+
+	// 规范请求路径
+	if cleaned := engine.normalizePath(c.Path); cleaned != c.Path {
+		if engine.RedirectCleanPath && (c.Method == http.MethodGet || c.Method == http.MethodHead) {
+			if req.URL.RawQuery != "" {
+				cleaned += "?" + req.URL.RawQuery
+			}
+			http.Redirect(w, req, cleaned, http.StatusMovedPermanently)
+			return
+		}
+		c.Path = cleaned
+	}
+
+	// 匹配路由时持有读锁，避免与运行时注册、删除路由并发
+	owner := engine.owner()
+	owner.mu.RLock()
+	// 按请求的 Host 选择路由结构
+	r, subdomain := owner.matchRouter(c)
+	redirect := r.match(c)
+	owner.mu.RUnlock()
+	if subdomain != "" {
+		c.Params.set(r.subdomainParam, subdomain)
+	}
+	// 克隆的 Engine 通过 Use 添加的中间件在共享的处理函数链之前执行
+	if engine.origin != nil && len(engine.RouterGroup.middlewares) > 0 {
+		own := engine.RouterGroup.middlewaresFor(c.Method)
+		c.handlers = append(own[:len(own):len(own)], c.handlers...)
+	}
+
+	if redirect != "" {
+		http.Redirect(c.Writer, c.Req, redirect, http.StatusMovedPermanently)
+		return
+	}
+	c.Next()
 }
\ No newline at end of file
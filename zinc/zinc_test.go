@@ -0,0 +1,4523 @@
+package zinc
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// performRequest 向 engine 发送一个测试请求并返回响应记录
+func performRequest(engine *Engine, method, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestNoRoute(t *testing.T) {
+	e := New()
+	global := 0
+	e.Use(func(c *Context) {
+		global++
+		c.Next()
+	})
+	g1 := e.Group("/g1")
+	g1.Use(func(c *Context) {
+		t.Fatal("group middleware shouldn't run for unmatched route")
+	})
+
+	w := performRequest(e, "GET", "/g1/missing")
+	if w.Code != http.StatusNotFound || global != 1 {
+		t.Fatalf("default 404 should run global middleware, got code %d", w.Code)
+	}
+
+	e.NoRoute(func(c *Context) {
+		c.JSON(http.StatusNotFound, H{"message": "not found"})
+	})
+	w = performRequest(e, "GET", "/missing")
+	if w.Code != http.StatusNotFound || w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("NoRoute handlers should render the response, got %q", w.Body.String())
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	e := New()
+	e.Pre(Rewrite(StripPrefix("/v1"), MapPath("/old/:id", "/new/:id"), DefaultQueryParam("page", "1")))
+	e.GET("/new/:id", func(c *Context) {
+		c.String(http.StatusOK, "%s-%s", c.Param("id"), c.Query("page"))
+	})
+
+	w := performRequest(e, "GET", "/v1/old/42")
+	if w.Code != http.StatusOK || w.Body.String() != "42-1" {
+		t.Fatalf("rewritten request should reach /new/:id, got %d %q", w.Code, w.Body.String())
+	}
+
+	constrained := New()
+	constrained.Pre(Rewrite(MapPath("/legacy/:id<int>", "/new/:id"), MapPath("/assets/v1/*file", "/static/*file")))
+	constrained.GET("/new/:id", func(c *Context) {
+		c.String(http.StatusOK, c.Param("id"))
+	})
+	for path, want := range map[string]int{"/legacy/42": http.StatusOK, "/legacy/abc": http.StatusNotFound, "/assets": http.StatusNotFound} {
+		if w := performRequest(constrained, "GET", path); w.Code != want {
+			t.Fatalf("%s: want %d, got %d", path, want, w.Code)
+		}
+	}
+	if w := performRequest(constrained, "GET", "/legacy/42"); w.Body.String() != "42" {
+		t.Fatalf("constrained parameter should be expanded by name, got %q", w.Body.String())
+	}
+}
+
+func TestTransformResponse(t *testing.T) {
+	e := New()
+	e.Use(TransformResponse(func(c *Context, body []byte) []byte {
+		return append([]byte("<!-- toolbar -->"), body...)
+	}, "text/html"))
+	e.GET("/page", func(c *Context) {
+		c.SetHeader("Content-Type", "text/html")
+		c.Data(http.StatusOK, []byte("<p>page</p>"))
+	})
+	e.GET("/text", func(c *Context) {
+		c.String(http.StatusOK, "text")
+	})
+
+	if w := performRequest(e, "GET", "/page"); w.Body.String() != "<!-- toolbar --><p>page</p>" {
+		t.Fatalf("html response should be transformed, got %q", w.Body.String())
+	}
+	if w := performRequest(e, "GET", "/text"); w.Body.String() != "text" {
+		t.Fatalf("text response shouldn't be transformed, got %q", w.Body.String())
+	}
+}
+
+func TestEnvelope(t *testing.T) {
+	e := New()
+	legacy := e.Group("/legacy")
+	legacy.Use(Envelope(LegacyEnvelope))
+	legacy.GET("/user", func(c *Context) {
+		c.JSON(http.StatusOK, H{"name": "zinc"})
+	})
+	legacy.GET("/fail", func(c *Context) {
+		c.Fail(http.StatusBadRequest, "bad")
+	})
+
+	if w := performRequest(e, "GET", "/legacy/user"); w.Body.String() != `{"code":0,"data":{"name":"zinc"},"msg":""}`+"\n" {
+		t.Fatalf("JSON should be wrapped in envelope, got %q", w.Body.String())
+	}
+	if w := performRequest(e, "GET", "/legacy/fail"); w.Body.String() != `{"code":400,"data":null,"msg":"bad"}`+"\n" {
+		t.Fatalf("error should be wrapped in envelope, got %q", w.Body.String())
+	}
+}
+
+func TestJSONWithETag(t *testing.T) {
+	e := New()
+	e.GET("/items", func(c *Context) {
+		c.JSONWithETag(http.StatusOK, H{"items": []int{1, 2, 3}})
+	})
+
+	w := performRequest(e, "GET", "/items")
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" {
+		t.Fatal("first request should return 200 with an ETag")
+	}
+
+	req := httptest.NewRequest("GET", "/items", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Fatalf("matching If-None-Match should return 304 without body, got %d", w.Code)
+	}
+}
+
+func TestCaseInsensitive(t *testing.T) {
+	e := New()
+	e.GET("/api/users/:name", func(c *Context) {
+		c.String(http.StatusOK, c.Param("name"))
+	})
+
+	if w := performRequest(e, "GET", "/API/Users/Zinc"); w.Code != http.StatusNotFound {
+		t.Fatal("matching should be case sensitive by default")
+	}
+
+	e.CaseInsensitive = true
+	if w := performRequest(e, "GET", "/API/Users/Zinc"); w.Body.String() != "Zinc" {
+		t.Fatalf("case insensitive matching should keep param case, got %q", w.Body.String())
+	}
+
+	e.RedirectCanonicalCase = true
+	w := performRequest(e, "GET", "/API/Users/Zinc?x=1")
+	if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/api/users/Zinc?x=1" {
+		t.Fatalf("should redirect to canonical path, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+}
+
+func TestOnMethods(t *testing.T) {
+	e := New()
+	e.Use(OnMethods([]string{"POST"}, func(c *Context) {
+		c.Fail(http.StatusForbidden, "forbidden")
+	}))
+	e.Any("/res", func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	if w := performRequest(e, "GET", "/res"); w.Code != http.StatusOK {
+		t.Fatalf("GET shouldn't run the middleware, got %d", w.Code)
+	}
+	if w := performRequest(e, "POST", "/res"); w.Code != http.StatusForbidden {
+		t.Fatalf("POST should run the middleware, got %d", w.Code)
+	}
+}
+
+func TestGroupMiddlewareChain(t *testing.T) {
+	e := New()
+	g1 := e.Group("/g1")
+	g1.Use(func(c *Context) {
+		c.Fail(http.StatusForbidden, "g1 only")
+	})
+	g1.GET("/foo", func(c *Context) {
+		c.String(http.StatusOK, "g1")
+	})
+	e.GET("/g1x/foo", func(c *Context) {
+		c.String(http.StatusOK, "g1x")
+	})
+
+	if w := performRequest(e, "GET", "/g1/foo"); w.Code != http.StatusForbidden {
+		t.Fatalf("/g1/foo should run g1 middleware, got %d", w.Code)
+	}
+	if w := performRequest(e, "GET", "/g1x/foo"); w.Code != http.StatusOK {
+		t.Fatalf("/g1x/foo shouldn't inherit g1 middleware, got %d", w.Code)
+	}
+}
+
+func TestRouteConsumes(t *testing.T) {
+	type createUserReq struct {
+		Name string `json:"name"`
+	}
+	e := New()
+	e.POST("/users", func(c *Context) {
+		c.String(http.StatusOK, "created")
+	}).Consumes(createUserReq{}).Produces(H{})
+	e.POST("/users/validated", func(c *Context) {
+		var req createUserReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.String(http.StatusOK, req.Name)
+	}).Consumes(createUserReq{}).Validate()
+	e.MaxBodyBytes = 32
+
+	req := httptest.NewRequest("POST", "/users", strings.NewReader(`{"name":"zinc","admin":true}`))
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Consumes alone should not validate, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest("POST", "/users/validated", strings.NewReader(`{"name":"zinc","admin":true}`))
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unknown field should be rejected, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest("POST", "/users/validated", strings.NewReader(`{"name":"zinc"}`))
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != "zinc" {
+		t.Fatalf("valid body should reach the handler, got %d %q", w.Code, w.Body.String())
+	}
+
+	// 未声明长度的请求体也不能超过 MaxBodyBytes
+	req = httptest.NewRequest("POST", "/users/validated", io.MultiReader(strings.NewReader(`{"name":"`), strings.NewReader(strings.Repeat("z", 64)+`"}`)))
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("oversized body should be rejected, got %d", w.Code)
+	}
+}
+
+func TestHost(t *testing.T) {
+	e := New()
+	e.GET("/", func(c *Context) {
+		c.String(http.StatusOK, "site")
+	})
+	api := e.Host("api.example.com")
+	api.GET("/", func(c *Context) {
+		c.String(http.StatusOK, "api")
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Host = "API.example.com:8080"
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Body.String() != "api" {
+		t.Fatalf("api host should match api routes, got %q", w.Body.String())
+	}
+
+	if w := performRequest(e, "GET", "/"); w.Body.String() != "site" {
+		t.Fatalf("other hosts should match default routes, got %q", w.Body.String())
+	}
+}
+
+func TestCheck(t *testing.T) {
+	e := New()
+	e.GET("/hello/:name", func(c *Context) {})
+	e.AddRoute("GET", "/hello/:id", func(c *Context) {})
+	e.Group("/empty")
+
+	kinds := make(map[string]bool)
+	for _, d := range e.Check() {
+		kinds[d.Kind] = true
+	}
+	if !kinds["conflict"] || !kinds["empty-group"] {
+		t.Fatalf("Check should report conflict and empty-group, got %v", e.Check())
+	}
+}
+
+func TestUseAfterRoutes(t *testing.T) {
+	e := New()
+	g := e.Group("/g")
+	g.GET("/foo", func(c *Context) {
+		c.String(http.StatusOK, "foo")
+	})
+	e.Use(func(c *Context) {
+		c.Fail(http.StatusForbidden, "late")
+	})
+
+	if w := performRequest(e, "GET", "/g/foo"); w.Code != http.StatusForbidden {
+		t.Fatalf("middleware added after routes should be applied retroactively, got %d", w.Code)
+	}
+}
+
+func TestRuntimeRoutes(t *testing.T) {
+	e := New()
+	e.GET("/hello/:id", func(c *Context) {
+		c.String(http.StatusOK, "id")
+	})
+	e.AddRoute("GET", "/hello/:name", func(c *Context) {
+		c.String(http.StatusOK, "name")
+	})
+	e.AddRoute("GET", "/plugin", func(c *Context) {
+		c.String(http.StatusOK, "plugin")
+	})
+
+	if w := performRequest(e, "GET", "/plugin"); w.Body.String() != "plugin" {
+		t.Fatal("route added at runtime should be served")
+	}
+	if !e.RemoveRoute("GET", "/plugin") || e.RemoveRoute("GET", "/plugin") {
+		t.Fatal("route should be removed exactly once")
+	}
+	if w := performRequest(e, "GET", "/plugin"); w.Code != http.StatusNotFound {
+		t.Fatal("removed route shouldn't be served")
+	}
+
+	e.RemoveRoute("GET", "/hello/:name")
+	if w := performRequest(e, "GET", "/hello/1"); w.Body.String() != "id" {
+		t.Fatalf("removing a route should restore the shadowed one, got %q", w.Body.String())
+	}
+}
+
+func TestVirtualHost(t *testing.T) {
+	api, site := New(), New()
+	api.GET("/", func(c *Context) {
+		c.String(http.StatusOK, "api")
+	})
+	site.GET("/", func(c *Context) {
+		c.String(http.StatusOK, "site")
+	})
+	vh := NewVirtualHost()
+	vh.Handle("api.example.com", api)
+	vh.Handle("*.example.com", site)
+
+	for host, want := range map[string]string{"api.example.com": "api", "www.example.com": "site"} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Host = host
+		w := httptest.NewRecorder()
+		vh.ServeHTTP(w, req)
+		if w.Body.String() != want {
+			t.Fatalf("host %s should be served by %s, got %q", host, want, w.Body.String())
+		}
+	}
+}
+
+func TestHeadFallbackToGet(t *testing.T) {
+	e := New()
+	e.GET("/file", func(c *Context) {
+		c.String(http.StatusOK, "hello")
+	})
+
+	if w := performRequest(e, "HEAD", "/file"); w.Code != http.StatusNotFound {
+		t.Fatalf("HEAD shouldn't fall back by default, got %d", w.Code)
+	}
+
+	e.HeadFallbackToGet = true
+	w := performRequest(e, "HEAD", "/file")
+	if w.Code != http.StatusOK || w.Body.Len() != 0 || w.Header().Get("Content-Length") != "5" {
+		t.Fatalf("HEAD should use GET handler without body, got %d %q %q", w.Code, w.Body.String(), w.Header().Get("Content-Length"))
+	}
+}
+
+func TestUseRawPath(t *testing.T) {
+	e := New()
+	e.UseRawPath = true
+	e.UnescapePathValues = true
+	e.GET("/g1/hello/:name", func(c *Context) {
+		c.String(http.StatusOK, "%s", c.Param("name"))
+	})
+
+	if w := performRequest(e, "GET", "/g1/hello/John%2FDoe"); w.Body.String() != "John/Doe" {
+		t.Fatalf("escaped slash should be matched as a single param, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestCleanPath(t *testing.T) {
+	e := New()
+	e.CleanPath = true
+	e.GET("/g1/hello/:name", func(c *Context) {
+		c.String(http.StatusOK, "%s", c.Path)
+	})
+
+	if w := performRequest(e, "GET", "/g1/./x/../hello/zinc"); w.Body.String() != "/g1/hello/zinc" {
+		t.Fatalf("dot segments should be cleaned, got %d %q", w.Code, w.Body.String())
+	}
+
+	e.RedirectCleanPath = true
+	w := performRequest(e, "GET", "//g1//hello/zinc")
+	if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/g1/hello/zinc" {
+		t.Fatalf("should redirect to clean path, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	e := New()
+	e.Use(Timeout(20 * time.Millisecond))
+	e.GET("/slow", func(c *Context) {
+		select {
+		case <-c.Req.Context().Done():
+		case <-time.After(time.Second):
+		}
+		c.String(http.StatusOK, "slow")
+	})
+	e.GET("/fast", func(c *Context) {
+		if !c.HasBudget(time.Millisecond) {
+			t.Error("fast handler should have budget left")
+		}
+		c.String(http.StatusOK, "fast")
+	})
+
+	if w := performRequest(e, "GET", "/slow"); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("slow handler should time out, got %d", w.Code)
+	}
+	if w := performRequest(e, "GET", "/fast"); w.Code != http.StatusOK || w.Body.String() != "fast" {
+		t.Fatalf("fast handler should respond, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestSubdomain(t *testing.T) {
+	e := New()
+	e.Subdomain("admin").GET("/", func(c *Context) {
+		c.String(http.StatusOK, "admin")
+	})
+	e.Subdomain(":tenant").GET("/", func(c *Context) {
+		c.String(http.StatusOK, "tenant %s", c.Param("tenant"))
+	})
+
+	for host, want := range map[string]string{"admin.example.com": "admin", "acme.example.com": "tenant acme"} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Host = host
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		if w.Body.String() != want {
+			t.Fatalf("host %s should be served by %q, got %q", host, want, w.Body.String())
+		}
+	}
+
+	e = New()
+	e.BaseDomain = "example.co.uk"
+	e.GET("/", func(c *Context) {
+		c.String(http.StatusOK, "default")
+	})
+	e.Subdomain("").GET("/", func(c *Context) {
+		c.String(http.StatusOK, "apex")
+	})
+	e.Subdomain("eu.admin").GET("/", func(c *Context) {
+		c.String(http.StatusOK, "eu admin")
+	})
+	e.Subdomain(":tenant").GET("/", func(c *Context) {
+		c.String(http.StatusOK, "tenant %s", c.Param("tenant"))
+	})
+	for host, want := range map[string]string{
+		"example.co.uk":          "apex",
+		"eu.admin.example.co.uk": "eu admin",
+		"acme.example.co.uk":     "tenant acme",
+		"acme.example.com":       "default",
+	} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Host = host
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		if w.Body.String() != want {
+			t.Fatalf("host %s should be served by %q, got %q", host, want, w.Body.String())
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("subdomain groups with different param names should panic")
+		}
+	}()
+	e.Subdomain(":org")
+}
+
+func TestStrictRequests(t *testing.T) {
+	counters := &StrictCounters{}
+	pre := 0
+	e := New()
+	e.MaxHeaderCount = 8
+	e.Pre(func(c *Context) {
+		pre++
+	}, StrictRequests(StrictConfig{Counters: counters}))
+	e.POST("/upload", func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	perform := func(setup func(req *http.Request)) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/upload", strings.NewReader("data"))
+		setup(req)
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		return w
+	}
+
+	w := perform(func(req *http.Request) {
+		req.Header.Set("Content-Length", "4")
+		req.TransferEncoding = []string{"chunked"}
+	})
+	if w.Code != http.StatusBadRequest || w.Header().Get("Connection") != "close" {
+		t.Fatalf("conflicting framing should be rejected, got %d", w.Code)
+	}
+	w = perform(func(req *http.Request) {
+		req.Header["Content-Length"] = []string{"4", "5"}
+	})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("differing Content-Length should be rejected, got %d", w.Code)
+	}
+	w = perform(func(req *http.Request) {
+		req.Header.Set("Transfer-Encoding", "gzip, chunked")
+	})
+	if w.Code != http.StatusNotImplemented {
+		t.Fatalf("unknown transfer coding should be rejected, got %d", w.Code)
+	}
+	w = perform(func(req *http.Request) {
+		req.Header["Bad Header"] = []string{"x"}
+	})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("malformed header should be rejected, got %d", w.Code)
+	}
+	w = perform(func(req *http.Request) {
+		for i := 0; i < 10; i++ {
+			req.Header.Set("X-Extra-"+string(rune('a'+i)), "1")
+		}
+	})
+	if w.Code != http.StatusRequestHeaderFieldsTooLarge || pre != 4 {
+		t.Fatalf("too many headers should be rejected before the pre handlers, got %d after %d pre handler runs", w.Code, pre)
+	}
+	if w := perform(func(req *http.Request) {}); w.Code != http.StatusOK {
+		t.Fatalf("well-formed request should pass, got %d", w.Code)
+	}
+
+	got := counters.Load()
+	if got.ConflictingLength != 2 || got.UnknownTransferCoding != 1 || got.MalformedHeader != 1 {
+		t.Fatalf("unexpected counters %+v", got)
+	}
+}
+
+func TestDuplicatePolicy(t *testing.T) {
+	e := New()
+	e.GET("/role", func(c *Context) {
+		c.String(http.StatusOK, "%s", c.Query("role"))
+	})
+
+	if w := performRequest(e, "GET", "/role?role=user&role=admin"); w.Body.String() != "user" {
+		t.Fatalf("first value should win by default, got %q", w.Body.String())
+	}
+	e.QueryDuplicates = LastWins
+	if w := performRequest(e, "GET", "/role?role=user&role=admin"); w.Body.String() != "admin" {
+		t.Fatalf("last value should win, got %q", w.Body.String())
+	}
+	e.QueryDuplicates = RejectDuplicates
+	if w := performRequest(e, "GET", "/role?role=user&role=admin"); w.Code != http.StatusBadRequest {
+		t.Fatalf("duplicate query params should be rejected, got %d", w.Code)
+	}
+	if w := performRequest(e, "GET", "/role?role=user"); w.Body.String() != "user" {
+		t.Fatalf("single query param should pass, got %q", w.Body.String())
+	}
+
+	e.HeaderDuplicates = RejectDuplicates
+	req := httptest.NewRequest("GET", "/role?role=user", nil)
+	req.Header.Add("Accept", "text/html")
+	req.Header.Add("Accept", "application/json")
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("repeated list headers should pass, got %d", w.Code)
+	}
+	req.Header.Add("X-Role", "user")
+	req.Header.Add("X-Role", "admin")
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("duplicate headers should be rejected, got %d", w.Code)
+	}
+}
+
+func TestCompress(t *testing.T) {
+	e := New()
+	e.Use(Compress(CompressConfig{BreachMitigation: true}))
+	e.GET("/text", func(c *Context) {
+		c.String(http.StatusOK, "%s", strings.Repeat("zinc ", 100))
+	})
+	e.GET("/raw", func(c *Context) {
+		c.String(http.StatusOK, "raw")
+	}).NoCompress()
+	e.GET("/events", func(c *Context) {
+		c.SetHeader("Content-Type", "text/event-stream")
+		c.Status(http.StatusOK)
+		c.Writer.Write([]byte("data: tick\n\n"))
+	})
+	e.GET("/login", func(c *Context) {
+		c.SetHeader("Set-Cookie", "session=secret")
+		c.String(http.StatusOK, "welcome")
+	})
+
+	perform := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		return w
+	}
+
+	w := perform("/text")
+	if w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatal("/text should be compressed")
+	}
+	reader, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(reader)
+	if string(body) != strings.Repeat("zinc ", 100) {
+		t.Fatalf("unexpected decompressed body %q", body)
+	}
+
+	for _, path := range []string{"/raw", "/events", "/login"} {
+		if w := perform(path); w.Header().Get("Content-Encoding") != "" {
+			t.Fatalf("%s shouldn't be compressed", path)
+		}
+	}
+	if w := performRequest(e, "GET", "/text"); w.Header().Get("Content-Encoding") != "" {
+		t.Fatal("response shouldn't be compressed without Accept-Encoding")
+	}
+}
+
+func TestRouteCache(t *testing.T) {
+	e := New()
+	e.RouteCacheSize = 2
+	e.GET("/users/:id", func(c *Context) {
+		c.String(http.StatusOK, "user %s", c.Param("id"))
+	})
+
+	for i := 0; i < 2; i++ {
+		if w := performRequest(e, "GET", "/users/42"); w.Body.String() != "user 42" {
+			t.Fatalf("cached lookup should keep params, got %q", w.Body.String())
+		}
+	}
+	performRequest(e, "GET", "/users/1")
+	performRequest(e, "GET", "/users/2")
+	if len(e.routeCache.entries) != 2 {
+		t.Fatalf("route cache should be bounded, got %d entries", len(e.routeCache.entries))
+	}
+
+	// 注册路由后缓存失效
+	e.AddRoute("GET", "/users/me", func(c *Context) {
+		c.String(http.StatusOK, "me")
+	})
+	if len(e.routeCache.entries) != 0 {
+		t.Fatal("registering a route should purge the route cache")
+	}
+	if w := performRequest(e, "GET", "/users/me"); w.Body.String() != "me" {
+		t.Fatalf("new static route should win, got %q", w.Body.String())
+	}
+
+	// 删除覆盖的路由后恢复先注册路由的参数名
+	e.AddRoute("GET", "/users/:name", func(c *Context) {
+		c.String(http.StatusOK, "name %s", c.Param("name"))
+	})
+	performRequest(e, "GET", "/users/7")
+	e.RemoveRoute("GET", "/users/:name")
+	if w := performRequest(e, "GET", "/users/7"); w.Body.String() != "user 7" {
+		t.Fatalf("restored route should see its own params, got %q", w.Body.String())
+	}
+}
+
+func TestMirror(t *testing.T) {
+	shadowed := make(chan string, 1)
+	shadow := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		shadowed <- req.Header.Get("X-Shadow-Request") + req.Header.Get("Authorization") + " " + string(body)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	e := New()
+	e.Use(Mirror(MirrorConfig{Percent: 100, Handler: shadow}))
+	e.POST("/orders", func(c *Context) {
+		body, _ := io.ReadAll(c.Req.Body)
+		c.String(http.StatusCreated, "%s", body)
+	})
+
+	req := httptest.NewRequest("POST", "/orders", strings.NewReader("order"))
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated || w.Body.String() != "order" {
+		t.Fatalf("primary response should be unaffected, got %d %q", w.Code, w.Body.String())
+	}
+	select {
+	case got := <-shadowed:
+		if got != "1 order" {
+			t.Fatalf("shadow request should carry the body without credentials, got %q", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("request should be mirrored")
+	}
+
+	// 镜像请求达到上限时丢弃新的镜像
+	release := make(chan struct{})
+	started := make(chan struct{}, 4)
+	var mirrored int64
+	e = New()
+	e.Use(Mirror(MirrorConfig{Percent: 100, MaxConcurrent: 1, Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt64(&mirrored, 1)
+		started <- struct{}{}
+		<-release
+	})}))
+	e.GET("/", func(c *Context) {})
+	for i := 0; i < 4; i++ {
+		performRequest(e, "GET", "/")
+	}
+	<-started
+	close(release)
+	if n := atomic.LoadInt64(&mirrored); n != 1 {
+		t.Fatalf("only one mirror should run at a time, got %d", n)
+	}
+}
+
+func TestPanicPolicy(t *testing.T) {
+	var reported []Severity
+	e := New()
+	e.PanicReporter = func(c *Context, err interface{}, stack string, severity Severity) {
+		reported = append(reported, severity)
+	}
+	e.Use(Recovery())
+	e.GET("/json", func(c *Context) {
+		panic("boom")
+	}).Critical()
+	pages := e.Group("/pages").OnPanic(PanicConfig{Policy: PanicHTML})
+	pages.GET("/home", func(c *Context) {
+		panic("boom")
+	})
+	e.Group("/supervised").OnPanic(PanicConfig{Policy: PanicRepanic}).GET("/job", func(c *Context) {
+		panic("boom")
+	})
+	e.Group("/stream").OnPanic(PanicConfig{Policy: PanicClose}).GET("/feed", func(c *Context) {
+		panic("boom")
+	})
+
+	if w := performRequest(e, "GET", "/json"); w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), "message") {
+		t.Fatalf("default policy should respond with JSON, got %d %q", w.Code, w.Body.String())
+	}
+	if w := performRequest(e, "GET", "/pages/home"); w.Code != http.StatusInternalServerError || w.Header().Get("Content-Type") != "text/plain" {
+		t.Fatalf("html policy without template should respond with text, got %d", w.Code)
+	}
+	for path, want := range map[string]interface{}{"/supervised/job": "boom", "/stream/feed": http.ErrAbortHandler} {
+		func() {
+			defer func() {
+				if got := recover(); got != want {
+					t.Fatalf("%s should panic with %v, got %v", path, want, got)
+				}
+			}()
+			performRequest(e, "GET", path)
+		}()
+	}
+	if len(reported) != 4 || reported[0] != SeverityCritical || reported[1] != SeverityError {
+		t.Fatalf("unexpected reported severities %v", reported)
+	}
+}
+
+func TestPanicHTMLHidesPanicValue(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "500.tmpl"), []byte("{{.status}} {{.error}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	e := New()
+	e.LoadHTMLGlob(filepath.Join(dir, "*"))
+	e.Use(Recovery())
+	e.Group("/pages").OnPanic(PanicConfig{Policy: PanicHTML, Template: "500.tmpl"}).GET("/home", func(c *Context) {
+		panic("db password is hunter2")
+	})
+	w := performRequest(e, "GET", "/pages/home")
+	if w.Code != http.StatusInternalServerError || w.Body.String() != "500 Internal Server Error" {
+		t.Fatalf("the error page should only show a generic message, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestRouteInfo(t *testing.T) {
+	e := New()
+	e.Use(func(c *Context) {
+		if auth, _ := c.RouteMeta("auth"); auth == true && c.Req.Header.Get("Authorization") == "" {
+			c.Fail(http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		c.Next()
+	})
+	e.GET("/admin/:section", func(c *Context) {
+		info := c.RouteInfo()
+		c.String(http.StatusOK, "%s %s", info.Name, info.Pattern)
+	}).Named("admin").Meta("auth", true)
+	e.GET("/public", func(c *Context) {
+		c.String(http.StatusOK, "public")
+	})
+
+	if w := performRequest(e, "GET", "/admin/users"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("route tagged with auth should require authorization, got %d", w.Code)
+	}
+	req := httptest.NewRequest("GET", "/admin/users", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Body.String() != "admin /admin/:section" {
+		t.Fatalf("unexpected route info %q", w.Body.String())
+	}
+	if w := performRequest(e, "GET", "/public"); w.Code != http.StatusOK {
+		t.Fatalf("untagged route should pass, got %d", w.Code)
+	}
+}
+
+func TestLintRoutes(t *testing.T) {
+	e := New()
+	e.GET("/hello/:name", func(c *Context) {})
+	e.AddRoute("GET", "/hello/:id", func(c *Context) {})
+	e.GET("/users/:id", func(c *Context) {})
+	e.GET("/users/:uid/posts", func(c *Context) {})
+	e.GET("/static/app.js", func(c *Context) {})
+	e.Host("api.example.com").GET("/static/*filepath", func(c *Context) {})
+	e.Group("/api").GET("users", func(c *Context) {})
+	e.Group("/v1").Group("admin").GET("/stats", func(c *Context) {})
+
+	kinds := make(map[string]int)
+	for _, d := range LintRoutes(e) {
+		kinds[d.Kind]++
+	}
+	want := map[string]int{"shadowed": 1, "param-name": 2, "catch-all-mask": 1, "group-prefix": 2}
+	for kind, count := range want {
+		if kinds[kind] != count {
+			t.Fatalf("LintRoutes should report %d %s, got %v", count, kind, LintRoutes(e))
+		}
+	}
+}
+
+func TestLintGlobalCatchAll(t *testing.T) {
+	e := New()
+	e.GET("/files/*filepath", func(c *Context) {
+		c.String(http.StatusOK, "files")
+	})
+	e.GET("/:section/readme", func(c *Context) {
+		c.String(http.StatusOK, "readme")
+	})
+	e.GET("/:id<int>/readme", func(c *Context) {})
+	e.GET("/files/index", func(c *Context) {})
+	e.Host("api.example.com").GET("/:section/meta", func(c *Context) {})
+
+	diagnostics := LintRoutes(e)
+	if len(diagnostics) != 1 || diagnostics[0].Kind != "catch-all-mask" || !strings.Contains(diagnostics[0].Message, "/:section/readme") {
+		t.Fatalf("the global catch-all should only mask /:section/readme, got %v", diagnostics)
+	}
+	if w := performRequest(e, "GET", "/files/readme"); w.Body.String() != "files" {
+		t.Fatalf("the catch-all should handle /files/readme, got %q", w.Body.String())
+	}
+}
+
+func TestFluentGroup(t *testing.T) {
+	e := New()
+	e.Group("/api").Use(func(c *Context) {
+		c.SetHeader("X-Group", "api")
+	}).GET("/me", func(c *Context) {
+		c.String(http.StatusOK, "me")
+	})
+
+	w := performRequest(e, "GET", "/api/me")
+	if w.Body.String() != "me" || w.Header().Get("X-Group") != "api" {
+		t.Fatalf("chained group should apply its middleware, got %q", w.Body.String())
+	}
+}
+
+func TestRequestLimits(t *testing.T) {
+	e := New()
+	e.MaxQueryLength = 32
+	e.MaxQueryParams = 3
+	e.MaxHeaderBytes = 64
+	e.GET("/search", func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	if w := performRequest(e, "GET", "/search?q=zinc&page=1"); w.Code != http.StatusOK {
+		t.Fatalf("request within limits should pass, got %d", w.Code)
+	}
+	if w := performRequest(e, "GET", "/search?q="+strings.Repeat("z", 40)); w.Code != http.StatusRequestURITooLong {
+		t.Fatalf("long query should be rejected, got %d", w.Code)
+	}
+	if w := performRequest(e, "GET", "/search?a=1&b=2&c=3&d=4"); w.Code != http.StatusRequestURITooLong {
+		t.Fatalf("too many query params should be rejected, got %d", w.Code)
+	}
+	req := httptest.NewRequest("GET", "/search", nil)
+	req.Header.Set("X-Large", strings.Repeat("z", 80))
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Code != http.StatusRequestHeaderFieldsTooLarge {
+		t.Fatalf("large headers should be rejected, got %d", w.Code)
+	}
+}
+
+func TestFreeze(t *testing.T) {
+	e := New()
+	e.GET("/ping", func(c *Context) {
+		c.String(http.StatusOK, "pong")
+	})
+	e.Freeze()
+
+	for name, register := range map[string]func(){
+		"GET": func() { e.GET("/late", func(c *Context) {}) },
+		"Use": func() { e.Use(Logger()) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("%s should panic after Freeze", name)
+				}
+			}()
+			register()
+		}()
+	}
+
+	// 冻结后通过 AddRoute 注册路由，与请求处理并发执行
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			performRequest(e, "GET", "/ping")
+		}
+	}()
+	e.AddRoute("GET", "/late", func(c *Context) {
+		c.String(http.StatusOK, "late")
+	})
+	<-done
+	if w := performRequest(e, "GET", "/late"); w.Body.String() != "late" {
+		t.Fatalf("AddRoute should still work after Freeze, got %q", w.Body.String())
+	}
+}
+
+func TestUseFor(t *testing.T) {
+	e := New()
+	var order []string
+	g := e.Group("/posts")
+	g.Use(func(c *Context) { order = append(order, "all") })
+	g.UseFor("post", func(c *Context) { order = append(order, "write") })
+	g.GET("", func(c *Context) { c.String(http.StatusOK, "list") })
+	g.POST("", func(c *Context) { c.String(http.StatusCreated, "create") })
+
+	performRequest(e, "GET", "/posts")
+	if strings.Join(order, ",") != "all" {
+		t.Fatalf("GET shouldn't run write middleware, got %v", order)
+	}
+	order = nil
+	performRequest(e, "POST", "/posts")
+	if strings.Join(order, ",") != "all,write" {
+		t.Fatalf("POST should run write middleware in order, got %v", order)
+	}
+}
+
+func TestRegisterInvalidRoute(t *testing.T) {
+	e := New()
+	e.GET("/users/:id", func(c *Context) {})
+	for pattern, want := range map[string]string{
+		"/a/*x/b":         "*x",
+		"/users/:id":      "already registered",
+		"/users/:uid":     `":uid" is named ":id" there`,
+		"/users/:id/":     "conflicts with existing route /users/:id",
+		"/users/:id<int>": "", // 约束不同的参数注册到不同的节点
+	} {
+		if want == "" {
+			e.GET(pattern, func(c *Context) {})
+			continue
+		}
+		func() {
+			defer func() {
+				if err := recover(); err == nil || !strings.Contains(err.(string), want) {
+					t.Fatalf("registering %s should panic mentioning %q, got %v", pattern, want, err)
+				}
+			}()
+			e.GET(pattern, func(c *Context) {})
+		}()
+	}
+}
+
+func TestSitemapAndRobots(t *testing.T) {
+	e := New()
+	e.GET("/", func(c *Context) {}).Named("home")
+	e.GET("/about", func(c *Context) {}).Named("about")
+	e.GET("/admin", func(c *Context) {}).Named("admin")
+	e.GET("/posts/:id", func(c *Context) {}).Named("post")
+	e.GET("/internal", func(c *Context) {})
+	e.Sitemap(SitemapOptions{
+		BaseURL: "https://example.com/",
+		Exclude: func(route *Route) bool { return route.Name == "admin" },
+		LastMod: func(route *Route) time.Time {
+			return time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+		},
+		Paths: func(route *Route) []string { return []string{"/posts/1", "/posts/2"} },
+	})
+	e.Robots(RobotsOptions{
+		Rules:   []RobotsRule{{Disallow: []string{"/admin"}}},
+		Sitemap: "https://example.com/sitemap.xml",
+	})
+
+	body := performRequest(e, "GET", "/sitemap.xml").Body.String()
+	for _, loc := range []string{"https://example.com/", "https://example.com/about", "https://example.com/posts/2"} {
+		if !strings.Contains(body, "<loc>"+loc+"</loc>") {
+			t.Fatalf("sitemap should contain %s, got %s", loc, body)
+		}
+	}
+	if strings.Contains(body, "admin") || strings.Contains(body, "internal") || !strings.Contains(body, "<lastmod>2024-01-02</lastmod>") {
+		t.Fatalf("unexpected sitemap %s", body)
+	}
+
+	want := "User-agent: *\nDisallow: /admin\n\nSitemap: https://example.com/sitemap.xml\n"
+	if got := performRequest(e, "GET", "/robots.txt").Body.String(); got != want {
+		t.Fatalf("unexpected robots.txt %q", got)
+	}
+}
+
+func TestContextAsContext(t *testing.T) {
+	type ctxKey struct{}
+	e := New()
+	e.ContextWithKeys = true
+	e.Use(func(c *Context) {
+		c.Req = c.Req.WithContext(context.WithValue(c.Req.Context(), ctxKey{}, "from-request"))
+		c.Keys = map[string]interface{}{"user": "zinc"}
+	})
+	e.GET("/ctx", func(c *Context) {
+		ctx, cancel := context.WithTimeout(c, time.Millisecond)
+		defer cancel()
+		<-ctx.Done()
+		c.String(http.StatusOK, "%v %v %v", ctx.Value(ctxKey{}), ctx.Value("user"), ctx.Err())
+	})
+
+	want := "from-request zinc context deadline exceeded"
+	if w := performRequest(e, "GET", "/ctx"); w.Body.String() != want {
+		t.Fatalf("Context should work as context.Context, got %q", w.Body.String())
+	}
+}
+
+type signupAccount struct {
+	Email string `json:"email"`
+}
+
+func (a *signupAccount) Validate() error {
+	if !strings.Contains(a.Email, "@") {
+		return errors.New("invalid email")
+	}
+	return nil
+}
+
+func TestFlow(t *testing.T) {
+	e := New()
+	e.FlowStore = NewMemoryFlowStore(time.Minute)
+	e.POST("/signup/account", func(c *Context) {
+		if err := c.Flow("signup").Save("account", &signupAccount{Email: c.Query("email")}); err != nil {
+			c.Fail(http.StatusBadRequest, err.Error())
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+	e.POST("/signup/finish", func(c *Context) {
+		flow := c.Flow("signup")
+		var account signupAccount
+		if !flow.Completed("account") || !flow.Load("account", &account) {
+			c.Fail(http.StatusConflict, "account step missing")
+			return
+		}
+		flow.Clear()
+		c.String(http.StatusOK, "%s", account.Email)
+	})
+
+	if w := performRequest(e, "POST", "/signup/account?email=bad"); w.Code != http.StatusBadRequest {
+		t.Fatalf("invalid step should be rejected, got %d", w.Code)
+	}
+	w := performRequest(e, "POST", "/signup/account?email=a@b.c")
+	cookie := w.Result().Cookies()[0]
+
+	req := httptest.NewRequest("POST", "/signup/finish", nil)
+	req.AddCookie(cookie)
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Body.String() != "a@b.c" {
+		t.Fatalf("flow should keep steps across requests, got %d %q", w.Code, w.Body.String())
+	}
+	if !cookie.Secure || !cookie.HttpOnly {
+		t.Fatalf("flow cookie should be Secure and HttpOnly, got %+v", cookie)
+	}
+
+	// 客户端提供的未知 flow ID 会被替换
+	req = httptest.NewRequest("POST", "/signup/account?email=a@b.c", nil)
+	req.AddCookie(&http.Cookie{Name: "zinc_flow_signup", Value: "attacker-chosen"})
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if cookies := w.Result().Cookies(); len(cookies) != 1 || cookies[0].Value == "attacker-chosen" {
+		t.Fatalf("unknown flow ID should be replaced, got %v", cookies)
+	}
+}
+
+func TestMemoryFlowStore(t *testing.T) {
+	s := NewMemoryFlowStore(time.Minute)
+	s.MaxFlows = 2
+	s.Set("a", map[string][]byte{"step": []byte("1")})
+	s.Set("b", map[string][]byte{"step": []byte("2")})
+	s.SetStep("a", "other", []byte("3"))
+	s.Set("c", nil)
+	if _, ok := s.Get("b"); ok {
+		t.Fatal("least recently updated flow should be evicted")
+	}
+	if steps, ok := s.Get("a"); !ok || string(steps["step"]) != "1" || string(steps["other"]) != "3" {
+		t.Fatalf("SetStep should keep the other steps, got %v %v", steps, ok)
+	}
+
+	// 并发保存不同步骤时不会互相覆盖
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s.SetStep("c", strconv.Itoa(i), nil)
+		}(i)
+	}
+	wg.Wait()
+	if steps, _ := s.Get("c"); len(steps) != 16 {
+		t.Fatalf("concurrent steps should all be saved, got %d", len(steps))
+	}
+
+	s = NewMemoryFlowStore(time.Millisecond)
+	s.Set("old", nil)
+	time.Sleep(2 * time.Millisecond)
+	s.Set("new", nil)
+	if len(s.flows) != 1 {
+		t.Fatalf("expired flows should be dropped, got %d", len(s.flows))
+	}
+}
+
+func TestCachingProxy(t *testing.T) {
+	var hits, conditional int
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		switch r.URL.Path {
+		case "/fresh":
+			w.Header().Set("Cache-Control", "max-age=60")
+		case "/validate":
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("ETag", `"v1"`)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				conditional++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		case "/private":
+			w.Header().Set("Cache-Control", "private, max-age=60")
+		case "/session":
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.Header().Set("Set-Cookie", "session="+strconv.Itoa(hits))
+		case "/vary":
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.Header().Set("Vary", "Accept-Language")
+			w.Write([]byte(r.Header.Get("Accept-Language") + " "))
+		}
+		w.Write([]byte("body " + r.URL.Path))
+	}))
+	defer upstream.Close()
+
+	e := New()
+	e.GET("/*path", CachingProxy(CachingProxyConfig{Upstream: upstream.URL}))
+
+	for _, want := range []string{"MISS", "HIT"} {
+		w := performRequest(e, "GET", "/fresh")
+		if w.Header().Get("X-Cache") != want || w.Body.String() != "body /fresh" {
+			t.Fatalf("/fresh: want %s, got %s %q", want, w.Header().Get("X-Cache"), w.Body.String())
+		}
+	}
+	if hits != 1 {
+		t.Fatalf("fresh response should be served from cache, upstream hits = %d", hits)
+	}
+
+	performRequest(e, "GET", "/validate")
+	w := performRequest(e, "GET", "/validate")
+	if w.Header().Get("X-Cache") != "REVALIDATED" || conditional != 1 || w.Body.String() != "body /validate" {
+		t.Fatalf("no-cache response should be revalidated, got %s conditional=%d", w.Header().Get("X-Cache"), conditional)
+	}
+	req := httptest.NewRequest("GET", "/validate", nil)
+	req.Header.Set("If-None-Match", `"v1"`)
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Fatalf("matching client ETag should get 304, got %d", w.Code)
+	}
+
+	hits = 0
+	performRequest(e, "GET", "/private")
+	performRequest(e, "GET", "/private")
+	if hits != 2 {
+		t.Fatalf("private responses must not be cached, upstream hits = %d", hits)
+	}
+
+	// 带有 Set-Cookie 的响应不缓存，每个客户端拿到自己的 Cookie
+	hits = 0
+	performRequest(e, "GET", "/session")
+	w = performRequest(e, "GET", "/session")
+	if hits != 2 || w.Header().Get("X-Cache") != "MISS" || w.Header().Get("Set-Cookie") != "session=2" {
+		t.Fatalf("responses with Set-Cookie must not be cached, got %s %q hits=%d", w.Header().Get("X-Cache"), w.Header().Get("Set-Cookie"), hits)
+	}
+
+	// 带有凭据的请求不使用缓存
+	hits = 0
+	req = httptest.NewRequest("GET", "/fresh", nil)
+	req.Header.Set("Authorization", "Bearer alice")
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if hits != 1 || w.Header().Get("X-Cache") != "MISS" {
+		t.Fatalf("credentialed request should bypass the cache, got %s hits=%d", w.Header().Get("X-Cache"), hits)
+	}
+
+	// 按 Vary 中的请求头部分别缓存
+	for _, lang := range []string{"en", "fr", "en"} {
+		req := httptest.NewRequest("GET", "/vary", nil)
+		req.Header.Set("Accept-Language", lang)
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		if w.Body.String() != lang+" body /vary" {
+			t.Fatalf("Accept-Language %s should get its own variant, got %q", lang, w.Body.String())
+		}
+	}
+
+	store := NewMemoryResponseStore()
+	store.MaxEntries = 2
+	store.Set("a", &CachedResponse{})
+	store.Set("b", &CachedResponse{})
+	store.Get("a")
+	store.Set("c", &CachedResponse{})
+	if _, ok := store.Get("b"); ok {
+		t.Fatal("least recently used response should be evicted")
+	}
+	if _, ok := store.Get("a"); !ok {
+		t.Fatal("recently used response should be kept")
+	}
+}
+
+func TestCachingProxyBadGateway(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	upstream.Close()
+
+	var recorded []error
+	e := New()
+	e.Use(func(c *Context) {
+		c.Next()
+		recorded = c.Errors
+	})
+	e.GET("/*path", CachingProxy(CachingProxyConfig{Upstream: upstream.URL}))
+	w := performRequest(e, "GET", "/data")
+	if w.Code != http.StatusBadGateway || strings.Contains(w.Body.String(), upstream.URL) {
+		t.Fatalf("the upstream address should not leak into the response, got %d %q", w.Code, w.Body.String())
+	}
+	if len(recorded) != 1 || !strings.Contains(recorded[0].Error(), upstream.URL) {
+		t.Fatalf("the upstream error should be recorded with c.Error, got %v", recorded)
+	}
+}
+
+func TestCachingProxyStaleWhileRevalidate(t *testing.T) {
+	revalidated := make(chan struct{}, 1)
+	var version int
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		version++
+		w.Header().Set("Cache-Control", "max-age=0, stale-while-revalidate=60")
+		w.Write([]byte("v" + strconv.Itoa(version)))
+		if version > 1 {
+			revalidated <- struct{}{}
+		}
+	}))
+	defer upstream.Close()
+
+	e := New()
+	e.GET("/data", CachingProxy(CachingProxyConfig{Upstream: upstream.URL, StaleWhileRevalidate: true}))
+	performRequest(e, "GET", "/data")
+	w := performRequest(e, "GET", "/data")
+	if w.Header().Get("X-Cache") != "STALE" || w.Body.String() != "v1" {
+		t.Fatalf("stale response should be served while revalidating, got %s %q", w.Header().Get("X-Cache"), w.Body.String())
+	}
+	select {
+	case <-revalidated:
+	case <-time.After(time.Second):
+		t.Fatal("stale response should be revalidated in the background")
+	}
+}
+
+func TestContextKeys(t *testing.T) {
+	e := New()
+	e.Use(func(c *Context) {
+		c.Set("user", "zinc")
+		c.Next()
+	})
+	e.GET("/keys", func(c *Context) {
+		if _, exists := c.Get("tenant"); exists {
+			t.Error("unset key should not exist")
+		}
+		c.String(http.StatusOK, "%s", c.MustGet("user").(string))
+	})
+	e.GET("/missing", func(c *Context) {
+		c.MustGet("tenant")
+	})
+
+	if w := performRequest(e, "GET", "/keys"); w.Body.String() != "zinc" {
+		t.Fatalf("handler should read the value set by middleware, got %q", w.Body.String())
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("MustGet should panic on a missing key")
+			}
+		}()
+		performRequest(e, "GET", "/missing")
+	}()
+}
+
+func TestLoadRoutes(t *testing.T) {
+	e := New()
+	e.RegisterHandler("users.list", func(c *Context) {
+		role, _ := c.RouteMeta("role")
+		c.String(http.StatusOK, "%s users %v", c.MustGet("auth"), role)
+	})
+	e.RegisterMiddleware("auth", func(c *Context) {
+		c.Set("auth", "alice")
+		c.Next()
+	})
+
+	err := e.LoadRoutes([]RouteSpec{{Method: "GET", Pattern: "/users", Handler: "users.list", Middlewares: []string{"missing"}}})
+	if err == nil || !strings.Contains(err.Error(), `middleware "missing"`) {
+		t.Fatalf("unknown middleware should be reported, got %v", err)
+	}
+	if len(e.Routes()) != 0 {
+		t.Fatal("no route should be registered when a spec is invalid")
+	}
+
+	err = e.LoadRoutes([]RouteSpec{{
+		Method: "GET", Pattern: "/users", Handler: "users.list",
+		Middlewares: []string{"auth"}, Name: "users", Metadata: map[string]interface{}{"role": "admin"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := performRequest(e, "GET", "/users"); w.Body.String() != "alice users admin" {
+		t.Fatalf("loaded route should run its middleware and metadata, got %q", w.Body.String())
+	}
+	if e.Routes()[0].Name != "users" {
+		t.Fatal("loaded route should be named")
+	}
+}
+
+func TestTypedKeys(t *testing.T) {
+	e := New()
+	now := time.Now()
+	e.GET("/typed", func(c *Context) {
+		c.Set("name", "zinc")
+		c.Set("count", 3)
+		c.Set("at", now)
+		c.Set("ttl", time.Second)
+		if c.GetString("name") != "zinc" || c.GetInt("count") != 3 || !c.GetTime("at").Equal(now) || c.GetDuration("ttl") != time.Second {
+			t.Error("typed getters should return the stored values")
+		}
+		if c.GetInt("name") != 0 || c.GetString("missing") != "" {
+			t.Error("mismatched or missing keys should return the zero value")
+		}
+		if n, ok := Get[int](c, "count"); !ok || n != 3 {
+			t.Error("Get[int] should return the stored int")
+		}
+		if _, ok := Get[string](c, "count"); ok {
+			t.Error("Get should report a type mismatch")
+		}
+	})
+	performRequest(e, "GET", "/typed")
+}
+
+func TestAbort(t *testing.T) {
+	e := New()
+	var aborted bool
+	e.Use(func(c *Context) {
+		c.Next()
+		aborted = c.IsAborted()
+	})
+	auth := e.Group("/auth")
+	auth.Use(func(c *Context) {
+		c.AbortWithStatus(http.StatusUnauthorized)
+	})
+	auth.GET("/me", func(c *Context) {
+		t.Error("handler should not run after Abort")
+	})
+	e.GET("/json", func(c *Context) {
+		c.AbortWithStatusJSON(http.StatusForbidden, H{"reason": "blocked"})
+	})
+	e.GET("/ok", func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	w := performRequest(e, "GET", "/auth/me")
+	if w.Code != http.StatusUnauthorized || w.Body.Len() != 0 || !aborted {
+		t.Fatalf("AbortWithStatus should stop the chain without a body, got %d %q aborted=%v", w.Code, w.Body.String(), aborted)
+	}
+	w = performRequest(e, "GET", "/json")
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "blocked") || !aborted {
+		t.Fatalf("AbortWithStatusJSON should write the payload, got %d %q", w.Code, w.Body.String())
+	}
+	performRequest(e, "GET", "/ok")
+	if aborted {
+		t.Fatal("a completed chain should not be reported as aborted")
+	}
+}
+
+func TestShutdownDrainsStreams(t *testing.T) {
+	e := New()
+	e.DrainGracePeriod = time.Second
+	started := make(chan struct{})
+	e.GET("/events", func(c *Context) {
+		c.SetHeader("Content-Type", "text/event-stream")
+		draining := c.Draining()
+		close(started)
+		<-draining
+		c.String(http.StatusOK, "event: goaway\n\n")
+	})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		done <- performRequest(e, "GET", "/events")
+	}()
+	<-started
+	if err := e.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// Shutdown 返回时长连接已经结束
+	if n := atomic.LoadInt64(&e.streams); n != 0 {
+		t.Fatalf("Shutdown should wait for draining streams to return, %d remaining", n)
+	}
+	if w := <-done; !strings.Contains(w.Body.String(), "goaway") {
+		t.Fatalf("stream should receive the goaway notice, got %q", w.Body.String())
+	}
+}
+
+func TestVirtualHostShutdown(t *testing.T) {
+	api := New()
+	started := make(chan struct{})
+	api.GET("/events", func(c *Context) {
+		draining := c.Draining()
+		close(started)
+		<-draining
+		c.String(http.StatusOK, "goaway")
+	})
+	vh := NewVirtualHost()
+	vh.Handle("api.example.com", api)
+	vh.Handle("*.example.com", api)
+	vh.Default(New())
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	served := make(chan error, 1)
+	go func() {
+		served <- vh.Run(addr)
+	}()
+
+	body := make(chan string, 1)
+	go func() {
+		for i := 0; i < 100; i++ {
+			req, _ := http.NewRequest("GET", "http://"+addr+"/events", nil)
+			req.Host = "api.example.com"
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				time.Sleep(10 * time.Millisecond)
+				continue
+			}
+			data, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			body <- string(data)
+			return
+		}
+		body <- "unreachable"
+	}()
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("the stream should start")
+	}
+	if err := vh.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-body; got != "goaway" {
+		t.Fatalf("the stream should be drained, got %q", got)
+	}
+	if err := <-served; err != http.ErrServerClosed {
+		t.Fatalf("Run should return ErrServerClosed, got %v", err)
+	}
+}
+
+func TestResponseBudget(t *testing.T) {
+	e := New()
+	counters := &BudgetCounters{}
+	e.GET("/big", func(c *Context) {
+		c.String(http.StatusOK, strings.Repeat("x", 100))
+	}).Budget(Budget{MaxBytes: 10, Counters: counters})
+	e.GET("/slow", func(c *Context) {
+		<-c.Done()
+		c.String(http.StatusOK, "late")
+	}).Budget(Budget{MaxDuration: 10 * time.Millisecond, Counters: counters})
+	e.GET("/small", func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	}).Budget(Budget{MaxBytes: 10, MaxDuration: time.Second})
+
+	if w := performRequest(e, "GET", "/big"); w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "xxx") {
+		t.Fatalf("oversized response should be replaced by 500, got %d %q", w.Code, w.Body.String())
+	}
+	if w := performRequest(e, "GET", "/slow"); w.Code != http.StatusServiceUnavailable || strings.Contains(w.Body.String(), "late") {
+		t.Fatalf("slow response should be replaced by 503, got %d %q", w.Code, w.Body.String())
+	}
+	if w := performRequest(e, "GET", "/small"); w.Code != http.StatusOK || w.Body.String() != "ok" {
+		t.Fatalf("response within budget should pass, got %d %q", w.Code, w.Body.String())
+	}
+	if got := counters.Load(); got.OversizedResponse != 1 || got.SlowResponse != 1 {
+		t.Fatalf("budget violations should be counted, got %+v", got)
+	}
+
+	// 超时回调与仍在写入或刚刚返回的处理函数并发时，响应只由处理函数所在的 goroutine 写出（go test -race）
+	e.GET("/stream", func(c *Context) {
+		for i := 0; i < 5; i++ {
+			c.Writer.Write([]byte("chunk"))
+			time.Sleep(time.Millisecond)
+		}
+	}).Budget(Budget{MaxDuration: 2 * time.Millisecond})
+	for i := 0; i < 10; i++ {
+		if w := performRequest(e, "GET", "/stream"); w.Code != http.StatusOK {
+			t.Fatalf("started response should keep its status, got %d", w.Code)
+		}
+	}
+}
+
+type patchUser struct {
+	Name  string `json:"name" form:"name"`
+	Age   int    `json:"age" form:"age"`
+	Admin bool   `json:"admin" form:"admin"`
+}
+
+func TestBindPatch(t *testing.T) {
+	e := New()
+	e.Handle("PATCH", "/users", func(c *Context) {
+		user := patchUser{Name: "zinc", Age: 3, Admin: true}
+		var patch patchUser
+		fields, err := c.BindPatch(&patch)
+		if err != nil {
+			return
+		}
+		ApplyPatch(&user, &patch, fields)
+		c.JSON(http.StatusOK, user)
+	})
+
+	for _, tt := range []struct {
+		contentType, body, want string
+	}{
+		{"application/json", `{"age":0}`, `{"name":"zinc","age":0,"admin":true}`},
+		{"application/json", `{"Name":"web","admin":false}`, `{"name":"web","age":3,"admin":false}`},
+		{"application/x-www-form-urlencoded", "admin=false", `{"name":"zinc","age":3,"admin":false}`},
+	} {
+		req := httptest.NewRequest("PATCH", "/users", strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", tt.contentType)
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		if strings.TrimSpace(w.Body.String()) != tt.want {
+			t.Errorf("PATCH %s: want %s, got %s", tt.body, tt.want, w.Body.String())
+		}
+	}
+
+	req := httptest.NewRequest("PATCH", "/users", strings.NewReader("age=old"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("invalid field should be rejected, got %d", w.Code)
+	}
+
+	e.QueryDuplicates = RejectDuplicates
+	e.MaxBodyBytes = 64
+	for body, want := range map[string]int{
+		"admin=false&admin=true":          http.StatusBadRequest,
+		"name=" + strings.Repeat("z", 64): http.StatusRequestEntityTooLarge,
+	} {
+		req := httptest.NewRequest("PATCH", "/users", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Fatalf("PATCH %s: want %d, got %d", body, want, w.Code)
+		}
+	}
+}
+
+func TestBindBodyLimit(t *testing.T) {
+	e := New()
+	e.MaxBodyBytes = 16
+	e.POST("/json", func(c *Context) {
+		var obj struct{ Name string }
+		if c.BindJSON(&obj) == nil {
+			c.String(http.StatusOK, obj.Name)
+		}
+	})
+	e.POST("/yaml", func(c *Context) {
+		var obj struct{ Name string }
+		if c.BindYAML(&obj) == nil {
+			c.String(http.StatusOK, obj.Name)
+		}
+	})
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest("POST", "/json", strings.NewReader(`{"name":"zinc"}`)))
+	if w.Code != http.StatusOK || w.Body.String() != "zinc" {
+		t.Fatalf("body within the limit should bind, got %d %q", w.Code, w.Body.String())
+	}
+	oversized := map[string]string{
+		"/json": `{"name":"` + strings.Repeat("z", 64) + `"}`,
+		"/yaml": "name: " + strings.Repeat("z", 64),
+	}
+	for path, body := range oversized {
+		// 声明了长度的请求体直接拒绝，未声明长度的请求体读到上限时拒绝
+		for _, reader := range []io.Reader{strings.NewReader(body), io.MultiReader(strings.NewReader(body))} {
+			req := httptest.NewRequest("POST", path, reader)
+			w := httptest.NewRecorder()
+			e.ServeHTTP(w, req)
+			if w.Code != http.StatusRequestEntityTooLarge {
+				t.Fatalf("%s: oversized body (ContentLength %d) should be rejected with 413, got %d", path, req.ContentLength, w.Code)
+			}
+		}
+	}
+}
+
+func TestScratch(t *testing.T) {
+	e := New()
+	e.ScratchSize = 16
+	e.GET("/scratch", func(c *Context) {
+		a := c.Scratch(8)
+		b := c.Scratch(32)
+		copy(a, "aaaaaaaa")
+		copy(b, strings.Repeat("b", 32))
+		a = append(a, 'x')
+		if string(b[:1]) != "b" || len(a) != 9 {
+			t.Error("borrowed slices should not overlap or be grown into each other")
+		}
+		c.String(http.StatusOK, "%s", c.Scratch(4))
+	})
+	if w := performRequest(e, "GET", "/scratch"); w.Body.String() != "\x00\x00\x00\x00" {
+		t.Fatalf("scratch should be zeroed, got %q", w.Body.String())
+	}
+}
+
+func TestScratchJSON(t *testing.T) {
+	e := New()
+	e.ScratchSize = 16
+	long := strings.Repeat("x", 64)
+	e.GET("/json", func(c *Context) {
+		c.JSON(http.StatusCreated, H{"name": long})
+	})
+	e.GET("/pure", func(c *Context) {
+		c.PureJSON(http.StatusOK, H{"html": "<b>"})
+	})
+	e.GET("/bad", func(c *Context) {
+		c.JSON(http.StatusOK, H{"ch": make(chan int)})
+	})
+	w := performRequest(e, "GET", "/json")
+	if w.Code != http.StatusCreated || w.Body.String() != `{"name":"`+long+`"}`+"\n" {
+		t.Fatalf("unexpected JSON response %d %q", w.Code, w.Body.String())
+	}
+	if w := performRequest(e, "GET", "/pure"); w.Body.String() != `{"html":"<b>"}`+"\n" {
+		t.Fatalf("unexpected PureJSON response %q", w.Body.String())
+	}
+	w = performRequest(e, "GET", "/bad")
+	if w.Code != http.StatusInternalServerError || strings.HasPrefix(w.Body.String(), "{") {
+		t.Fatalf("encode error should be a clean 500, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+// scratchHandler 每个请求需要 4KB 临时缓冲区的处理函数
+func scratchHandler(c *Context) {
+	buf := c.Scratch(4 << 10)
+	buf[0] = 'z'
+	c.Data(http.StatusOK, buf[:1])
+}
+
+func benchmarkScratch(b *testing.B, size int) {
+	e := New()
+	e.ScratchSize = size
+	e.GET("/scratch", scratchHandler)
+	req := httptest.NewRequest("GET", "/scratch", nil)
+	w := httptest.NewRecorder()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e.ServeHTTP(w, req)
+	}
+}
+
+func BenchmarkScratchDisabled(b *testing.B) {
+	benchmarkScratch(b, 0)
+}
+
+func BenchmarkScratchArena(b *testing.B) {
+	benchmarkScratch(b, 8<<10)
+}
+
+var errNotFound = errors.New("record not found")
+
+func TestErrorHandler(t *testing.T) {
+	e := New()
+	e.Use(ErrorHandlerWith(ErrorHandlerConfig{Map: func(err error) *HTTPError {
+		if errors.Is(err, errNotFound) {
+			return NewHTTPError(http.StatusNotFound, "not found", err)
+		}
+		return nil
+	}}))
+	e.GET("/typed", func(c *Context) {
+		c.Error(NewHTTPError(http.StatusConflict, "version conflict", nil))
+		c.Abort()
+	})
+	e.GET("/mapped", func(c *Context) {
+		c.Error(fmt.Errorf("load user: %w", errNotFound))
+	})
+	e.GET("/internal", func(c *Context) {
+		c.Error(errors.New("db password leaked"))
+	})
+	e.GET("/written", func(c *Context) {
+		c.String(http.StatusOK, "ok")
+		c.Error(errors.New("after response"))
+	})
+
+	for _, tt := range []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/typed", http.StatusConflict, "version conflict"},
+		{"/mapped", http.StatusNotFound, "not found"},
+		{"/internal", http.StatusInternalServerError, "Internal Server Error"},
+		{"/written", http.StatusOK, "ok"},
+	} {
+		w := performRequest(e, "GET", tt.path)
+		if w.Code != tt.code || !strings.Contains(w.Body.String(), tt.body) || strings.Contains(w.Body.String(), "password") {
+			t.Errorf("%s: want %d %q, got %d %q", tt.path, tt.code, tt.body, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestFormatMessage(t *testing.T) {
+	files := "{n, plural, =0 {no files} one {# file} other {# files}}"
+	for _, tt := range []struct {
+		lang, message string
+		args          map[string]interface{}
+		want          string
+	}{
+		{"en", files, map[string]interface{}{"n": 0}, "no files"},
+		{"en", files, map[string]interface{}{"n": 1}, "1 file"},
+		{"en", files, map[string]interface{}{"n": 1200}, "1,200 files"},
+		{"ru", "{n, plural, one {# файл} few {# файла} many {# файлов} other {# файла}}", map[string]interface{}{"n": 22}, "22 файла"},
+		{"ru", "{n, plural, one {# файл} few {# файла} many {# файлов} other {# файла}}", map[string]interface{}{"n": 11}, "11 файлов"},
+		{"en", "{gender, select, female {She} other {They}} liked {n, plural, one {your post} other {# posts}}", map[string]interface{}{"gender": "female", "n": 3}, "She liked 3 posts"},
+		{"de", "Preis: {price}", map[string]interface{}{"price": 1234.5}, "Preis: 1.234,5"},
+	} {
+		got, err := FormatMessage(tt.lang, tt.message, tt.args)
+		if err != nil || got != tt.want {
+			t.Errorf("FormatMessage(%s, %q) = %q, %v; want %q", tt.lang, tt.message, got, err, tt.want)
+		}
+	}
+	if _, err := FormatMessage("en", "{n, plural, one {x}}", map[string]interface{}{"n": 2}); err == nil {
+		t.Error("a message without a matching case should fail")
+	}
+
+	date := time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC)
+	if got := FormatDate("zh-CN", date); got != "2024年3月9日" {
+		t.Errorf("FormatDate zh = %q", got)
+	}
+	if got := FormatNumber("fr", -1234567.891, 2); got != "-1\u202f234\u202f567,89" {
+		t.Errorf("FormatNumber fr = %q", got)
+	}
+
+	e := New()
+	e.GET("/msg", func(c *Context) {
+		msg, _ := c.FormatMessage(files, map[string]interface{}{"n": 2})
+		c.String(http.StatusOK, "%s", msg)
+	})
+	req := httptest.NewRequest("GET", "/msg", nil)
+	req.Header.Set("Accept-Language", "de;q=0.5, en-US")
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Body.String() != "2 files" {
+		t.Fatalf("context should format in the preferred language, got %q", w.Body.String())
+	}
+}
+
+func TestNonEmptyCatchAll(t *testing.T) {
+	e := New()
+	e.GET("/assets/*filepath", func(c *Context) {
+		c.String(http.StatusOK, "[%s]", c.Param("filepath"))
+	})
+	if w := performRequest(e, "GET", "/assets/"); w.Body.String() != "[]" {
+		t.Fatalf("empty catch-all should match by default, got %d %q", w.Code, w.Body.String())
+	}
+	e.NonEmptyCatchAll = true
+	for _, path := range []string{"/assets/", "/assets"} {
+		if w := performRequest(e, "GET", path); w.Code != http.StatusNotFound {
+			t.Fatalf("%s should not match with NonEmptyCatchAll, got %d", path, w.Code)
+		}
+	}
+	if w := performRequest(e, "GET", "/assets/app.js"); w.Body.String() != "[app.js]" {
+		t.Fatalf("non-empty catch-all should still match, got %q", w.Body.String())
+	}
+}
+
+func TestProblemDetails(t *testing.T) {
+	e := New()
+	e.ProblemJSON = true
+	e.Use(Recovery())
+	e.GET("/validate", func(c *Context) {
+		c.Problem(http.StatusUnprocessableEntity, ProblemDetails{
+			Type:       "https://example.com/probs/invalid",
+			Detail:     "name is required",
+			Extensions: map[string]interface{}{"field": "name"},
+		})
+	})
+	e.GET("/panic", func(c *Context) {
+		panic("boom")
+	})
+
+	w := performRequest(e, "GET", "/validate")
+	var body map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if w.Header().Get("Content-Type") != "application/problem+json" || body["status"] != float64(422) ||
+		body["title"] != "Unprocessable Entity" || body["field"] != "name" || body["type"] != "https://example.com/probs/invalid" {
+		t.Fatalf("unexpected problem response %s %s", w.Header().Get("Content-Type"), w.Body.String())
+	}
+
+	for path, code := range map[string]int{"/missing": http.StatusNotFound, "/panic": http.StatusInternalServerError} {
+		w := performRequest(e, "GET", path)
+		body = nil
+		json.Unmarshal(w.Body.Bytes(), &body)
+		if w.Code != code || w.Header().Get("Content-Type") != "application/problem+json" || body["status"] != float64(code) {
+			t.Errorf("%s: built-in error should be problem+json, got %d %s", path, w.Code, w.Body.String())
+		}
+	}
+}
+
+type bindUser struct {
+	Name    string `json:"name"`
+	Age     int    `json:"age"`
+	Address struct {
+		City string `json:"city"`
+	} `json:"address"`
+}
+
+func TestBindJSON(t *testing.T) {
+	e := New()
+	e.DisallowUnknownFields = true
+	e.POST("/users", func(c *Context) {
+		var user bindUser
+		if c.BindJSON(&user) != nil {
+			return
+		}
+		c.String(http.StatusOK, "%s %d %s", user.Name, user.Age, user.Address.City)
+	})
+
+	for _, tt := range []struct {
+		body, want string
+		code       int
+	}{
+		{`{"name":"zinc","age":3,"address":{"city":"Hangzhou"}}`, "zinc 3 Hangzhou", http.StatusOK},
+		{``, `"reason":"empty_body"`, http.StatusBadRequest},
+		{`{"name":`, `"reason":"syntax"`, http.StatusBadRequest},
+		{`{"name":"zinc","age":"three"}`, `"field":"age"`, http.StatusBadRequest},
+		{`{"address":{"city":1}}`, `"field":"address.city"`, http.StatusBadRequest},
+		{`{"name":"zinc","admin":true}`, `"reason":"unknown_field"`, http.StatusBadRequest},
+	} {
+		req := httptest.NewRequest("POST", "/users", strings.NewReader(tt.body))
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		if w.Code != tt.code || !strings.Contains(w.Body.String(), tt.want) {
+			t.Errorf("%s: want %d containing %s, got %d %s", tt.body, tt.code, tt.want, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestWellKnown(t *testing.T) {
+	e := New()
+	wk := e.WellKnown()
+	wk.SecurityTxt(SecurityTxt{
+		Contact:            []string{"mailto:security@example.com"},
+		Expires:            time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+		PreferredLanguages: []string{"en", "zh"},
+	})
+	wk.ChangePassword("/account/password")
+	wk.AppleAppSiteAssociation(H{"applinks": H{"apps": []string{}}})
+
+	w := performRequest(e, "GET", "/.well-known/security.txt")
+	want := "Contact: mailto:security@example.com\nExpires: 2030-01-01T00:00:00Z\nPreferred-Languages: en, zh\n"
+	if w.Body.String() != want || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain") {
+		t.Fatalf("unexpected security.txt %q", w.Body.String())
+	}
+	if w := performRequest(e, "GET", "/.well-known/change-password"); w.Code != http.StatusFound || w.Header().Get("Location") != "/account/password" {
+		t.Fatalf("change-password should redirect, got %d %s", w.Code, w.Header().Get("Location"))
+	}
+	w = performRequest(e, "GET", "/.well-known/apple-app-site-association")
+	if w.Header().Get("Content-Type") != "application/json" || w.Body.String() != `{"applinks":{"apps":[]}}` {
+		t.Fatalf("unexpected apple-app-site-association %s %q", w.Header().Get("Content-Type"), w.Body.String())
+	}
+}
+
+func TestRedirects(t *testing.T) {
+	e := New()
+	e.Redirects(map[string]string{
+		"/old/:id":        "/new/:id",
+		"/blog/*path":     "302 https://blog.example.com/posts/*path",
+		"/promo":          "307 /campaigns/spring",
+		"/users/:id<int>": "/members/:id",
+	})
+	for _, tt := range []struct {
+		method, path, location string
+		code                   int
+	}{
+		{"GET", "/old/42?ref=mail", "/new/42?ref=mail", http.StatusMovedPermanently},
+		{"HEAD", "/old/42", "/new/42", http.StatusMovedPermanently},
+		{"GET", "/blog/2024/hello", "https://blog.example.com/posts/2024/hello", http.StatusFound},
+		{"GET", "/promo", "/campaigns/spring", http.StatusTemporaryRedirect},
+		{"GET", "/users/7", "/members/7", http.StatusMovedPermanently},
+	} {
+		w := performRequest(e, tt.method, tt.path)
+		if w.Code != tt.code || w.Header().Get("Location") != tt.location {
+			t.Errorf("%s %s: want %d %s, got %d %s", tt.method, tt.path, tt.code, tt.location, w.Code, w.Header().Get("Location"))
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("a target using an unknown parameter should panic")
+		}
+	}()
+	New().Redirects(map[string]string{"/a/:id": "/b/:slug"})
+}
+
+func TestBindFormats(t *testing.T) {
+	type config struct {
+		Name string   `json:"name" xml:"name" yaml:"name" toml:"name"`
+		Port int      `json:"port" xml:"port" yaml:"port" toml:"port"`
+		Tags []string `json:"tags" xml:"tag" yaml:"tags" toml:"tags"`
+	}
+	e := New()
+	e.DisallowUnknownFields = true
+	e.POST("/config", func(c *Context) {
+		var cfg config
+		if c.BindBody(&cfg) != nil {
+			return
+		}
+		c.String(http.StatusOK, "%s %d %v", cfg.Name, cfg.Port, cfg.Tags)
+	})
+
+	for _, tt := range []struct {
+		contentType, body, want string
+		code                    int
+	}{
+		{"application/json", `{"name":"api","port":80,"tags":["a","b"]}`, "api 80 [a b]", http.StatusOK},
+		{"application/xml", `<config><name>api</name><port>80</port><tag>a</tag><tag>b</tag></config>`, "api 80 [a b]", http.StatusOK},
+		{"application/yaml", "name: api\nport: 80\ntags: [a, b]\n", "api 80 [a b]", http.StatusOK},
+		{"application/toml", "name = \"api\"\nport = 80\ntags = [\"a\", \"b\"]\n", "api 80 [a b]", http.StatusOK},
+		{"application/xml", `<config><name>api`, `"reason":"syntax"`, http.StatusBadRequest},
+		{"application/yaml", "name: api\nport: eighty\n", `"reason":"type"`, http.StatusBadRequest},
+		{"application/yaml", "name: api\nadmin: true\n", `"field":"admin"`, http.StatusBadRequest},
+		{"application/toml", "name = \"api\"\nadmin = true\n", `"reason":"unknown_field"`, http.StatusBadRequest},
+		{"application/toml", "name = ", `"reason":"syntax"`, http.StatusBadRequest},
+		{"application/yaml", "", `"reason":"empty_body"`, http.StatusBadRequest},
+		{"text/csv", "name,port", `unsupported content type`, http.StatusBadRequest},
+	} {
+		req := httptest.NewRequest("POST", "/config", strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", tt.contentType)
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		if w.Code != tt.code || !strings.Contains(w.Body.String(), tt.want) {
+			t.Errorf("%s %q: want %d containing %s, got %d %s", tt.contentType, tt.body, tt.code, tt.want, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestBindQuery(t *testing.T) {
+	type listQuery struct {
+		Page   int       `form:"page"`
+		Active *bool     `form:"active"`
+		Tags   []string  `form:"tag"`
+		Since  time.Time `form:"since" time_format:"2006-01-02"`
+		Secret string    `form:"-"`
+	}
+	e := New()
+	e.GET("/items", func(c *Context) {
+		var q listQuery
+		if c.BindQuery(&q) != nil {
+			return
+		}
+		c.String(http.StatusOK, "%d %v %v %s %q", q.Page, q.Active != nil && *q.Active, q.Tags, q.Since.Format("Jan 2"), q.Secret)
+	})
+
+	w := performRequest(e, "GET", "/items?page=2&active=true&tag=a&tag=b&since=2024-03-01&Secret=x")
+	if want := `2 true [a b] Mar 1 ""`; w.Body.String() != want {
+		t.Fatalf("want %q, got %q", want, w.Body.String())
+	}
+	w = performRequest(e, "GET", "/items?page=two")
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"field":"page"`) ||
+		!strings.Contains(w.Body.String(), `cannot parse \"two\" as int`) {
+		t.Fatalf("expected a per-field error, got %d %s", w.Code, w.Body.String())
+	}
+	if w := performRequest(e, "GET", "/items?since=yesterday"); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"field":"since"`) {
+		t.Fatalf("expected an error for since, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestCanary(t *testing.T) {
+	e := New()
+	e.GET("/search", func(c *Context) {
+		c.String(http.StatusOK, "v1")
+	}).Canary(func(c *Context) {
+		c.String(http.StatusOK, "v2")
+	}, CanaryConfig{Percent: 0, Header: "X-Canary", Cookie: "search_canary"})
+
+	w := performRequest(e, "GET", "/search")
+	cookie := w.Result().Cookies()
+	if w.Body.String() != "v1" || len(cookie) != 1 || cookie[0].Name != "search_canary" || cookie[0].Value == "stable" {
+		t.Fatalf("0%% canary should serve v1 and assign a client id, got %q %q", w.Body.String(), w.Header().Get("Set-Cookie"))
+	}
+	req := httptest.NewRequest("GET", "/search", nil)
+	req.Header.Set("X-Canary", "canary")
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Body.String() != "v2" {
+		t.Fatalf("header should force the canary, got %q", w.Body.String())
+	}
+
+	e.Freeze()
+	if !e.SetCanaryPercent("GET", "/search", 100) || e.SetCanaryPercent("GET", "/missing", 100) {
+		t.Fatal("SetCanaryPercent should only find routes with a canary")
+	}
+	if w := performRequest(e, "GET", "/search"); w.Body.String() != "v2" {
+		t.Fatalf("100%% canary should serve v2, got %q", w.Body.String())
+	}
+	// 提高比例后，0% 时分到原 Handler 的客户端也要转到灰度 Handler
+	req = httptest.NewRequest("GET", "/search", nil)
+	req.AddCookie(cookie[0])
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Body.String() != "v2" || w.Header().Get("Set-Cookie") != "" {
+		t.Fatalf("raising the percentage should move existing clients, got %q %q", w.Body.String(), w.Header().Get("Set-Cookie"))
+	}
+
+	// 同一客户端在比例不变时分流结果稳定
+	if !e.SetCanary("GET", "/search", CanaryConfig{Percent: 50, Cookie: "search_canary"}) {
+		t.Fatal("SetCanary should find the route")
+	}
+	seen := map[string]int{}
+	for i := 0; i < 40; i++ {
+		id := fmt.Sprintf("client-%d", i)
+		var first string
+		for j := 0; j < 3; j++ {
+			req := httptest.NewRequest("GET", "/search", nil)
+			req.AddCookie(&http.Cookie{Name: "search_canary", Value: id})
+			w := httptest.NewRecorder()
+			e.ServeHTTP(w, req)
+			if j == 0 {
+				first = w.Body.String()
+			} else if w.Body.String() != first {
+				t.Fatalf("client %s should stay on %s, got %s", id, first, w.Body.String())
+			}
+		}
+		seen[first]++
+	}
+	if seen["v1"] == 0 || seen["v2"] == 0 {
+		t.Fatalf("50%% canary should split clients, got %v", seen)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			e.SetCanaryPercent("GET", "/search", i*25)
+		}(i)
+		go func() {
+			defer wg.Done()
+			performRequest(e, "GET", "/search")
+		}()
+	}
+	wg.Wait()
+
+	e = New()
+	e.GET("/later", func(c *Context) {
+		c.String(http.StatusOK, "v1")
+	}).Canary(func(c *Context) {
+		c.String(http.StatusOK, "v2")
+	}, CanaryConfig{Percent: 100, Start: time.Now().Add(time.Hour)})
+	if w := performRequest(e, "GET", "/later"); w.Body.String() != "v1" {
+		t.Fatalf("canary should not start before Start, got %q", w.Body.String())
+	}
+}
+
+type quotaError struct {
+	Limit int
+}
+
+func (e quotaError) Error() string {
+	return fmt.Sprintf("quota of %d exceeded", e.Limit)
+}
+
+func TestStructuredPanic(t *testing.T) {
+	e := New()
+	var recorded []error
+	e.Use(func(c *Context) {
+		c.Next()
+		recorded = c.Errors
+	}, ErrorHandlerWith(ErrorHandlerConfig{Map: func(err error) *HTTPError {
+		var quota quotaError
+		if errors.As(err, &quota) {
+			return NewHTTPError(http.StatusTooManyRequests, quota.Error(), err)
+		}
+		return nil
+	}}), Recovery())
+	api := e.Group("/api").OnPanic(PanicConfig{Policy: PanicDelegate})
+	api.GET("/quota", func(c *Context) {
+		panic(quotaError{Limit: 10})
+	})
+	api.GET("/missing", func(c *Context) {
+		panic(NewHTTPError(http.StatusNotFound, "no such order", nil))
+	})
+	e.GET("/point", func(c *Context) {
+		panic(struct{ X, Y int }{1, 2})
+	})
+
+	if w := performRequest(e, "GET", "/api/quota"); w.Code != http.StatusTooManyRequests || !strings.Contains(w.Body.String(), "quota of 10 exceeded") {
+		t.Fatalf("panic value should reach the error handler typed, got %d %s", w.Code, w.Body.String())
+	}
+	if w := performRequest(e, "GET", "/api/missing"); w.Code != http.StatusNotFound {
+		t.Fatalf("a panicking HTTPError should keep its code, got %d", w.Code)
+	}
+	if w := performRequest(e, "GET", "/point"); w.Code != http.StatusInternalServerError {
+		t.Fatalf("default policy should respond 500, got %d", w.Code)
+	}
+
+	bare := New()
+	bare.Use(Recovery())
+	bare.Group("/api").OnPanic(PanicConfig{Policy: PanicDelegate}).GET("/boom", func(c *Context) {
+		panic("boom")
+	})
+	if w := performRequest(bare, "GET", "/api/boom"); w.Code != http.StatusInternalServerError {
+		t.Fatalf("delegating without an error handler should respond 500, got %d", w.Code)
+	}
+	var panicErr *PanicError
+	if len(recorded) != 1 || !errors.As(recorded[0], &panicErr) || panicErr.Error() != "panic: struct { X int; Y int } {X:1 Y:2}" || panicErr.Stack == "" {
+		t.Fatalf("unexpected recorded panic %v", recorded)
+	}
+}
+
+func TestBindUri(t *testing.T) {
+	e := New()
+	e.GET("/orders/:id/items/*path", func(c *Context) {
+		var uri struct {
+			ID   int64  `uri:"id"`
+			Path string `uri:"path"`
+		}
+		if c.BindUri(&uri) != nil {
+			return
+		}
+		c.String(http.StatusOK, "%d %s", uri.ID, uri.Path)
+	})
+
+	if w := performRequest(e, "GET", "/orders/42/items/a/b"); w.Body.String() != "42 a/b" {
+		t.Fatalf("unexpected binding %q", w.Body.String())
+	}
+	w := performRequest(e, "GET", "/orders/abc/items/x")
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"field":"id"`) {
+		t.Fatalf("expected 400 for a non-numeric id, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestBindHeader(t *testing.T) {
+	e := New()
+	e.GET("/", func(c *Context) {
+		var h struct {
+			APIVersion int      `header:"x-api-version"`
+			PerPage    *int     `header:"X-Per-Page"`
+			Features   []string `header:"X-Feature"`
+		}
+		if c.BindHeader(&h) != nil {
+			return
+		}
+		c.String(http.StatusOK, "%d %v %v", h.APIVersion, h.PerPage == nil, h.Features)
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Api-Version", "3")
+	req.Header.Add("X-Feature", "beta")
+	req.Header.Add("X-Feature", "dark-mode")
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if want := "3 true [beta dark-mode]"; w.Body.String() != want {
+		t.Fatalf("want %q, got %q", want, w.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Per-Page", "many")
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"field":"X-Per-Page"`) {
+		t.Fatalf("expected 400 for a bad header, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestRecordResponse(t *testing.T) {
+	e := New()
+	var body string
+	var complete bool
+	var header http.Header
+	e.Use(RecordResponse(8), func(c *Context) {
+		c.Next()
+		b, ok := c.ResponseBody()
+		body, complete, header = string(b), ok, c.ResponseHeader()
+	})
+	e.GET("/short", func(c *Context) {
+		c.SetHeader("X-Signature", "abc")
+		c.String(http.StatusOK, "hello")
+	})
+	e.GET("/long", func(c *Context) {
+		c.String(http.StatusOK, "hello, world")
+		c.SetHeader("X-Late", "ignored")
+	})
+
+	w := performRequest(e, "GET", "/short")
+	if w.Body.String() != "hello" || body != "hello" || !complete || header.Get("X-Signature") != "abc" {
+		t.Fatalf("unexpected recording %q %v %v", body, complete, header)
+	}
+	w = performRequest(e, "GET", "/long")
+	if w.Body.String() != "hello, world" || body != "hello, w" || complete || header.Get("X-Late") != "" {
+		t.Fatalf("unexpected bounded recording %q %v %v", body, complete, header)
+	}
+}
+
+// hijackRecorder 支持 http.Hijacker 的 httptest.ResponseRecorder
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+}
+
+func (w *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.hijacked = true
+	return nil, nil, nil
+}
+
+func TestRecordResponseHijack(t *testing.T) {
+	e := New()
+	e.Use(RecordResponse(8))
+	var unwrapped http.ResponseWriter
+	var hijackErr error
+	e.GET("/ws", func(c *Context) {
+		unwrapped = c.Writer.(interface{ Unwrap() http.ResponseWriter }).Unwrap()
+		_, _, hijackErr = c.Writer.(http.Hijacker).Hijack()
+	})
+
+	w := &hijackRecorder{ResponseRecorder: httptest.NewRecorder()}
+	e.ServeHTTP(w, httptest.NewRequest("GET", "/ws", nil))
+	if hijackErr != nil || !w.hijacked || unwrapped != w {
+		t.Fatalf("recorder should delegate Hijack and Unwrap, got %v %v", hijackErr, w.hijacked)
+	}
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/ws", nil))
+	if hijackErr != http.ErrNotSupported {
+		t.Fatalf("hijacking an unsupported writer should fail, got %v", hijackErr)
+	}
+}
+
+func TestRecordResponseLimit(t *testing.T) {
+	var body []byte
+	complete := true
+	e := New()
+	e.Use(RecordResponse(0), func(c *Context) {
+		c.Next()
+		body, complete = c.ResponseBody()
+		body = append([]byte(nil), body...)
+	})
+	e.GET("/data", func(c *Context) {
+		c.String(http.StatusOK, "data")
+		c.Writer.Write([]byte("more"))
+	})
+	if w := performRequest(e, "GET", "/data"); w.Body.String() != "datamore" {
+		t.Fatalf("a zero limit should still pass the response through, got %q", w.Body.String())
+	}
+	if len(body) != 0 || complete {
+		t.Fatalf("a zero limit should record no body, got %q complete=%v", body, complete)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("a negative limit should panic")
+		}
+	}()
+	RecordResponse(-1)
+}
+
+func TestFallback(t *testing.T) {
+	dir := t.TempDir()
+	index := filepath.Join(dir, "index.html")
+	if err := os.WriteFile(index, []byte("<div id=app></div>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	legacy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "legacy %s", r.URL.Path)
+	}))
+	defer legacy.Close()
+
+	e := New()
+	e.GET("/api/ping", func(c *Context) {
+		c.String(http.StatusOK, "pong")
+	})
+	e.Fallback(
+		SPAFallback("/app", index),
+		RedirectFallback(map[string]string{"/about-us.php": "/about"}),
+	)
+	e.Fallback(ProxyFallback(legacy.URL))
+
+	for _, tt := range []struct {
+		path, want string
+		code       int
+	}{
+		{"/api/ping", "pong", http.StatusOK},
+		{"/app/orders/42", "<div id=app></div>", http.StatusOK},
+		{"/about-us.php?ref=x", "", http.StatusMovedPermanently},
+		{"/app/missing.js", "legacy /app/missing.js", http.StatusOK},
+		{"/cart.php", "legacy /cart.php", http.StatusOK},
+	} {
+		w := performRequest(e, "GET", tt.path)
+		if w.Code != tt.code || w.Body.String() != tt.want {
+			t.Errorf("%s: want %d %q, got %d %q", tt.path, tt.code, tt.want, w.Code, w.Body.String())
+		}
+	}
+	if w := performRequest(e, "GET", "/about-us.php?ref=x"); w.Header().Get("Location") != "/about?ref=x" {
+		t.Fatalf("unexpected redirect %q", w.Header().Get("Location"))
+	}
+
+	e = New()
+	e.Fallback(RedirectFallback(map[string]string{"/old": "302 /new"}))
+	e.NoRoute(func(c *Context) {
+		c.String(http.StatusNotFound, "custom 404")
+	})
+	if w := performRequest(e, "GET", "/old"); w.Code != http.StatusFound {
+		t.Fatalf("expected 302, got %d", w.Code)
+	}
+	if w := performRequest(e, "GET", "/nowhere"); w.Body.String() != "custom 404" {
+		t.Fatalf("unresolved requests should reach NoRoute, got %q", w.Body.String())
+	}
+}
+
+func TestShouldBind(t *testing.T) {
+	type search struct {
+		Q     string `form:"q" json:"q" xml:"q"`
+		Limit int    `form:"limit" json:"limit" xml:"limit"`
+	}
+	e := New()
+	handler := func(c *Context) {
+		var s search
+		if c.MustBind(&s) != nil {
+			return
+		}
+		c.String(http.StatusOK, "%s %d", s.Q, s.Limit)
+	}
+	e.GET("/search", handler)
+	e.POST("/search", handler)
+
+	if w := performRequest(e, "GET", "/search?q=zinc&limit=5"); w.Body.String() != "zinc 5" {
+		t.Fatalf("GET should bind the query, got %q", w.Body.String())
+	}
+	for contentType, body := range map[string]string{
+		"application/json":                  `{"q":"zinc","limit":5}`,
+		"application/xml":                   `<search><q>zinc</q><limit>5</limit></search>`,
+		"application/x-www-form-urlencoded": "q=zinc&limit=5",
+	} {
+		req := httptest.NewRequest("POST", "/search", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		if w.Body.String() != "zinc 5" {
+			t.Errorf("%s: got %d %q", contentType, w.Code, w.Body.String())
+		}
+	}
+	req := httptest.NewRequest("POST", "/search", strings.NewReader("q=zinc&limit=many"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"field":"limit"`) {
+		t.Fatalf("MustBind should abort with 400, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestTimeoutGatewayRetry(t *testing.T) {
+	e := New()
+	release := make(chan struct{})
+	var calls int32
+	slow := func(c *Context) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		c.String(http.StatusOK, "report")
+	}
+	timeout := TimeoutWith(TimeoutConfig{
+		Timeout:        10 * time.Millisecond,
+		GatewayTimeout: true,
+		Store:          NewMemoryResponseStore(),
+		Grace:          time.Second,
+	})
+	e.GET("/report", slow).Use(timeout)
+	e.POST("/report", slow).Use(timeout)
+
+	w := performRequest(e, "GET", "/report?day=1")
+	if w.Code != http.StatusGatewayTimeout || w.Header().Get("Retry-After") != "1" || w.Header().Get(HeaderRetrySafe) != "true" {
+		t.Fatalf("GET timeout should be a retry-safe 504, got %d %v", w.Code, w.Header())
+	}
+	if w := performRequest(e, "POST", "/report"); w.Code != http.StatusServiceUnavailable || w.Header().Get(HeaderRetrySafe) != "" {
+		t.Fatalf("POST timeout should stay 503, got %d", w.Code)
+	}
+	close(release)
+
+	var retry *httptest.ResponseRecorder
+	for i := 0; i < 100; i++ {
+		retry = performRequest(e, "GET", "/report?day=1")
+		if retry.Header().Get("X-Cache") == "HIT" {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if retry.Code != http.StatusOK || retry.Body.String() != "report" || retry.Header().Get("X-Cache") != "HIT" {
+		t.Fatalf("retry should be served from the abandoned handler's result, got %d %q", retry.Code, retry.Body.String())
+	}
+	// 带凭据的请求不读写共享缓存
+	req := httptest.NewRequest("GET", "/report?day=1", nil)
+	req.Header.Set("Authorization", "Bearer alice")
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Header().Get("X-Cache") == "HIT" {
+		t.Fatal("a credentialed request should not be served from the cache")
+	}
+
+	// 后台名额用完后，超时的处理函数立即取消
+	e = New()
+	hold := make(chan struct{})
+	canceled := make(chan string, 2)
+	e.GET("/export/:id", func(c *Context) {
+		select {
+		case <-hold:
+		case <-c.Req.Context().Done():
+			canceled <- c.Param("id")
+		}
+	}).Use(TimeoutWith(TimeoutConfig{
+		Timeout:       10 * time.Millisecond,
+		Store:         NewMemoryResponseStore(),
+		Grace:         time.Minute,
+		MaxBackground: 1,
+	}))
+	performRequest(e, "GET", "/export/1")
+	performRequest(e, "GET", "/export/2")
+	select {
+	case id := <-canceled:
+		if id != "2" {
+			t.Fatalf("the request over the background limit should be canceled, got %s", id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the request over the background limit should be canceled")
+	}
+	close(hold)
+}
+
+func TestTimeoutDetachedHandlers(t *testing.T) {
+	reported := make(chan interface{}, 1)
+	e := New()
+	e.HeadFallbackToGet = true
+	e.PanicReporter = func(c *Context, err interface{}, stack string, severity Severity) {
+		reported <- err
+	}
+	release := make(chan struct{})
+	store := NewMemoryResponseStore()
+	e.Use(TimeoutWith(TimeoutConfig{
+		Timeout: 10 * time.Millisecond,
+		Store:   store,
+		Grace:   time.Second,
+	}))
+	e.GET("/report", func(c *Context) {
+		<-release
+		c.String(http.StatusOK, "report")
+	})
+	e.GET("/crash", func(c *Context) {
+		<-release
+		panic("late failure")
+	})
+
+	// HEAD 在后台完成的结果不能作为 GET 的缓存
+	if w := performRequest(e, "HEAD", "/report"); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("HEAD should time out, got %d", w.Code)
+	}
+	if w := performRequest(e, "GET", "/crash"); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("GET /crash should time out, got %d", w.Code)
+	}
+	close(release)
+	// 等待 HEAD 在后台完成的结果写入缓存
+	for i := 0; i < 100; i++ {
+		store.mu.Lock()
+		n := store.order.Len()
+		store.mu.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	select {
+	case err := <-reported:
+		if err != "late failure" {
+			t.Fatalf("unexpected panic value %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("a panic after the timeout should be reported")
+	}
+	if w := performRequest(e, "GET", "/report"); w.Header().Get("X-Cache") == "HIT" || w.Body.String() != "report" {
+		t.Fatalf("GET should not be served from the HEAD result, got %q %q", w.Header().Get("X-Cache"), w.Body.String())
+	}
+}
+
+type testMoney struct {
+	Cents int64
+}
+
+func TestValidatorsAndDecoders(t *testing.T) {
+	RegisterValidator("test_iban", func(value interface{}, param string) bool {
+		s, _ := value.(string)
+		return strings.HasPrefix(s, "DE") && len(s) == 22
+	})
+	RegisterDecoder(testMoney{}, func(s string) (interface{}, error) {
+		f, err := strconv.ParseFloat(strings.TrimPrefix(s, "$"), 64)
+		return testMoney{Cents: int64(f * 100)}, err
+	})
+
+	type transfer struct {
+		To     string    `json:"to" form:"to" validate:"required,test_iban"`
+		Amount testMoney `json:"-" form:"amount"`
+		Note   string    `json:"note" form:"note" validate:"max=5"`
+		Tier   string    `json:"tier" form:"tier" validate:"oneof=free pro"`
+	}
+	e := New()
+	e.GET("/transfer", func(c *Context) {
+		var tr transfer
+		if c.BindQuery(&tr) != nil {
+			return
+		}
+		c.String(http.StatusOK, "%s %d", tr.To, tr.Amount.Cents)
+	})
+	e.POST("/transfer", func(c *Context) {
+		var tr transfer
+		if c.BindJSON(&tr) != nil {
+			return
+		}
+		c.String(http.StatusOK, tr.To)
+	})
+
+	iban := "DE89370400440532013000"
+	for _, tt := range []struct {
+		path, want string
+		code       int
+	}{
+		{"/transfer?to=" + iban + "&amount=$12.50&tier=pro", iban + " 1250", http.StatusOK},
+		{"/transfer?to=FR76&tier=pro", `"field":"to"`, http.StatusBadRequest},
+		{"/transfer?tier=pro", `is required`, http.StatusBadRequest},
+		{"/transfer?to=" + iban + "&amount=lots&tier=pro", `"field":"amount"`, http.StatusBadRequest},
+		{"/transfer?to=" + iban + "&tier=enterprise", `"field":"tier"`, http.StatusBadRequest},
+	} {
+		w := performRequest(e, "GET", tt.path)
+		if w.Code != tt.code || !strings.Contains(w.Body.String(), tt.want) {
+			t.Errorf("%s: want %d containing %s, got %d %s", tt.path, tt.code, tt.want, w.Code, w.Body.String())
+		}
+	}
+
+	req := httptest.NewRequest("POST", "/transfer", strings.NewReader(`{"to":"`+iban+`","note":"rent for may","tier":"free"}`))
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"field":"note"`) || !strings.Contains(w.Body.String(), `"reason":"invalid"`) {
+		t.Fatalf("JSON binding should validate too, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestClone(t *testing.T) {
+	e := New()
+	e.Use(func(c *Context) {
+		c.SetHeader("X-Global", "1")
+		c.Next()
+	})
+	e.GET("/me", func(c *Context) {
+		c.String(http.StatusOK, "me")
+	})
+
+	internal := e.Clone()
+	internal.Use(func(c *Context) {
+		if c.Req.Header.Get("X-Internal-Token") != "secret" {
+			c.AbortWithStatus(http.StatusUnauthorized)
+		}
+	})
+	internal.ProblemJSON = true
+
+	if w := performRequest(e, "GET", "/me"); w.Code != http.StatusOK || w.Header().Get("X-Global") != "1" {
+		t.Fatalf("original engine should be unaffected, got %d", w.Code)
+	}
+	if w := performRequest(internal, "GET", "/me"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("clone middleware should run first, got %d", w.Code)
+	}
+	req := httptest.NewRequest("GET", "/me", nil)
+	req.Header.Set("X-Internal-Token", "secret")
+	w := httptest.NewRecorder()
+	internal.ServeHTTP(w, req)
+	if w.Body.String() != "me" || w.Header().Get("X-Global") != "1" {
+		t.Fatalf("clone should share routes and global middleware, got %q", w.Body.String())
+	}
+
+	// 路由树共享，运行时注册的路由在克隆中可见；配置不共享
+	e.AddRoute("GET", "/late", func(c *Context) {
+		c.String(http.StatusOK, "late")
+	})
+	req = httptest.NewRequest("GET", "/late", nil)
+	req.Header.Set("X-Internal-Token", "secret")
+	w = httptest.NewRecorder()
+	internal.ServeHTTP(w, req)
+	if w.Body.String() != "late" {
+		t.Fatalf("clone should see routes added at runtime, got %q", w.Body.String())
+	}
+	// 路由列表也与原 Engine 一致，Check 和 LintRoutes 不会读到过期的路由
+	hasLate := func(routes []*Route) bool {
+		for _, route := range routes {
+			if route.Pattern == "/late" {
+				return true
+			}
+		}
+		return false
+	}
+	if !hasLate(internal.Routes()) {
+		t.Fatal("clone Routes should include routes added at runtime")
+	}
+	e.AddRoute("GET", "/hello/:name", func(c *Context) {})
+	e.AddRoute("GET", "/hello/:id", func(c *Context) {})
+	if len(internal.Check()) != 1 || len(LintRoutes(internal)) != 2 {
+		t.Fatalf("clone diagnostics should follow the shared routes, got %v %v", internal.Check(), LintRoutes(internal))
+	}
+	e.RemoveRoute("GET", "/late")
+	if hasLate(internal.Routes()) {
+		t.Fatal("clone Routes should drop routes removed at runtime")
+	}
+	if w := performRequest(e, "GET", "/missing"); strings.Contains(w.Header().Get("Content-Type"), "problem") {
+		t.Fatal("clone config should not leak into the original")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("registering routes on a clone should panic")
+		}
+	}()
+	internal.GET("/other", func(c *Context) {})
+}
+
+func TestCloneCopiesConfig(t *testing.T) {
+	// 配置选项都应放在 EngineConfig 中，否则 Clone 不会复制
+	engineType := reflect.TypeOf(Engine{})
+	for i := 0; i < engineType.NumField(); i++ {
+		field := engineType.Field(i)
+		if field.IsExported() && field.Name != "RouterGroup" && field.Name != "EngineConfig" {
+			t.Fatalf("exported option Engine.%s should be declared in EngineConfig", field.Name)
+		}
+	}
+
+	e := New()
+	config := reflect.ValueOf(&e.EngineConfig).Elem()
+	implementations := []interface{}{NewMemoryFlowStore(time.Minute), msgpackCodec{}}
+	for i := 0; i < config.NumField(); i++ {
+		field := config.Field(i)
+		switch field.Kind() {
+		case reflect.Bool:
+			field.SetBool(true)
+		case reflect.Int, reflect.Int32, reflect.Int64:
+			field.SetInt(1)
+		case reflect.String:
+			field.SetString("x")
+		case reflect.Slice:
+			field.Set(reflect.MakeSlice(field.Type(), 1, 1))
+		case reflect.Ptr:
+			field.Set(reflect.New(field.Type().Elem()))
+		case reflect.Func:
+			field.Set(reflect.MakeFunc(field.Type(), func(args []reflect.Value) []reflect.Value { return nil }))
+		case reflect.Interface:
+			for _, impl := range implementations {
+				if v := reflect.ValueOf(impl); v.Type().Implements(field.Type()) {
+					field.Set(v)
+				}
+			}
+		}
+		if field.IsZero() {
+			t.Fatalf("no test value for EngineConfig.%s", config.Type().Field(i).Name)
+		}
+	}
+
+	cloned := reflect.ValueOf(&e.Clone().EngineConfig).Elem()
+	for i := 0; i < config.NumField(); i++ {
+		name := config.Type().Field(i).Name
+		if config.Field(i).Kind() == reflect.Func {
+			if cloned.Field(i).IsNil() {
+				t.Fatalf("Clone should copy EngineConfig.%s", name)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(config.Field(i).Interface(), cloned.Field(i).Interface()) {
+			t.Fatalf("Clone should copy EngineConfig.%s", name)
+		}
+	}
+}
+
+func TestContextFuncMap(t *testing.T) {
+	dir := t.TempDir()
+	page := `{{define "page"}}{{if hasPerm "admin"}}admin {{end}}{{currentUser}} {{upper .}}{{end}}`
+	if err := os.WriteFile(filepath.Join(dir, "page.tmpl"), []byte(page), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	e := New()
+	e.SetFuncMap(template.FuncMap{"upper": strings.ToUpper})
+	e.SetContextFuncMap(template.FuncMap{
+		"currentUser": func(c *Context) string {
+			return c.GetString("user")
+		},
+		"hasPerm": func(c *Context, perm string) bool {
+			return c.GetString("role") == perm
+		},
+	})
+	e.LoadHTMLGlob(filepath.Join(dir, "*"))
+	e.GET("/:user/:role", func(c *Context) {
+		c.Set("user", c.Param("user"))
+		c.Set("role", c.Param("role"))
+		c.HTML(http.StatusOK, "page", "hi")
+	})
+
+	if w := performRequest(e, "GET", "/alice/admin"); w.Body.String() != "admin alice HI" {
+		t.Fatalf("unexpected render %q", w.Body.String())
+	}
+	if w := performRequest(e, "GET", "/bob/viewer"); w.Body.String() != "bob HI" {
+		t.Fatalf("each render should see its own context, got %q", w.Body.String())
+	}
+
+	// 并发渲染时每个请求使用各自的模板集副本
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			user := fmt.Sprintf("user%d", i)
+			if w := performRequest(e, "GET", "/"+user+"/viewer"); w.Body.String() != user+" HI" {
+				t.Errorf("concurrent render saw another context: %q", w.Body.String())
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestShouldBindBodyWith(t *testing.T) {
+	e := New()
+	e.Use(func(c *Context) {
+		var signed struct {
+			Signature string `json:"sig"`
+		}
+		if c.BindBodyWith(&signed, JSONBinding) != nil {
+			return
+		}
+		if signed.Signature != "ok" {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+		c.Next()
+	})
+	e.POST("/orders", func(c *Context) {
+		var order struct {
+			ID int `json:"id"`
+		}
+		var raw map[string]interface{}
+		if c.BindBodyWith(&order, JSONBinding) != nil || c.BindBodyWith(&raw, JSONBinding) != nil {
+			return
+		}
+		// 缓存后普通的绑定方法也可以再读取一次
+		var again struct {
+			ID int `json:"id"`
+		}
+		if c.BindJSON(&again) != nil {
+			return
+		}
+		c.String(http.StatusOK, "%d %d %d", order.ID, len(raw), again.ID)
+	})
+
+	req := httptest.NewRequest("POST", "/orders", strings.NewReader(`{"sig":"ok","id":7}`))
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Body.String() != "7 2 7" {
+		t.Fatalf("body should be bindable repeatedly, got %d %q", w.Code, w.Body.String())
+	}
+	req = httptest.NewRequest("POST", "/orders", strings.NewReader(`{"sig":"bad","id":7}`))
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", w.Code)
+	}
+}
+
+func TestBindDefaults(t *testing.T) {
+	type listQuery struct {
+		Limit  int      `form:"limit,default=50"`
+		Sort   string   `form:"sort,omitempty,default=created"`
+		Fields []string `form:"field,default=id;name"`
+		Cursor *int64   `form:"cursor"`
+		Q      string   `form:"q"`
+	}
+	e := New()
+	e.GET("/items", func(c *Context) {
+		var q listQuery
+		if c.BindQuery(&q) != nil {
+			return
+		}
+		c.String(http.StatusOK, "%d %s %v %v %q", q.Limit, q.Sort, q.Fields, q.Cursor == nil, q.Q)
+	})
+
+	for path, want := range map[string]string{
+		"/items":                                 `50 created [id name] true ""`,
+		"/items?limit=&sort=&cursor=&q=":         `50 created [id name] true ""`,
+		"/items?limit=5&sort=name&field=id&q=go": `5 name [id] true "go"`,
+		"/items?cursor=0":                        `50 created [id name] false ""`,
+	} {
+		if w := performRequest(e, "GET", path); w.Body.String() != want {
+			t.Errorf("%s: want %q, got %q", path, want, w.Body.String())
+		}
+	}
+}
+
+// flushCounter 记录 Flush 次数的 ResponseRecorder
+type flushCounter struct {
+	*httptest.ResponseRecorder
+	flushes int
+}
+
+func (w *flushCounter) Flush() {
+	w.flushes++
+	w.ResponseRecorder.Flush()
+}
+
+func TestHTMLStream(t *testing.T) {
+	dir := t.TempDir()
+	report := `{{define "report"}}<table>{{range .}}<tr><td>{{.}}</td></tr>{{end}}</table>{{end}}` +
+		`{{define "broken"}}<p>start</p>{{explode}}{{end}}`
+	if err := os.WriteFile(filepath.Join(dir, "report.tmpl"), []byte(report), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	e := New()
+	e.SetFuncMap(template.FuncMap{"explode": func() string { panic("boom") }})
+	e.LoadHTMLGlob(filepath.Join(dir, "*"))
+	rows := make([]int, 20000)
+	var streamErr error
+	e.GET("/report", func(c *Context) {
+		streamErr = c.HTMLStream(http.StatusOK, "report", rows)
+	})
+	e.GET("/broken", func(c *Context) {
+		streamErr = c.HTMLStream(http.StatusOK, "broken", nil)
+	})
+
+	w := &flushCounter{ResponseRecorder: httptest.NewRecorder()}
+	e.ServeHTTP(w, httptest.NewRequest("GET", "/report", nil))
+	if streamErr != nil || w.Code != http.StatusOK || !strings.HasSuffix(w.Body.String(), "</table>") {
+		t.Fatalf("unexpected stream result %v %d", streamErr, w.Code)
+	}
+	if want := w.Body.Len() / (32 << 10); w.flushes < want {
+		t.Fatalf("expected at least %d flushes for %d bytes, got %d", want, w.Body.Len(), w.flushes)
+	}
+
+	// 模板 panic 时保留已发送的内容，之后断开连接，即使外层有 Recovery
+	e.Use(Recovery())
+	w = &flushCounter{ResponseRecorder: httptest.NewRecorder()}
+	func() {
+		defer func() {
+			if p := recover(); p != http.ErrAbortHandler {
+				t.Fatalf("a panicking template should abort the connection, got %v", p)
+			}
+		}()
+		e.ServeHTTP(w, httptest.NewRequest("GET", "/broken", nil))
+	}()
+	if w.Code != http.StatusOK || w.Body.String() != "<p>start</p>" {
+		t.Fatalf("a failing template should keep the committed prelude, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestInspector(t *testing.T) {
+	e := New()
+	e.Inspector(2)
+	e.GET("/orders/:id", func(c *Context) {
+		c.Error(errors.New("inventory lookup failed"))
+		c.String(http.StatusOK, "order")
+	})
+
+	req := httptest.NewRequest("GET", "/orders/1?token=abc", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	performRequest(e, "GET", "/missing")
+
+	// 默认只允许本机直接访问
+	if w := performRequest(e, "GET", "/_zinc/requests"); w.Code != http.StatusForbidden {
+		t.Fatalf("remote clients should be rejected, got %d", w.Code)
+	}
+	local := func(path string, header string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.RemoteAddr = "127.0.0.1:40000"
+		if header != "" {
+			req.Header.Set("X-Forwarded-For", header)
+		}
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		return w
+	}
+	if w := local("/_zinc", "203.0.113.7"); w.Code != http.StatusForbidden {
+		t.Fatalf("proxied requests should be rejected, got %d", w.Code)
+	}
+
+	w := local("/_zinc/requests", "")
+	var entries []InspectedRequest
+	if err := json.Unmarshal(w.Body.Bytes(), &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Status != http.StatusNotFound || entries[1].Route != "/orders/:id" {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+	order := entries[1]
+	if strings.Contains(order.URI, "abc") || order.Header.Get("Authorization") == "Bearer secret" ||
+		len(order.Errors) != 1 || len(order.Handlers) != 2 {
+		t.Fatalf("unexpected order entry %+v", order)
+	}
+	if w := local("/_zinc", ""); !strings.Contains(w.Body.String(), "inventory lookup failed") {
+		t.Fatalf("inspector page should list errors, got %s", w.Body.String())
+	}
+
+	// 传入鉴权中间件时由它决定是否允许访问
+	e = New()
+	e.Inspector(2, func(c *Context) {
+		if c.Req.Header.Get("X-Admin-Token") != "secret" {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		c.Next()
+	})
+	if w := performRequest(e, "GET", "/_zinc/requests"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("auth handler should guard the inspector, got %d", w.Code)
+	}
+	req = httptest.NewRequest("GET", "/_zinc/requests", nil)
+	req.Header.Set("X-Admin-Token", "secret")
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("authorized remote clients should be allowed, got %d", w.Code)
+	}
+}
+
+func TestKeyLock(t *testing.T) {
+	e := New()
+	var active, maxActive int32
+	e.POST("/accounts/:id/transfer", func(c *Context) {
+		n := atomic.AddInt32(&active, 1)
+		for {
+			m := atomic.LoadInt32(&maxActive)
+			if n <= m || atomic.CompareAndSwapInt32(&maxActive, m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&active, -1)
+		c.String(http.StatusOK, "ok")
+	}).Use(KeyLock(KeyLockConfig{Key: ParamKey("id")}))
+	e.GET("/slow/:id", func(c *Context) {
+		time.Sleep(50 * time.Millisecond)
+	}).Use(KeyLock(KeyLockConfig{Key: ParamKey("id"), Timeout: 10 * time.Millisecond}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			performRequest(e, "POST", "/accounts/42/transfer")
+		}()
+	}
+	wg.Wait()
+	if maxActive != 1 {
+		t.Fatalf("requests for the same account should be serialized, got %d concurrent", maxActive)
+	}
+
+	codes := make(chan int, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			codes <- performRequest(e, "GET", "/slow/1").Code
+		}()
+	}
+	if a, b := <-codes, <-codes; a+b != http.StatusOK+http.StatusServiceUnavailable {
+		t.Fatalf("waiting past the timeout should be rejected with 503, got %d and %d", a, b)
+	}
+}
+
+// multipartBody 构造包含 parts（字段名到内容）的 multipart/form-data 请求体，文件名为字段名加 .bin
+func multipartBody(t *testing.T, parts ...[2]string) (*bytes.Buffer, string) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, part := range parts {
+		w, err := mw.CreateFormFile(part[0], part[0]+".bin")
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(part[1]))
+	}
+	mw.Close()
+	return &body, mw.FormDataContentType()
+}
+
+func TestMultipartReader(t *testing.T) {
+	e := New()
+	e.POST("/upload", func(c *Context) {
+		mr, err := c.MultipartReader()
+		if err != nil {
+			c.Fail(http.StatusBadRequest, err.Error())
+			return
+		}
+		mr.MaxPartBytes = 8
+		mr.MaxParts = 2
+		var got []string
+		err = mr.Each(func(part *Part) error {
+			data, err := io.ReadAll(part)
+			got = append(got, fmt.Sprintf("%s=%s(%d)", part.FormName(), data, part.Size()))
+			return err
+		})
+		switch {
+		case errors.Is(err, ErrPartTooLarge):
+			c.Fail(http.StatusRequestEntityTooLarge, strings.Join(got, ","))
+		case errors.Is(err, ErrTooManyParts):
+			c.Fail(http.StatusBadRequest, err.Error())
+		case err != nil:
+			c.Fail(http.StatusBadRequest, err.Error())
+		default:
+			c.String(http.StatusOK, strings.Join(got, ","))
+		}
+	})
+
+	for _, tt := range []struct {
+		parts []([2]string)
+		code  int
+		want  string
+	}{
+		{[]([2]string){{"a", "1234"}, {"b", "12345678"}}, http.StatusOK, "a=1234(4),b=12345678(8)"},
+		{[]([2]string){{"a", "123456789"}}, http.StatusRequestEntityTooLarge, "a=12345678(9)"},
+		{[]([2]string){{"a", "1"}, {"b", "2"}, {"c", "3"}}, http.StatusBadRequest, "too many parts"},
+	} {
+		body, contentType := multipartBody(t, tt.parts...)
+		req := httptest.NewRequest("POST", "/upload", body)
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		if w.Code != tt.code || !strings.Contains(w.Body.String(), tt.want) {
+			t.Errorf("%v: want %d containing %q, got %d %s", tt.parts, tt.code, tt.want, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestUploads(t *testing.T) {
+	e := New()
+	store := NewMemoryUploadStore()
+	var completed UploadInfo
+	e.Group("/files").Uploads(UploadConfig{Store: store, MaxSize: 10, OnComplete: func(c *Context, info UploadInfo) {
+		completed = info
+	}})
+	send := func(method, path string, body string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Tus-Resumable", "1.0.0")
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := send("OPTIONS", "/files/", "", nil); w.Code != http.StatusNoContent || w.Header().Get("Tus-Max-Size") != "10" {
+		t.Fatalf("options: %d %v", w.Code, w.Header())
+	}
+	if w := send("POST", "/files/", "", map[string]string{"Upload-Length": "11"}); w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("oversized upload should be rejected, got %d", w.Code)
+	}
+	w := send("POST", "/files/", "", map[string]string{"Upload-Length": "8", "Upload-Metadata": "filename YS50eHQ=,draft"})
+	location := w.Header().Get("Location")
+	if w.Code != http.StatusCreated || !strings.HasPrefix(location, "/files/") {
+		t.Fatalf("create: %d %q", w.Code, location)
+	}
+	octet := map[string]string{"Content-Type": "application/offset+octet-stream", "Upload-Offset": "0"}
+	if w := send("PATCH", location, "abcd", octet); w.Code != http.StatusNoContent || w.Header().Get("Upload-Offset") != "4" {
+		t.Fatalf("first chunk: %d %v", w.Code, w.Header())
+	}
+	// 偏移不一致时返回 409，客户端通过 HEAD 查询后继续
+	if w := send("PATCH", location, "efgh", octet); w.Code != http.StatusConflict {
+		t.Fatalf("stale offset should conflict, got %d", w.Code)
+	}
+	if w := send("HEAD", location, "", nil); w.Code != http.StatusOK || w.Header().Get("Upload-Offset") != "4" || w.Header().Get("Upload-Length") != "8" {
+		t.Fatalf("head: %d %v", w.Code, w.Header())
+	}
+	octet["Upload-Offset"] = "4"
+	if w := send("PATCH", location, "efgh", octet); w.Code != http.StatusNoContent || w.Header().Get("Upload-Offset") != "8" {
+		t.Fatalf("last chunk: %d %v", w.Code, w.Header())
+	}
+	id := strings.TrimPrefix(location, "/files/")
+	if data, _ := store.Data(id); string(data) != "abcdefgh" || completed.ID != id || completed.Metadata["filename"] != "a.txt" {
+		t.Fatalf("completed upload: %q %+v", data, completed)
+	}
+
+	if w := send("PATCH", location, "x", map[string]string{"Content-Type": "text/plain", "Upload-Offset": "8"}); w.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("wrong content type should be rejected, got %d", w.Code)
+	}
+	if w := performRequest(e, "HEAD", location); w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("missing Tus-Resumable should fail, got %d", w.Code)
+	}
+	if w := send("DELETE", location, "", nil); w.Code != http.StatusNoContent {
+		t.Fatalf("delete: %d", w.Code)
+	}
+	if w := send("HEAD", location, "", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("deleted upload should be gone, got %d", w.Code)
+	}
+
+	// 过期的上传返回 410
+	store.Create(UploadInfo{ID: "old", Size: 4, Expires: time.Now().Add(-time.Minute)})
+	if w := send("PATCH", "/files/old", "abcd", map[string]string{"Content-Type": "application/offset+octet-stream", "Upload-Offset": "0"}); w.Code != http.StatusGone {
+		t.Fatalf("expired upload: %d", w.Code)
+	}
+	if w := send("PATCH", "/files/unknown", "abcd", map[string]string{"Content-Type": "application/offset+octet-stream", "Upload-Offset": "0"}); w.Code != http.StatusNotFound {
+		t.Fatalf("unknown upload: %d", w.Code)
+	}
+
+	// 数据分块写入，上传过程中 HEAD 可以看到进度
+	store.Create(UploadInfo{ID: "slow", Size: 8})
+	pr, pw := io.Pipe()
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		req := httptest.NewRequest("PATCH", "/files/slow", pr)
+		req.Header.Set("Tus-Resumable", "1.0.0")
+		req.Header.Set("Content-Type", "application/offset+octet-stream")
+		req.Header.Set("Upload-Offset", "0")
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		done <- w
+	}()
+	pw.Write([]byte("abcd"))
+	var progress string
+	for i := 0; i < 100 && progress != "4"; i++ {
+		time.Sleep(time.Millisecond)
+		progress = send("HEAD", "/files/slow", "", nil).Header().Get("Upload-Offset")
+	}
+	if progress != "4" {
+		t.Fatalf("HEAD should report the streamed offset, got %q", progress)
+	}
+	pw.Write([]byte("efgh"))
+	pw.Close()
+	if w := <-done; w.Code != http.StatusNoContent || w.Header().Get("Upload-Offset") != "8" {
+		t.Fatalf("streamed upload: %d %v", w.Code, w.Header())
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Uploads without MaxSize should panic")
+		}
+	}()
+	New().Group("/files").Uploads(UploadConfig{})
+}
+
+func TestUploadLock(t *testing.T) {
+	u := &uploader{locks: make(map[string]*uploadLock)}
+	unlock := u.lock("a")
+	acquired := make(chan struct{})
+	released := make(chan struct{})
+	go func() {
+		unlock := u.lock("a")
+		close(acquired)
+		unlock()
+		close(released)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("the second PATCH should wait for the first")
+	case <-time.After(10 * time.Millisecond):
+	}
+	unlock()
+	<-released
+	if len(u.locks) != 0 {
+		t.Fatalf("locks should be released, got %d", len(u.locks))
+	}
+}
+
+// signatureInspector 拒绝内容中包含 EICAR 的文件，记录检查过的文件名
+type signatureInspector struct {
+	mu      sync.Mutex
+	checked []string
+}
+
+type signatureCheck struct {
+	content bytes.Buffer
+}
+
+func (s *signatureInspector) Inspect(info PartInfo) (io.WriteCloser, error) {
+	if strings.HasSuffix(info.FileName, ".exe") {
+		return nil, errors.New("executables are not allowed")
+	}
+	s.mu.Lock()
+	s.checked = append(s.checked, info.FileName)
+	s.mu.Unlock()
+	return &signatureCheck{}, nil
+}
+
+func (s *signatureCheck) Write(p []byte) (int, error) {
+	return s.content.Write(p)
+}
+
+func (s *signatureCheck) Close() error {
+	if strings.Contains(s.content.String(), "EICAR") {
+		return errors.New("malware signature found")
+	}
+	return nil
+}
+
+func TestUploadInspection(t *testing.T) {
+	inspector := &signatureInspector{}
+	var saved []string
+	e := New()
+	e.POST("/buffered", func(c *Context) {
+		saved = append(saved, "buffered")
+		c.String(http.StatusOK, "ok")
+	}).Use(InspectUploads(inspector))
+	e.POST("/stream", func(c *Context) {
+		mr, err := c.MultipartReader()
+		if err != nil {
+			c.Fail(http.StatusBadRequest, err.Error())
+			return
+		}
+		mr.Inspectors = []PartInspector{inspector}
+		err = mr.Each(func(part *Part) error {
+			data, err := io.ReadAll(part)
+			if err != nil {
+				return err
+			}
+			saved = append(saved, string(data))
+			return nil
+		})
+		var inspectErr *InspectionError
+		if errors.As(err, &inspectErr) {
+			c.Fail(http.StatusUnprocessableEntity, inspectErr.Error())
+			return
+		}
+		c.String(http.StatusOK, "ok")
+	})
+
+	for _, tt := range []struct {
+		path  string
+		parts [][2]string
+		code  int
+		saved []string
+	}{
+		{"/buffered", [][2]string{{"a", "hello"}}, http.StatusOK, []string{"buffered"}},
+		{"/buffered", [][2]string{{"a", "hello"}, {"b", "xEICARx"}}, http.StatusUnprocessableEntity, nil},
+		{"/stream", [][2]string{{"a", "hello"}, {"b", "world"}}, http.StatusOK, []string{"hello", "world"}},
+		// 检查在最后一次 Read 返回 io.EOF 之前完成，被拒绝的部分不会被保存
+		{"/stream", [][2]string{{"a", "hello"}, {"b", "xEICARx"}, {"c", "later"}}, http.StatusUnprocessableEntity, []string{"hello"}},
+	} {
+		saved = nil
+		body, contentType := multipartBody(t, tt.parts...)
+		req := httptest.NewRequest("POST", tt.path, body)
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		if w.Code != tt.code || fmt.Sprint(saved) != fmt.Sprint(tt.saved) {
+			t.Errorf("%s %v: want %d %v, got %d %v %s", tt.path, tt.parts, tt.code, tt.saved, w.Code, saved, w.Body.String())
+		}
+	}
+
+	// Inspect 返回错误时在读取内容之前拒绝
+	saved = nil
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, _ := mw.CreateFormFile("file", "setup.exe")
+	fw.Write([]byte("MZ"))
+	mw.Close()
+	req := httptest.NewRequest("POST", "/stream", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Code != http.StatusUnprocessableEntity || !strings.Contains(w.Body.String(), "executables") || saved != nil {
+		t.Fatalf("executable should be rejected, got %d %s %v", w.Code, w.Body.String(), saved)
+	}
+}
+
+func TestCharset(t *testing.T) {
+	e := New()
+	e.Charset = "utf-8"
+	e.GET("/text", func(c *Context) {
+		c.String(http.StatusOK, "hi")
+	})
+	e.GET("/json", func(c *Context) {
+		c.JSON(http.StatusOK, H{"ok": true})
+	})
+	e.GET("/legacy", func(c *Context) {
+		c.SetCharset("gbk")
+		c.String(http.StatusOK, "hi")
+	})
+
+	for path, want := range map[string]string{
+		"/text":    "text/plain; charset=utf-8",
+		"/json":    "application/json; charset=utf-8",
+		"/legacy":  "text/plain; charset=gbk",
+		"/missing": "text/plain; charset=utf-8",
+	} {
+		if got := performRequest(e, "GET", path).Header().Get("Content-Type"); got != want {
+			t.Errorf("%s: want %q, got %q", path, want, got)
+		}
+	}
+
+	// 默认不附加字符集，与之前的行为一致
+	e.Charset = ""
+	if got := performRequest(e, "GET", "/json").Header().Get("Content-Type"); got != "application/json" {
+		t.Fatalf("want application/json without charset, got %q", got)
+	}
+}
+
+func TestUploadChecks(t *testing.T) {
+	e := New()
+	e.POST("/avatar", func(c *Context) {
+		c.String(http.StatusOK, "saved")
+	}).Use(InspectUploads(MaxFileSize(64), SniffContentType("image/")))
+
+	png := "\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 16)
+	gif := "GIF89a" + strings.Repeat("\x00", 16)
+	for _, tt := range []struct {
+		declared, content string
+		code              int
+		reason            string
+	}{
+		{"image/png", png, http.StatusOK, ""},
+		{"application/octet-stream", png, http.StatusOK, ""},
+		{"image/png", gif, http.StatusUnprocessableEntity, ReasonTypeMismatch},
+		{"image/png", "%PDF-1.7 pretending to be an image", http.StatusUnprocessableEntity, ReasonTypeNotAllowed},
+		{"image/png", png + strings.Repeat("\x00", 64), http.StatusRequestEntityTooLarge, ReasonTooLarge},
+	} {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", `form-data; name="avatar"; filename="me.png"`)
+		header.Set("Content-Type", tt.declared)
+		pw, _ := mw.CreatePart(header)
+		pw.Write([]byte(tt.content))
+		mw.Close()
+		req := httptest.NewRequest("POST", "/avatar", &body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+
+		var resp UploadError
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if w.Code != tt.code || resp.Reason != tt.reason {
+			t.Errorf("%s %q: want %d %s, got %d %s", tt.declared, tt.content[:8], tt.code, tt.reason, w.Code, w.Body.String())
+		}
+		if tt.reason != "" && (resp.Field != "avatar" || resp.FileName != "me.png") {
+			t.Errorf("upload error should name the file, got %s", w.Body.String())
+		}
+	}
+}
+
+func TestCookies(t *testing.T) {
+	e := New()
+	e.Use(func(c *Context) {
+		c.SetSameSite(http.SameSiteStrictMode)
+		c.Next()
+	})
+	e.GET("/login", func(c *Context) {
+		c.SetCookie("user", "张三 & co", CookieOptions{MaxAge: 60, Secure: true, HttpOnly: true})
+		c.SetCookie("theme", "dark", CookieOptions{Path: "/app", SameSite: http.SameSiteLaxMode})
+		c.Status(http.StatusNoContent)
+	})
+	e.GET("/whoami", func(c *Context) {
+		user, err := c.Cookie("user")
+		if err != nil {
+			c.String(http.StatusUnauthorized, err.Error())
+			return
+		}
+		c.String(http.StatusOK, user)
+	})
+
+	w := performRequest(e, "GET", "/login")
+	cookies := w.Result().Cookies()
+	if len(cookies) != 2 {
+		t.Fatalf("want 2 cookies, got %v", w.Header()["Set-Cookie"])
+	}
+	user, theme := cookies[0], cookies[1]
+	if user.Path != "/" || user.MaxAge != 60 || !user.Secure || !user.HttpOnly || user.SameSite != http.SameSiteStrictMode {
+		t.Fatalf("unexpected user cookie %+v", user)
+	}
+	if theme.Path != "/app" || theme.SameSite != http.SameSiteLaxMode || theme.HttpOnly {
+		t.Fatalf("unexpected theme cookie %+v", theme)
+	}
+
+	req := httptest.NewRequest("GET", "/whoami", nil)
+	req.AddCookie(user)
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Body.String() != "张三 & co" {
+		t.Fatalf("cookie should round-trip, got %q", w.Body.String())
+	}
+	if w := performRequest(e, "GET", "/whoami"); w.Code != http.StatusUnauthorized || w.Body.String() != http.ErrNoCookie.Error() {
+		t.Fatalf("missing cookie: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestFileIfRange(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "video.bin")
+	if err := os.WriteFile(name, []byte("0123456789"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	modtime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	os.Chtimes(name, modtime, modtime)
+
+	e := New()
+	e.GET("/download", func(c *Context) {
+		c.File(name)
+	})
+	e.Static("/assets", dir)
+	get := func(path string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/download", nil)
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || w.Body.String() != "0123456789" || etag == "" || w.Header().Get("Date") == "" ||
+		w.Header().Get("Last-Modified") != modtime.UTC().Format(http.TimeFormat) {
+		t.Fatalf("unexpected full response %d %v", w.Code, w.Header())
+	}
+
+	lastModified := modtime.UTC().Format(http.TimeFormat)
+	for _, tt := range []struct {
+		path, ifRange string
+		code          int
+		body          string
+	}{
+		{"/download", etag, http.StatusPartialContent, "456"},
+		{"/download", lastModified, http.StatusPartialContent, "456"},
+		{"/download", `"stale"`, http.StatusOK, "0123456789"},
+		{"/download", "W/" + etag, http.StatusOK, "0123456789"},
+		{"/download", modtime.Add(-time.Hour).UTC().Format(http.TimeFormat), http.StatusOK, "0123456789"},
+		{"/assets/video.bin", etag, http.StatusPartialContent, "456"},
+		{"/assets/video.bin", `"stale"`, http.StatusOK, "0123456789"},
+	} {
+		w := get(tt.path, map[string]string{"Range": "bytes=4-6", "If-Range": tt.ifRange})
+		if w.Code != tt.code || w.Body.String() != tt.body {
+			t.Errorf("%s If-Range %s: want %d %q, got %d %q", tt.path, tt.ifRange, tt.code, tt.body, w.Code, w.Body.String())
+		}
+	}
+
+	// 刚修改的文件的日期不是强验证器，If-Range 日期不再匹配，避免拼接同一秒内修改前后的内容
+	now := time.Now()
+	os.Chtimes(name, now, now)
+	w = get("/download", map[string]string{"Range": "bytes=4-6", "If-Range": now.UTC().Format(http.TimeFormat)})
+	if w.Code != http.StatusOK {
+		t.Fatalf("weak date validator should serve the full file, got %d", w.Code)
+	}
+	if performRequest(e, "GET", "/assets/missing.bin").Code != http.StatusNotFound {
+		t.Fatal("missing static file should be 404")
+	}
+}
+
+func TestSLOTracker(t *testing.T) {
+	var events []string
+	slo := NewSLOTracker(SLOConfig{
+		Name:        "api",
+		Objective:   0.9,
+		Latency:     time.Hour,
+		BurnRate:    2,
+		MinRequests: 10,
+		OnBurn: func(status SLOStatus) {
+			events = append(events, fmt.Sprintf("burn %.1f", status.BurnRate))
+		},
+		OnRecover: func(status SLOStatus) {
+			events = append(events, "recover")
+		},
+	})
+	e := New()
+	api := e.Group("/api")
+	api.Use(slo.Middleware())
+	api.GET("/ok", func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	api.GET("/fail", func(c *Context) {
+		c.Fail(http.StatusBadGateway, "upstream down")
+	})
+
+	// 请求数达到 MinRequests 之前不判断
+	for i := 0; i < 5; i++ {
+		performRequest(e, "GET", "/api/fail")
+	}
+	if slo.Burning() || len(events) != 0 {
+		t.Fatalf("too few requests to burn, got %v", events)
+	}
+	for i := 0; i < 10; i++ {
+		performRequest(e, "GET", "/api/ok")
+	}
+	// 5/15 不达标，消耗速率为 (1/3)/0.1
+	status := slo.Status()
+	if status.Requests != 15 || status.Bad != 5 || !status.Burning || fmt.Sprintf("%.2f", status.BurnRate) != "3.33" {
+		t.Fatalf("unexpected status %+v", status)
+	}
+	if len(events) != 1 || events[0] != "burn 5.0" {
+		t.Fatalf("OnBurn should fire once when crossing the threshold, got %v", events)
+	}
+	for i := 0; i < 15; i++ {
+		performRequest(e, "GET", "/api/ok")
+	}
+	if slo.Burning() || len(events) != 2 || events[1] != "recover" {
+		t.Fatalf("OnRecover should fire once the burn rate drops, got %v %+v", events, slo.Status())
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	e := New()
+	e.GET("/ip", func(c *Context) {
+		c.String(http.StatusOK, c.ClientIP())
+	})
+	ip := func(remote string, header map[string]string) string {
+		req := httptest.NewRequest("GET", "/ip", nil)
+		req.RemoteAddr = remote
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		return w.Body.String()
+	}
+
+	// 默认不信任任何转发头部
+	if got := ip("203.0.113.9:5000", map[string]string{"X-Forwarded-For": "1.2.3.4", "CF-Connecting-IP": "5.6.7.8"}); got != "203.0.113.9" {
+		t.Fatalf("untrusted headers should be ignored, got %s", got)
+	}
+
+	e.TrustedProxies = []string{"10.0.0.0/8", "192.168.1.1"}
+	for _, tt := range []struct {
+		remote, forwarded, realIP, want string
+	}{
+		{"10.1.2.3:80", "198.51.100.7, 10.0.0.5", "", "198.51.100.7"},
+		{"10.1.2.3:80", "6.6.6.6, 198.51.100.7", "", "198.51.100.7"},
+		{"192.168.1.1:80", "", "198.51.100.8", "198.51.100.8"},
+		{"10.1.2.3:80", "garbage, 10.0.0.5", "", "10.1.2.3"},
+		{"203.0.113.9:80", "198.51.100.7", "", "203.0.113.9"},
+		{"[2001:db8::1]:443", "", "", "2001:db8::1"},
+	} {
+		header := map[string]string{}
+		if tt.forwarded != "" {
+			header["X-Forwarded-For"] = tt.forwarded
+		}
+		if tt.realIP != "" {
+			header["X-Real-IP"] = tt.realIP
+		}
+		if got := ip(tt.remote, header); got != tt.want {
+			t.Errorf("%s via %q: want %s, got %s", tt.remote, tt.forwarded, tt.want, got)
+		}
+	}
+
+	e.TrustedPlatform = PlatformCloudflare
+	if got := ip("172.70.1.1:80", map[string]string{"CF-Connecting-IP": "198.51.100.9", "X-Forwarded-For": "1.2.3.4"}); got != "198.51.100.9" {
+		t.Fatalf("platform header should win, got %s", got)
+	}
+	if got := ip("172.70.1.1:80", map[string]string{"CF-Connecting-IP": "not-an-ip"}); got != "172.70.1.1" {
+		t.Fatalf("invalid platform header should be ignored, got %s", got)
+	}
+}
+
+func TestHeaderAccessors(t *testing.T) {
+	request := func(header map[string]string) *Context {
+		req := httptest.NewRequest("GET", "/", nil)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		return newContext(httptest.NewRecorder(), req)
+	}
+
+	c := request(map[string]string{"content-type": "Application/JSON; charset=UTF-8", "X-Request-Id": "abc"})
+	if c.ContentType() != "application/json" || c.GetHeader("x-request-id") != "abc" || c.GetHeader("Missing") != "" {
+		t.Fatalf("unexpected accessors: %q %q", c.ContentType(), c.GetHeader("x-request-id"))
+	}
+
+	for _, tt := range []struct {
+		accept string
+		offers []string
+		want   string
+	}{
+		{"", []string{"application/json", "text/html"}, "application/json"},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", []string{"application/json", "text/html"}, "text/html"},
+		{"application/json;q=0.5, text/*", []string{"application/json", "text/plain"}, "text/plain"},
+		{"*/*", []string{"application/json", "text/html"}, "application/json"},
+		{"text/*, text/csv;q=0", []string{"text/csv", "text/plain"}, "text/plain"},
+		{"image/png", []string{"application/json"}, ""},
+	} {
+		if got := request(map[string]string{"Accept": tt.accept}).Accepts(tt.offers...); got != tt.want {
+			t.Errorf("Accept %q %v: want %q, got %q", tt.accept, tt.offers, tt.want, got)
+		}
+	}
+
+	if !request(map[string]string{"Connection": "keep-alive, Upgrade", "Upgrade": "WebSocket"}).IsWebsocket() {
+		t.Fatal("should detect websocket handshake")
+	}
+	if request(map[string]string{"Upgrade": "websocket"}).IsWebsocket() {
+		t.Fatal("Upgrade without Connection: upgrade isn't a handshake")
+	}
+}
+
+func TestQueryAccessors(t *testing.T) {
+	e := New()
+	e.GET("/search", func(c *Context) {
+		status, hasStatus := c.GetQuery("status")
+		c.JSON(http.StatusOK, H{
+			"page":      c.DefaultQuery("page", "1"),
+			"sort":      c.DefaultQuery("sort", "date"),
+			"status":    status,
+			"hasStatus": hasStatus,
+			"tags":      c.QueryArray("tag"),
+			"missing":   c.QueryArray("missing"),
+			"filter":    c.QueryMap("filter"),
+		})
+	})
+
+	w := performRequest(e, "GET", "/search?sort=&tag=go&tag=web&filter[state]=open&filter[owner]=me&filter=x&filterx[a]=b&status=")
+	want := `{"filter":{"owner":"me","state":"open"},"hasStatus":true,"missing":[],"page":"1","sort":"","status":"","tags":["go","web"]}`
+	if strings.TrimSpace(w.Body.String()) != want {
+		t.Fatalf("want %s, got %s", want, w.Body.String())
+	}
+}
+
+func TestPostFormAccessors(t *testing.T) {
+	e := New()
+	e.POST("/profile", func(c *Context) {
+		nick, hasNick := c.GetPostForm("nick")
+		_, hasAge := c.GetPostForm("age")
+		c.JSON(http.StatusOK, H{
+			"nick":    nick,
+			"hasNick": hasNick,
+			"hasAge":  hasAge,
+			"age":     c.PostForm("age"),
+			"lang":    c.DefaultPostForm("lang", "zh"),
+			"colors":  c.PostFormArray("color"),
+			"addr":    c.PostFormMap("addr"),
+		})
+	})
+
+	body := "nick=&color=red&color=blue&addr[city]=Beijing&addr[zip]=100000"
+	req := httptest.NewRequest("POST", "/profile", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	want := `{"addr":{"city":"Beijing","zip":"100000"},"age":"","colors":["red","blue"],"hasAge":false,"hasNick":true,"lang":"zh","nick":""}`
+	if strings.TrimSpace(w.Body.String()) != want {
+		t.Fatalf("want %s, got %s", want, w.Body.String())
+	}
+
+	// multipart 请求体同样支持
+	var mbody bytes.Buffer
+	mw := multipart.NewWriter(&mbody)
+	mw.WriteField("color", "green")
+	mw.WriteField("lang", "en")
+	mw.Close()
+	req = httptest.NewRequest("POST", "/profile", &mbody)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), `"colors":["green"]`) || !strings.Contains(w.Body.String(), `"lang":"en"`) {
+		t.Fatalf("multipart fields should be read, got %s", w.Body.String())
+	}
+
+	// 查询参数不能冒充表单字段
+	req = httptest.NewRequest("POST", "/profile?age=30&lang=fr&color=black", strings.NewReader("color=red"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), `"colors":["red"]`) || !strings.Contains(w.Body.String(), `"hasAge":false`) ||
+		!strings.Contains(w.Body.String(), `"age":""`) || !strings.Contains(w.Body.String(), `"lang":"zh"`) {
+		t.Fatalf("query parameters should not be read as form fields, got %s", w.Body.String())
+	}
+}
+
+func TestGetRawData(t *testing.T) {
+	e := New()
+	e.POST("/webhook", func(c *Context) {
+		payload, err := c.GetRawData()
+		if err != nil {
+			c.Fail(http.StatusBadRequest, err.Error())
+			return
+		}
+		again, _ := c.GetRawData()
+		var event struct {
+			Type string `json:"type"`
+		}
+		if c.BindJSON(&event) != nil {
+			return
+		}
+		c.String(http.StatusOK, "%d %s %v", len(payload), event.Type, bytes.Equal(payload, again))
+	})
+	e.POST("/limited", func(c *Context) {
+		if _, err := c.GetRawDataLimit(8); errors.Is(err, ErrRequestBodyTooLarge) {
+			// 请求体恢复完整，仍然可以流式处理
+			rest, _ := io.ReadAll(c.Req.Body)
+			c.String(http.StatusRequestEntityTooLarge, "%s", rest)
+			return
+		}
+		payload, _ := c.GetRawData()
+		c.String(http.StatusOK, "%s", payload)
+	})
+
+	post := func(path string, body string, chunked bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", path, strings.NewReader(body))
+		if chunked {
+			req.ContentLength = -1
+		}
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		return w
+	}
+	if w := post("/webhook", `{"type":"push"}`, false); w.Body.String() != "15 push true" {
+		t.Fatalf("raw data should be cached for later binds, got %d %s", w.Code, w.Body.String())
+	}
+	if w := post("/limited", "short", false); w.Code != http.StatusOK || w.Body.String() != "short" {
+		t.Fatalf("small body: %d %s", w.Code, w.Body.String())
+	}
+	for _, chunked := range []bool{false, true} {
+		if w := post("/limited", "much too long", chunked); w.Code != http.StatusRequestEntityTooLarge || w.Body.String() != "much too long" {
+			t.Fatalf("chunked=%v: large body should be rejected and restored, got %d %q", chunked, w.Code, w.Body.String())
+		}
+	}
+
+	// GetRawData 和 ShouldBindBodyWith 不超过 Engine.MaxBodyBytes
+	e.MaxBodyBytes = 8
+	e.POST("/bind", func(c *Context) {
+		var event struct {
+			Type string `json:"type"`
+		}
+		if c.BindBodyWith(&event, JSONBinding) != nil {
+			return
+		}
+		c.String(http.StatusOK, event.Type)
+	})
+	for _, chunked := range []bool{false, true} {
+		if w := post("/webhook", `{"type":"push"}`, chunked); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), ErrRequestBodyTooLarge.Error()) {
+			t.Fatalf("chunked=%v: GetRawData should stop at MaxBodyBytes, got %d %q", chunked, w.Code, w.Body.String())
+		}
+		if w := post("/bind", `{"type":"push"}`, chunked); w.Code != http.StatusRequestEntityTooLarge {
+			t.Fatalf("chunked=%v: BindBodyWith should respond 413, got %d %q", chunked, w.Code, w.Body.String())
+		}
+	}
+	if w := post("/bind", `{"a":1}`, false); w.Code != http.StatusOK {
+		t.Fatalf("a body within MaxBodyBytes should bind, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestYAMLAndTOML(t *testing.T) {
+	type limits struct {
+		MaxConns int      `yaml:"max_conns" toml:"max_conns"`
+		Hosts    []string `yaml:"hosts" toml:"hosts"`
+	}
+	snapshot := limits{MaxConns: 64, Hosts: []string{"a", "b"}}
+	e := New()
+	e.GET("/config.yaml", func(c *Context) {
+		c.YAML(http.StatusOK, snapshot)
+	})
+	e.GET("/config.toml", func(c *Context) {
+		c.TOML(http.StatusOK, snapshot)
+	})
+	e.GET("/bad.toml", func(c *Context) {
+		c.TOML(http.StatusOK, H{"ch": make(chan int)})
+	})
+
+	w := performRequest(e, "GET", "/config.yaml")
+	if w.Header().Get("Content-Type") != "application/yaml" || w.Body.String() != "max_conns: 64\nhosts:\n    - a\n    - b\n" {
+		t.Fatalf("unexpected yaml %s %q", w.Header().Get("Content-Type"), w.Body.String())
+	}
+	w = performRequest(e, "GET", "/config.toml")
+	if w.Header().Get("Content-Type") != "application/toml" || w.Body.String() != "max_conns = 64\nhosts = [\"a\", \"b\"]\n" {
+		t.Fatalf("unexpected toml %s %q", w.Header().Get("Content-Type"), w.Body.String())
+	}
+	// 编码失败时不写出半截的响应
+	if w := performRequest(e, "GET", "/bad.toml"); w.Code != http.StatusInternalServerError {
+		t.Fatalf("encoding error should be 500, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestProtoBuf(t *testing.T) {
+	e := New()
+	e.POST("/echo", func(c *Context) {
+		var msg wrapperspb.StringValue
+		if c.BindProtoBuf(&msg) != nil {
+			return
+		}
+		c.ProtoBuf(http.StatusOK, wrapperspb.String(strings.ToUpper(msg.GetValue())))
+	})
+	e.POST("/any", func(c *Context) {
+		var msg wrapperspb.Int64Value
+		if c.MustBind(&msg) != nil {
+			return
+		}
+		c.String(http.StatusOK, "%d", msg.GetValue())
+	})
+	post := func(path string, body []byte) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-protobuf")
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		return w
+	}
+
+	body, _ := proto.Marshal(wrapperspb.String("zinc"))
+	w := post("/echo", body)
+	var resp wrapperspb.StringValue
+	if err := proto.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.GetValue() != "ZINC" || w.Header().Get("Content-Type") != "application/x-protobuf" {
+		t.Fatalf("unexpected response %d %s %v", w.Code, w.Header().Get("Content-Type"), err)
+	}
+	// ShouldBind 按 Content-Type 选择 Protocol Buffers
+	body, _ = proto.Marshal(wrapperspb.Int64(42))
+	if w := post("/any", body); w.Body.String() != "42" {
+		t.Fatalf("MustBind should decode protobuf, got %d %s", w.Code, w.Body.String())
+	}
+	if w := post("/echo", []byte{0xff, 0xff}); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), ReasonSyntax) {
+		t.Fatalf("malformed protobuf should be rejected, got %d %s", w.Code, w.Body.String())
+	}
+	// 请求体整个读入内存，不超过 Engine.MaxBodyBytes
+	e.MaxBodyBytes = 8
+	body, _ = proto.Marshal(wrapperspb.String(strings.Repeat("z", 16)))
+	if w := post("/echo", body); w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("oversized protobuf should be rejected, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+// countingCodec 记录调用次数的 Codec，内部使用JSON
+type countingCodec struct {
+	calls int32
+}
+
+func (c *countingCodec) Marshal(v interface{}) ([]byte, error) {
+	atomic.AddInt32(&c.calls, 1)
+	return json.Marshal(v)
+}
+
+func (c *countingCodec) Unmarshal(data []byte, v interface{}) error {
+	atomic.AddInt32(&c.calls, 1)
+	return json.Unmarshal(data, v)
+}
+
+func TestMsgPack(t *testing.T) {
+	type point struct {
+		X    int    `msgpack:"x" json:"x"`
+		Y    int    `msgpack:"y" json:"y" validate:"max=100"`
+		Name string `msgpack:"name" json:"name"`
+	}
+	e := New()
+	e.POST("/move", func(c *Context) {
+		var p point
+		if c.BindMsgPack(&p) != nil {
+			return
+		}
+		p.X++
+		c.MsgPack(http.StatusOK, p)
+	})
+	e.POST("/auto", func(c *Context) {
+		var p point
+		if c.MustBind(&p) != nil {
+			return
+		}
+		c.String(http.StatusOK, p.Name)
+	})
+	post := func(path string, body []byte) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/msgpack")
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		return w
+	}
+
+	body, _ := msgpack.Marshal(point{X: 1, Y: 2, Name: "p"})
+	w := post("/move", body)
+	var got point
+	if err := msgpack.Unmarshal(w.Body.Bytes(), &got); err != nil || got != (point{X: 2, Y: 2, Name: "p"}) || w.Header().Get("Content-Type") != "application/msgpack" {
+		t.Fatalf("unexpected response %d %+v %v", w.Code, got, err)
+	}
+	if w := post("/auto", body); w.Body.String() != "p" {
+		t.Fatalf("MustBind should select msgpack by Content-Type, got %d %s", w.Code, w.Body.String())
+	}
+	body, _ = msgpack.Marshal(point{Y: 200})
+	if w := post("/move", body); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"field":"y"`) {
+		t.Fatalf("validation should use msgpack field names, got %d %s", w.Code, w.Body.String())
+	}
+
+	// 替换编解码器
+	codec := &countingCodec{}
+	e.MsgPackCodec = codec
+	if w := post("/move", []byte(`{"x":5,"name":"q"}`)); strings.TrimSpace(w.Body.String()) != `{"x":6,"y":0,"name":"q"}` || atomic.LoadInt32(&codec.calls) != 2 {
+		t.Fatalf("custom codec should be used, got %s after %d calls", w.Body.String(), codec.calls)
+	}
+	e.POST("/twice", func(c *Context) {
+		var p point
+		if c.BindBodyWith(&p, MsgPackBinding) != nil {
+			return
+		}
+		c.String(http.StatusOK, p.Name)
+	})
+	if w := post("/twice", []byte(`{"name":"r"}`)); w.Body.String() != "r" || atomic.LoadInt32(&codec.calls) != 3 {
+		t.Fatalf("BindBodyWith should use the custom codec, got %d %s", w.Code, w.Body.String())
+	}
+	// 请求体整个读入内存，不超过 Engine.MaxBodyBytes
+	e.MaxBodyBytes = 8
+	if w := post("/move", []byte(`{"name":"too long"}`)); w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("oversized msgpack should be rejected, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestJSONVariants(t *testing.T) {
+	e := New()
+	e.GET("/indented", func(c *Context) {
+		c.IndentedJSON(http.StatusOK, H{"name": "zinc"})
+	})
+	e.GET("/pure", func(c *Context) {
+		c.PureJSON(http.StatusOK, H{"html": "<b>&</b>"})
+	})
+	e.GET("/ascii", func(c *Context) {
+		c.AsciiJSON(http.StatusOK, H{"lang": "中文😀"})
+	})
+	e.GET("/secure", func(c *Context) {
+		c.SecureJSON(http.StatusOK, []string{"a", "b"})
+	})
+	e.GET("/secure-object", func(c *Context) {
+		c.SecureJSON(http.StatusOK, H{"a": 1})
+	})
+
+	cases := []struct {
+		path string
+		body string
+	}{
+		{"/indented", "{\n    \"name\": \"zinc\"\n}"},
+		{"/pure", "{\"html\":\"<b>&</b>\"}\n"},
+		{"/ascii", `{"lang":"\u4e2d\u6587\ud83d\ude00"}`},
+		{"/secure", `while(1);["a","b"]`},
+		// 对象不能作为脚本执行，不加前缀
+		{"/secure-object", `{"a":1}`},
+	}
+	for _, tc := range cases {
+		w := performRequest(e, "GET", tc.path)
+		if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json" || w.Body.String() != tc.body {
+			t.Fatalf("%s: unexpected response %d %s %q", tc.path, w.Code, w.Header().Get("Content-Type"), w.Body.String())
+		}
+	}
+
+	e.SecureJSONPrefix = ")]}',\n"
+	if w := performRequest(e, "GET", "/secure"); w.Body.String() != ")]}',\n[\"a\",\"b\"]" {
+		t.Fatalf("custom prefix not applied: %q", w.Body.String())
+	}
+}
+
+func TestJSONHandler(t *testing.T) {
+	type greetReq struct {
+		Name string `json:"name" validate:"max=8"`
+	}
+	type greetResp struct {
+		Greeting string `json:"greeting"`
+	}
+	greet := JSONHandler(func(c *Context, req greetReq) (greetResp, error) {
+		switch req.Name {
+		case "":
+			return greetResp{}, NewHTTPError(http.StatusUnprocessableEntity, "name is required", nil)
+		case "db":
+			return greetResp{}, errors.New("dial tcp 10.0.0.5:5432: connection refused")
+		case "gone":
+			return greetResp{}, errNotFound
+		}
+		return greetResp{Greeting: "hello " + req.Name}, nil
+	})
+	plain := New()
+	plain.POST("/greet", greet)
+	handled := New()
+	handled.Use(ErrorHandlerWith(ErrorHandlerConfig{Map: func(err error) *HTTPError {
+		if errors.Is(err, errNotFound) {
+			return NewHTTPError(http.StatusNotFound, "no such user", err)
+		}
+		return nil
+	}}))
+	handled.POST("/greet", greet)
+	strict := New()
+	strict.DisallowUnknownFields = true
+	strict.POST("/greet", greet)
+
+	for _, tt := range []struct {
+		engine *Engine
+		body   string
+		code   int
+		want   string
+	}{
+		{plain, `{"name":"zinc"}`, http.StatusOK, `{"greeting":"hello zinc"}`},
+		{plain, `{"name":`, http.StatusBadRequest, "truncated"},
+		{strict, `{"name":"zinc","admin":true}`, http.StatusBadRequest, "unknown field"},
+		{plain, `{}`, http.StatusUnprocessableEntity, "name is required"},
+		{plain, ``, http.StatusUnprocessableEntity, "name is required"},
+		{plain, `{"name":"a very long name"}`, http.StatusBadRequest, "name"},
+		// 没有 ErrorHandler 时内部错误也不返回给客户端
+		{plain, `{"name":"db"}`, http.StatusInternalServerError, "Internal Server Error"},
+		{handled, `{"name":"gone"}`, http.StatusNotFound, "no such user"},
+		{handled, `{"name":"db"}`, http.StatusInternalServerError, "Internal Server Error"},
+	} {
+		req := httptest.NewRequest("POST", "/greet", strings.NewReader(tt.body))
+		w := httptest.NewRecorder()
+		tt.engine.ServeHTTP(w, req)
+		if w.Code != tt.code || !strings.Contains(w.Body.String(), tt.want) || strings.Contains(w.Body.String(), "10.0.0.5") {
+			t.Errorf("%s: want %d %q, got %d %q", tt.body, tt.code, tt.want, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestRedactor(t *testing.T) {
+	r := DefaultRedactor()
+	r.Patterns = []*regexp.Regexp{regexp.MustCompile(`^card\.`)}
+
+	if got := r.RedactURI("/login?user=zinc&token=abc&next=%2Fhome&token=def"); got != "/login?user=zinc&token=%2A%2A%2A%2A%2A%2A&next=%2Fhome&token=%2A%2A%2A%2A%2A%2A" {
+		t.Fatalf("query order and encoding should be kept, got %s", got)
+	}
+	if got := r.RedactURI("/search?q=zinc"); got != "/search?q=zinc" {
+		t.Fatalf("uri without sensitive params should be unchanged, got %s", got)
+	}
+
+	header := http.Header{"Authorization": {"Bearer secret"}, "Accept": {"*/*"}}
+	redacted := r.RedactHeader(header)
+	if redacted.Get("Authorization") != "******" || redacted.Get("Accept") != "*/*" || header.Get("Authorization") != "Bearer secret" {
+		t.Fatalf("unexpected redacted header %v (original %v)", redacted, header)
+	}
+
+	body := r.RedactJSON([]byte(`{"user":{"name":"zinc","password":"p"},"card":{"number":"4111"},"items":[{"token":"t"}],"id":12345678901234567890}`))
+	if string(body) != `{"card":{"number":"******"},"id":12345678901234567890,"items":[{"token":"******"}],"user":{"name":"zinc","password":"******"}}` {
+		t.Fatalf("unexpected redacted json %s", body)
+	}
+	for _, bad := range []string{"not json", `{"password":"hunter2"`, "{\"a\":1}\n{\"password\":\"hunter2\"}"} {
+		if got := r.RedactJSON([]byte(bad)); string(got) != "[unredactable body]" {
+			t.Fatalf("%q should not be logged as is, got %s", bad, got)
+		}
+	}
+}
+
+func TestRecoveryRedaction(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	var reportedURI, reportedAuth string
+	var reportedBody []byte
+	e := New()
+	e.PanicReporter = func(c *Context, err interface{}, stack string, severity Severity) {
+		reportedURI, reportedAuth = c.Req.URL.String(), c.Req.Header.Get("Authorization")
+		reportedBody, _ = c.GetRawData()
+	}
+	e.Use(Recovery())
+	e.POST("/login", func(c *Context) {
+		c.GetRawData()
+		panic("boom")
+	})
+	req := httptest.NewRequest("POST", "/login?token=abc", strings.NewReader(`{"password":"hunter2"}`))
+	req.Header.Set("Authorization", "Bearer s3cr3t")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+
+	reported := reportedURI + reportedAuth + string(reportedBody)
+	for _, leaked := range []string{"abc", "hunter2", "s3cr3t"} {
+		if strings.Contains(buf.String(), leaked) || strings.Contains(reported, leaked) {
+			t.Fatalf("panic log or report leaks %q: %s %s", leaked, buf.String(), reported)
+		}
+	}
+	if !strings.Contains(buf.String(), "POST /login?token=") || reportedAuth != "******" {
+		t.Fatalf("panic log and report should keep the redacted request, got %s %s", buf.String(), reported)
+	}
+}
+
+func TestLoggerRedaction(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	e := New()
+	e.Use(LoggerWith(LoggerConfig{LogHeaders: true, LogBody: true}))
+	e.POST("/login", func(c *Context) {
+		var form struct {
+			User string `json:"user"`
+		}
+		c.ShouldBindBodyWith(&form, JSONBinding)
+		c.String(http.StatusOK, form.User)
+	})
+	req := httptest.NewRequest("POST", "/login?token=abc", strings.NewReader(`{"user":"zinc","password":"hunter2"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Cookie", "session=s3cr3t")
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+
+	line := buf.String()
+	for _, leaked := range []string{"abc", "hunter2", "s3cr3t"} {
+		if strings.Contains(line, leaked) {
+			t.Fatalf("log line leaks %q: %s", leaked, line)
+		}
+	}
+	if !strings.Contains(line, `"user":"zinc"`) || !strings.Contains(line, "Cookie: ******") || !strings.Contains(line, "[200] /login?token=") {
+		t.Fatalf("unexpected log line %s", line)
+	}
+}
+
+func TestRewritePrefixBoundaries(t *testing.T) {
+	for _, tt := range []struct {
+		rule       RewriteRule
+		path, want string
+	}{
+		{StripPrefix("/v1"), "/v1/users", "/users"},
+		{StripPrefix("/v1/"), "/v1/users", "/users"},
+		{StripPrefix("/v1"), "/v1", "/"},
+		{StripPrefix("/v1"), "/v10/users", "/v10/users"},
+		{AddPrefix("/v1"), "/users", "/v1/users"},
+		{AddPrefix("/v1"), "/v1/users", "/v1/users"},
+		{AddPrefix("/v1"), "/v10/users", "/v1/v10/users"},
+	} {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		tt.rule(req)
+		if req.URL.Path != tt.want {
+			t.Errorf("%s should be rewritten to %s, got %s", tt.path, tt.want, req.URL.Path)
+		}
+	}
+}
+
+func TestPagination(t *testing.T) {
+	e := New()
+	e.GET("/items", func(c *Context) {
+		p := c.Pagination()
+		c.Paginated(http.StatusOK, p, 45, []int{p.Offset()})
+	})
+	e.GET("/empty", func(c *Context) {
+		c.Paginated(http.StatusOK, c.Pagination(), 0, []int{})
+	})
+	e.GET("/manual", func(c *Context) {
+		// 手动构造的 Pagination 没有设置 Limit
+		c.Paginated(http.StatusOK, Pagination{Page: 1}, 45, nil)
+	})
+
+	for _, tt := range []struct {
+		path  string
+		rels  []string
+		body  string
+		total string
+	}{
+		{"/items?limit=20", []string{"first", "next", "last"}, `"data":[0],"page":1,"limit":20`, "45"},
+		{"/items?page=2&limit=20", []string{"first", "prev", "next", "last"}, `"data":[20],"page":2`, "45"},
+		{"/items?page=3&limit=20", []string{"first", "prev", "last"}, `"data":[40],"page":3`, "45"},
+		{"/items?page=0&limit=1000", []string{"first", "last"}, `"page":1,"limit":100`, "45"},
+		{"/empty", []string{"first", "last"}, `"data":[]`, "0"},
+		{"/manual", []string{"first", "next", "last"}, `"limit":20`, "45"},
+	} {
+		w := performRequest(e, "GET", tt.path)
+		link := w.Header().Get("Link")
+		var rels []string
+		for _, part := range strings.Split(link, ", ") {
+			rels = append(rels, part[strings.Index(part, `rel="`)+5:len(part)-1])
+		}
+		if strings.Join(rels, ",") != strings.Join(tt.rels, ",") || !strings.Contains(w.Body.String(), tt.body) || w.Header().Get("X-Total-Count") != tt.total {
+			t.Errorf("%s: unexpected response %s %s %q", tt.path, link, w.Header().Get("X-Total-Count"), w.Body.String())
+		}
+	}
+	if w := performRequest(e, "GET", "/items?page=3&limit=20"); !strings.Contains(w.Header().Get("Link"), `</items?limit=20&page=3>; rel="last"`) {
+		t.Fatalf("last link should point at page 3, got %s", w.Header().Get("Link"))
+	}
+}
+
+func TestQueryDSL(t *testing.T) {
+	config := QueryDSLConfig{SortFields: []string{"created_at", "name"}, FilterFields: []string{"status"}}
+	e := New()
+	e.GET("/orders", func(c *Context) {
+		dsl, err := c.BindQueryDSL(config)
+		if err != nil {
+			return
+		}
+		c.JSON(http.StatusOK, dsl)
+	})
+
+	w := performRequest(e, "GET", "/orders?sort=-created_at,+name,&filter[status]=active")
+	if w.Code != http.StatusOK || w.Body.String() != `{"Sort":[{"Field":"created_at","Desc":true},{"Field":"name","Desc":false}],"Filter":{"status":"active"}}`+"\n" {
+		t.Fatalf("unexpected dsl %d %s", w.Code, w.Body.String())
+	}
+	w = performRequest(e, "GET", "/orders")
+	if w.Code != http.StatusOK || w.Body.String() != `{"Sort":null,"Filter":{}}`+"\n" {
+		t.Fatalf("empty query should give an empty dsl, got %d %s", w.Code, w.Body.String())
+	}
+	for _, path := range []string{"/orders?sort=password", "/orders?filter[owner]=1", "/orders?sort=-name&filter[status]=a&filter[secret]=b"} {
+		if w := performRequest(e, "GET", path); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "unknown") {
+			t.Errorf("%s should be rejected, got %d %s", path, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestLoadShedding(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	e := New()
+	e.Use(LoadShedding(LoadShedConfig{MaxInFlight: 2}))
+	e.GET("/block", func(c *Context) {
+		started <- struct{}{}
+		<-release
+	})
+	e.GET("/", func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			performRequest(e, "GET", "/block")
+		}()
+		<-started
+	}
+	// 默认不信任客户端声明的优先级
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Priority", "critical")
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("X-Priority should be ignored by default, got %d", w.Code)
+	}
+	close(release)
+	wg.Wait()
+	if w := performRequest(e, "GET", "/"); w.Code != http.StatusOK {
+		t.Fatalf("request should pass after load drops, got %d", w.Code)
+	}
+
+	// 并发请求不能同时通过在途请求数检查
+	var admitted int64
+	hold := make(chan struct{})
+	e = New()
+	e.Use(LoadShedding(LoadShedConfig{MaxInFlight: 4}))
+	e.GET("/", func(c *Context) {
+		atomic.AddInt64(&admitted, 1)
+		<-hold
+	})
+	var shed int64
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			e.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+			if w.Code == http.StatusServiceUnavailable {
+				atomic.AddInt64(&shed, 1)
+			}
+		}()
+	}
+	// 被放行的请求阻塞在 Handler 中，其余请求被拒绝
+	for atomic.LoadInt64(&admitted)+atomic.LoadInt64(&shed) < 32 {
+		time.Sleep(time.Millisecond)
+	}
+	close(hold)
+	wg.Wait()
+	if n := atomic.LoadInt64(&admitted); n != 4 {
+		t.Fatalf("expected 4 admitted requests, got %d", n)
+	}
+}
+
+func TestLoadSheddingLatencyRecovers(t *testing.T) {
+	e := New()
+	e.Use(LoadShedding(LoadShedConfig{MaxLatency: 20 * time.Millisecond, Classify: PriorityFromHeader}))
+	e.GET("/slow", func(c *Context) {
+		time.Sleep(200 * time.Millisecond)
+	})
+	e.GET("/", func(c *Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	low := func() int {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Priority", "low")
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	performRequest(e, "GET", "/slow")
+	if code := low(); code != http.StatusOK {
+		t.Fatalf("first low priority request after a slow one is a probe, got %d", code)
+	}
+	if code := low(); code != http.StatusServiceUnavailable {
+		t.Fatalf("low priority request should be shed while slow, got %d", code)
+	}
+	// 只有低优先级请求时，探测请求使平均耗时逐渐下降
+	deadline := time.Now().Add(2 * time.Second)
+	for low() != http.StatusOK || low() != http.StatusOK {
+		if time.Now().After(deadline) {
+			t.Fatal("load shedding should recover once requests are fast again")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestHTTPClient(t *testing.T) {
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Request-Id")))
+	}))
+	defer downstream.Close()
+
+	e := New()
+	e.GET("/", func(c *Context) {
+		out, _ := http.NewRequest("GET", downstream.URL, nil)
+		resp, err := c.HTTPClient().Do(out)
+		if err != nil {
+			c.String(http.StatusBadGateway, err.Error())
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		if out.Header.Get("X-Request-Id") != "" {
+			c.String(http.StatusInternalServerError, "outbound request was modified")
+			return
+		}
+		c.String(http.StatusOK, "%s %d", body, len(c.OutboundCalls()))
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Request-Id", "abc")
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != "abc 1" {
+		t.Fatalf("expected propagated request id and one recorded call, got %d %q", w.Code, w.Body.String())
+	}
+
+	// 没有设置 context 的下游请求继承当前请求的取消信号
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req = httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Code != http.StatusBadGateway {
+		t.Fatalf("outbound call should be canceled with the request, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestHTMLFragment(t *testing.T) {
+	e := New()
+	e.GET("/none", func(c *Context) {
+		c.HTMLFragment(http.StatusOK, "row", nil)
+	})
+	if w := performRequest(e, "GET", "/none"); w.Code != http.StatusInternalServerError {
+		t.Fatalf("rendering without templates should fail with 500, got %d", w.Code)
+	}
+
+	dir := t.TempDir()
+	page := `{{define "page"}}<ul>{{template "row" .}}</ul>{{end}}{{define "row"}}<li>{{currentUser}}: {{.}}</li>{{end}}`
+	if err := os.WriteFile(filepath.Join(dir, "page.tmpl"), []byte(page), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	e.SetContextFuncMap(template.FuncMap{
+		"currentUser": func(c *Context) string {
+			return c.Param("user")
+		},
+	})
+	e.LoadHTMLGlob(filepath.Join(dir, "*"))
+	e.GET("/:user", func(c *Context) {
+		c.HTMLOrFragment(http.StatusOK, "page", "row", "hi")
+	})
+	e.GET("/:user/missing", func(c *Context) {
+		c.HTMLFragment(http.StatusOK, "missing", nil)
+	})
+
+	req := httptest.NewRequest("GET", "/alice", nil)
+	req.Header.Set("HX-Request", "true")
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Body.String() != "<li>alice: hi</li>" {
+		t.Fatalf("HTMX request should render the fragment, got %q", w.Body.String())
+	}
+	// 渲染片段之后仍然可以渲染完整页面
+	if w := performRequest(e, "GET", "/bob"); w.Body.String() != "<ul><li>bob: hi</li></ul>" {
+		t.Fatalf("full page should render after a fragment, got %d %q", w.Code, w.Body.String())
+	}
+	if w := performRequest(e, "GET", "/bob/missing"); w.Code != http.StatusInternalServerError {
+		t.Fatalf("undefined fragment should fail with 500, got %d", w.Code)
+	}
+}
+
+func TestBodyLimit(t *testing.T) {
+	e := New()
+	e.Use(BodyLimit(8))
+	e.POST("/", func(c *Context) {
+		data, err := io.ReadAll(c.Req.Body)
+		if err != nil {
+			c.String(http.StatusRequestEntityTooLarge, "read %d", len(data))
+			return
+		}
+		c.String(http.StatusOK, "%s", data)
+	})
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader("small"))
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != "small" {
+		t.Fatalf("body within the limit should pass, got %d %q", w.Code, w.Body.String())
+	}
+
+	// 声明的长度超过上限时不读取请求体
+	req = httptest.NewRequest("POST", "/", strings.NewReader("0123456789"))
+	req.Header.Set("Expect", "100-continue")
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge || w.Header().Get("Connection") != "close" {
+		t.Fatalf("declared oversized body should be rejected, got %d", w.Code)
+	}
+
+	// 未声明长度的请求体最多读取 limit 字节
+	req = httptest.NewRequest("POST", "/", io.MultiReader(strings.NewReader("01234"), strings.NewReader("56789")))
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge || w.Body.String() != "read 8" {
+		t.Fatalf("undeclared oversized body should be cut at the limit, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestRouteMutationAfterFreeze(t *testing.T) {
+	e := New()
+	route := e.GET("/users", func(c *Context) {
+		c.String(http.StatusOK, "%v", c.RouteInfo().Metadata["auth"])
+	}).Meta("auth", true)
+	e.Freeze()
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					performRequest(e, "GET", "/users")
+				}
+			}
+		}()
+	}
+	mutations := map[string]func(){
+		"Use":        func() { route.Use(func(c *Context) {}) },
+		"Meta":       func() { route.Meta("auth", false) },
+		"Named":      func() { route.Named("users") },
+		"Critical":   func() { route.Critical() },
+		"NoCompress": func() { route.NoCompress() },
+		"Consumes":   func() { route.Consumes(H{}) },
+		"Canary":     func() { route.Canary(func(c *Context) {}, CanaryConfig{Percent: 50}) },
+		"OnPanic":    func() { e.Group("/admin").OnPanic(PanicConfig{Policy: PanicRepanic}) },
+	}
+	for name, mutate := range mutations {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s after Freeze should panic", name)
+				}
+			}()
+			mutate()
+		}()
+	}
+	close(stop)
+	wg.Wait()
+	if w := performRequest(e, "GET", "/users"); w.Body.String() != "true" {
+		t.Fatalf("route should be unchanged, got %q", w.Body.String())
+	}
+}
+
+func TestPreAbort(t *testing.T) {
+	e := New()
+	e.Pre(func(c *Context) {
+		if c.Query("deny") != "" {
+			c.Abort()
+		}
+	})
+	var served bool
+	e.GET("/", func(c *Context) {
+		served = true
+	})
+	performRequest(e, "GET", "/?deny=1")
+	if served {
+		t.Fatal("aborting in a pre handler should stop routing")
+	}
+	performRequest(e, "GET", "/")
+	if !served {
+		t.Fatal("request should be routed when pre handlers don't abort")
+	}
+}